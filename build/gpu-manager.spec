@@ -27,6 +27,8 @@ install -d $RPM_BUILD_ROOT/etc/gpu-manager
 
 install -p -m 755 ./go/bin/gpu-manager $RPM_BUILD_ROOT/%{_bindir}/
 install -p -m 755 ./go/bin/gpu-client $RPM_BUILD_ROOT/%{_bindir}/
+install -p -m 755 ./go/bin/gpu-scheduler-extender $RPM_BUILD_ROOT/%{_bindir}/
+install -p -m 755 ./go/bin/gpu-admission-webhook $RPM_BUILD_ROOT/%{_bindir}/
 
 install -p -m 644 ./build/extra-config.json $RPM_BUILD_ROOT/etc/gpu-manager/
 install -p -m 644 ./build/gpu-manager.conf $RPM_BUILD_ROOT/etc/gpu-manager/
@@ -44,5 +46,7 @@ rm -rf $RPM_BUILD_ROOT
 
 /%{_bindir}/gpu-manager
 /%{_bindir}/gpu-client
+/%{_bindir}/gpu-scheduler-extender
+/%{_bindir}/gpu-admission-webhook
 
 /%{_unitdir}/gpu-manager.service
@@ -19,7 +19,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	goflag "flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	vcudaapi "tkestack.io/gpu-manager/pkg/api/runtime/vcuda"
 	"tkestack.io/gpu-manager/pkg/flags"
@@ -33,6 +38,16 @@ import (
 
 var (
 	addr, busID, podUID, contName, contID string
+	processes                             []string
+)
+
+// Commands of gpu-client, register is the default one for compatibility
+const (
+	registerCmd  = "register"
+	configCmd    = "config"
+	watchCmd     = "watch"
+	usageCmd     = "usage"
+	heartbeatCmd = "heartbeat"
 )
 
 func main() {
@@ -43,13 +58,20 @@ func main() {
 	cmdFlags.StringVar(&podUID, "pod-uid", "", "Pod UID of caller")
 	cmdFlags.StringVar(&contName, "cont-name", "", "Container name of caller")
 	cmdFlags.StringVar(&contID, "cont-id", "", "Container id of calller")
+	cmdFlags.StringArrayVar(&processes, "process", nil,
+		"Usage of a process for usage command, in the format of <pid>:<bus-id>:<used-memory>:<sm-time>")
 
 	flags.InitFlags()
 	goflag.CommandLine.Parse([]string{})
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
-	if len(addr) == 0 || len(podUID) == 0 || (len(contName) == 0 && len(contID) == 0) {
+	cmd := registerCmd
+	if cmdFlags.NArg() > 0 {
+		cmd = cmdFlags.Arg(0)
+	}
+
+	if len(addr) == 0 || len(podUID) == 0 || (cmd == registerCmd && len(contName) == 0 && len(contID) == 0) {
 		klog.Fatalf("argument is empty, current: %s", cmdFlags.Args())
 	}
 
@@ -62,6 +84,27 @@ func main() {
 	client := vcudaapi.NewVCUDAServiceClient(conn)
 	ctx := context.TODO()
 
+	switch cmd {
+	case registerCmd:
+		err = register(ctx, client)
+	case configCmd:
+		err = getConfig(ctx, client)
+	case watchCmd:
+		err = watchConfig(ctx, client)
+	case usageCmd:
+		err = reportUsage(ctx, client)
+	case heartbeatCmd:
+		err = heartbeat(ctx, client)
+	default:
+		klog.Fatalf("unknown command %s", cmd)
+	}
+
+	if err != nil {
+		klog.Fatalf("fail to get response from manager, error %v", err)
+	}
+}
+
+func register(ctx context.Context, client vcudaapi.VCUDAServiceClient) error {
 	req := &vcudaapi.VDeviceRequest{
 		BusId:         busID,
 		PodUid:        podUID,
@@ -73,8 +116,100 @@ func main() {
 		req.ContainerId = contID
 	}
 
-	_, err = client.RegisterVDevice(ctx, req)
+	_, err := client.RegisterVDevice(ctx, req)
+	return err
+}
+
+func getConfig(ctx context.Context, client vcudaapi.VCUDAServiceClient) error {
+	config, err := client.GetConfig(ctx, &vcudaapi.ConfigRequest{PodUid: podUID, ContainerId: contID})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(config)
+}
+
+func watchConfig(ctx context.Context, client vcudaapi.VCUDAServiceClient) error {
+	stream, err := client.WatchConfig(ctx, &vcudaapi.ConfigRequest{PodUid: podUID, ContainerId: contID})
 	if err != nil {
-		klog.Fatalf("fail to get response from manager, error %v", err)
+		return err
+	}
+
+	for {
+		config, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := printJSON(config); err != nil {
+			return err
+		}
 	}
 }
+
+func reportUsage(ctx context.Context, client vcudaapi.VCUDAServiceClient) error {
+	req := &vcudaapi.UsageRequest{PodUid: podUID, ContainerId: contID}
+	for _, p := range processes {
+		usage, err := parseProcessUsage(p)
+		if err != nil {
+			return err
+		}
+		req.Processes = append(req.Processes, usage)
+	}
+
+	_, err := client.ReportUsage(ctx, req)
+	return err
+}
+
+func heartbeat(ctx context.Context, client vcudaapi.VCUDAServiceClient) error {
+	resp, err := client.Heartbeat(ctx, &vcudaapi.HeartbeatRequest{PodUid: podUID, ContainerId: contID})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(resp.Generation)
+	return nil
+}
+
+// parseProcessUsage parses <pid>:<bus-id>:<used-memory>:<sm-time>, bus id
+// contains colons itself
+func parseProcessUsage(s string) (*vcudaapi.ProcessUsage, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid process usage %s", s)
+	}
+
+	n := len(fields)
+	pid, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid of %s, %v", s, err)
+	}
+	usedMemory, err := strconv.ParseUint(fields[n-2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid used memory of %s, %v", s, err)
+	}
+	smTime, err := strconv.ParseUint(fields[n-1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sm time of %s, %v", s, err)
+	}
+
+	return &vcudaapi.ProcessUsage{
+		Pid:        int32(pid),
+		BusId:      strings.Join(fields[1:n-2], ":"),
+		UsedMemory: usedMemory,
+		SmTime:     smTime,
+	}, nil
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog"
+
+	"tkestack.io/gpu-manager/cmd/gpu-admission-webhook/options"
+	"tkestack.io/gpu-manager/pkg/webhook"
+)
+
+//Run starts the admission webhook and blocks until receiving signals
+func Run(opt *options.Options) error {
+	if opt.TLSCertFile == "" || opt.TLSKeyFile == "" {
+		return fmt.Errorf("admission webhook must be served with --tls-cert-file and --tls-private-key-file")
+	}
+
+	defaultMemory, err := resource.ParseQuantity(opt.DefaultMemory)
+	if err != nil {
+		return fmt.Errorf("invalid default memory %s: err(%v)", opt.DefaultMemory, err)
+	}
+
+	wh, err := webhook.NewWebhook(opt.EnableShare, opt.MemoryBlockSize<<20, defaultMemory.Value())
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    opt.ListenAddr,
+		Handler: wh.Handler(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		klog.V(2).Infof("Admission webhook is serving at %s", opt.ListenAddr)
+		errCh <- srv.ListenAndServeTLS(opt.TLSCertFile, opt.TLSKeyFile)
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-sigCh:
+		klog.Infof("Received signal %s, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return srv.Shutdown(ctx)
+}
@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package options
+
+import (
+	"github.com/spf13/pflag"
+)
+
+const (
+	DefaultListenAddr      = ":8443"
+	DefaultMemoryBlockSize = 256
+	DefaultMemory          = "1Gi"
+)
+
+// Options contains admission webhook information
+type Options struct {
+	ListenAddr      string
+	TLSCertFile     string
+	TLSKeyFile      string
+	EnableShare     bool
+	MemoryBlockSize int64
+	DefaultMemory   string
+}
+
+// NewOptions gives a default options template.
+func NewOptions() *Options {
+	return &Options{
+		ListenAddr:      DefaultListenAddr,
+		EnableShare:     true,
+		MemoryBlockSize: DefaultMemoryBlockSize,
+		DefaultMemory:   DefaultMemory,
+	}
+}
+
+// AddFlags add some commandline flags.
+func (opt *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opt.ListenAddr, "listen-addr", opt.ListenAddr, "address for serving admission reviews")
+	fs.StringVar(&opt.TLSCertFile, "tls-cert-file", opt.TLSCertFile, "file containing the x509 certificate for HTTPS")
+	fs.StringVar(&opt.TLSKeyFile, "tls-private-key-file", opt.TLSKeyFile, "file containing the x509 private key matching --tls-cert-file")
+	fs.BoolVar(&opt.EnableShare, "share-mode", opt.EnableShare, "enable share mode allocation, should be the same as gpu-manager")
+	fs.Int64Var(&opt.MemoryBlockSize, "memory-block-size", opt.MemoryBlockSize,
+		"size of a vmemory block, unit MiB, should be the same as gpu-manager")
+	fs.StringVar(&opt.DefaultMemory, "default-memory", opt.DefaultMemory,
+		"GPU memory set for containers requesting vcore less than a card without vmemory, rounded up to whole blocks, 0 disables it")
+}
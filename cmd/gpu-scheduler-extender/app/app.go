@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+
+	"tkestack.io/gpu-manager/cmd/gpu-scheduler-extender/options"
+	"tkestack.io/gpu-manager/pkg/extender"
+)
+
+//Run starts the scheduler extender and blocks until receiving signals
+func Run(opt *options.Options) error {
+	clientCfg, err := clientcmd.BuildConfigFromFlags("", opt.KubeConfigFile)
+	if err != nil {
+		return fmt.Errorf("invalid client config: err(%v)", err)
+	}
+
+	client, err := kubernetes.NewForConfig(clientCfg)
+	if err != nil {
+		return fmt.Errorf("can not generate client from config: error(%v)", err)
+	}
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	ext, err := extender.NewExtender(client, factory, opt.EnableShare, opt.OversubscriptionRatio)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	factory.Start(stopCh)
+	for typ, synced := range factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			return fmt.Errorf("can't sync cache of %v", typ)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:    opt.ListenAddr,
+		Handler: ext.Handler(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		klog.V(2).Infof("Scheduler extender is serving at %s", opt.ListenAddr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-sigCh:
+		klog.Infof("Received signal %s, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return srv.Shutdown(ctx)
+}
@@ -0,0 +1,50 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package options
+
+import (
+	"github.com/spf13/pflag"
+)
+
+const (
+	DefaultListenAddr = ":3456"
+)
+
+// Options contains scheduler extender information
+type Options struct {
+	ListenAddr            string
+	KubeConfigFile        string
+	EnableShare           bool
+	OversubscriptionRatio float64
+}
+
+// NewOptions gives a default options template.
+func NewOptions() *Options {
+	return &Options{
+		ListenAddr:  DefaultListenAddr,
+		EnableShare: true,
+	}
+}
+
+// AddFlags add some commandline flags.
+func (opt *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opt.ListenAddr, "listen-addr", opt.ListenAddr, "address for serving scheduler extender requests")
+	fs.StringVar(&opt.KubeConfigFile, "kubeconfig", opt.KubeConfigFile, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
+	fs.BoolVar(&opt.EnableShare, "share-mode", opt.EnableShare, "enable share mode allocation, should be the same as gpu-manager")
+	fs.Float64Var(&opt.OversubscriptionRatio, "oversubscription-ratio", opt.OversubscriptionRatio, "oversubscription ratio of best-effort work, should be the same as gpu-manager")
+}
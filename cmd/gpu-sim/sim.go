@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	goflag "flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog"
+
+	"tkestack.io/gpu-manager/pkg/flags"
+	"tkestack.io/gpu-manager/pkg/logs"
+	"tkestack.io/gpu-manager/pkg/simulator"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	topologyFile, traceFile string
+	cardMemory, cardModel   string
+	output                  string
+	policies                []string
+	opt                     = simulator.Options{EnableShare: true}
+	memoryBlockSize         = int64(256)
+)
+
+func main() {
+	cmdFlags := pflag.CommandLine
+
+	cmdFlags.StringVar(&topologyFile, "topology", "", "topology file in JSON of tencent.com/gpu-topology annotation or matrix of `nvidia-smi topo -m`")
+	cmdFlags.StringVar(&traceFile, "trace", "", "trace of pods in JSON, or CSV if the name ends with .csv")
+	cmdFlags.StringVar(&cardMemory, "card-memory", "16Gi", "memory of cards if topology is a matrix")
+	cmdFlags.StringVar(&cardModel, "card-model", "", "model of cards if topology is a matrix")
+	cmdFlags.StringSliceVar(&policies, "policies", []string{simulator.DefaultPolicy, simulator.FirstFitPolicy}, "policies to be compared")
+	cmdFlags.StringVar(&output, "output", "table", "output format, table or json")
+	cmdFlags.BoolVar(&opt.EnableShare, "share-mode", opt.EnableShare, "enable share mode allocation")
+	cmdFlags.Float64Var(&opt.OversubscriptionRatio, "oversubscription-ratio", opt.OversubscriptionRatio,
+		"ratio of cores used by guaranteed and burstable work that best-effort work can oversubscribe on a shared card")
+	cmdFlags.Int64Var(&memoryBlockSize, "memory-block-size", memoryBlockSize, "size of a vmemory block, unit MiB")
+
+	flags.InitFlags()
+	goflag.CommandLine.Parse([]string{})
+	logs.InitLogs()
+	defer logs.FlushLogs()
+
+	if len(topologyFile) == 0 || len(traceFile) == 0 {
+		klog.Fatalf("--topology and --trace are required")
+	}
+
+	memory, err := resource.ParseQuantity(cardMemory)
+	if err != nil {
+		klog.Fatalf("invalid card memory %s, %v", cardMemory, err)
+	}
+
+	topo, err := simulator.LoadTopology(topologyFile, uint64(memory.Value()), cardModel)
+	if err != nil {
+		klog.Fatalf("can't load topology, %v", err)
+	}
+
+	pods, err := simulator.LoadTrace(traceFile)
+	if err != nil {
+		klog.Fatalf("can't load trace, %v", err)
+	}
+
+	opt.MemoryBlockSize = memoryBlockSize << 20
+	reports := make([]*simulator.Report, 0, len(policies))
+	for _, policy := range policies {
+		report, err := simulator.Simulate(topo, pods, policy, opt)
+		if err != nil {
+			klog.Fatalf("can't simulate %s, %v", policy, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			klog.Fatalf("can't encode reports, %v", err)
+		}
+		return
+	}
+
+	printTable(reports)
+}
+
+func printTable(reports []*simulator.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "POLICY\tPODS\tPLACED\tREJECTED\tINVALID\tREJECTION\tCORE-UTIL\tMEM-UTIL\tFRAG\tPEAK-FRAG\tLINK-LEVEL\tLINKS")
+	for _, r := range reports {
+		links := make([]string, 0, len(r.Links))
+		for link, count := range r.Links {
+			links = append(links, fmt.Sprintf("%s:%d", link, count))
+		}
+		sort.Strings(links)
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.2f%%\t%.2f%%\t%.2f%%\t%.2f%%\t%.2f%%\t%.1f\t%s\n",
+			r.Policy, r.Pods, r.Placed, r.Rejected, r.Invalid, r.RejectionRate*100,
+			r.CoreUtilization*100, r.MemoryUtilization*100, r.Fragmentation*100, r.PeakFragmentation*100,
+			r.LinkLevel, strings.Join(links, ","))
+	}
+}
@@ -18,10 +18,13 @@
 package app
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"tkestack.io/gpu-manager/cmd/manager/options"
@@ -31,28 +34,48 @@ import (
 	"tkestack.io/gpu-manager/pkg/utils"
 
 	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 )
 
 // #lizard forgives
 func Run(opt *options.Options) error {
+	if err := config.ValidateMemoryOversubscription(opt.MemoryOversubscriptionRatio,
+		opt.CardMemoryOversubscriptionRatios, opt.EnableUVMSwap); err != nil {
+		return err
+	}
+
+	if err := config.ValidateMemoryBlockSize(opt.MemoryBlockSize << 20); err != nil {
+		return err
+	}
+
 	cfg := &config.Config{
 		Driver:                   opt.Driver,
+		ConfigFile:               opt.ConfigFile,
 		QueryPort:                opt.QueryPort,
 		QueryAddr:                opt.QueryAddr,
 		KubeConfig:               opt.KubeConfigFile,
 		SamplePeriod:             time.Duration(opt.SamplePeriod) * time.Second,
 		VCudaRequestsQueue:       make(chan *types.VCudaRequest, 10),
+		ExtraConfig:              config.NewExtraConfigStore(),
 		DevicePluginPath:         pluginapi.DevicePluginPath,
 		VirtualManagerPath:       opt.VirtualManagerPath,
 		VolumeConfigPath:         opt.VolumeConfigPath,
 		EnableShare:              opt.EnableShare,
+		OversubscriptionRatio:    opt.OversubscriptionRatio,
 		AllocationCheckPeriod:    time.Duration(opt.AllocationCheckPeriod) * time.Second,
 		CheckpointPath:           opt.CheckpointPath,
 		ContainerRuntimeEndpoint: opt.ContainerRuntimeEndpoint,
 		CgroupDriver:             opt.CgroupDriver,
 		RequestTimeout:           opt.RequestTimeout,
+		RecycleGracePeriod:       opt.RecycleGracePeriod,
+		CandidateWaitTimeout:     opt.CandidateWaitTimeout,
+
+		MemoryOversubscriptionRatio:      opt.MemoryOversubscriptionRatio,
+		CardMemoryOversubscriptionRatios: opt.CardMemoryOversubscriptionRatios,
+		EnableUVMSwap:                    opt.EnableUVMSwap,
+		MemoryBlockSize:                  opt.MemoryBlockSize << 20,
 	}
 
 	if len(opt.HostnameOverride) > 0 {
@@ -79,31 +102,45 @@ func Run(opt *options.Options) error {
 		}
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
 	srv := server.NewManager(cfg)
-	go srv.Run()
 
-	waitTimer := time.NewTimer(opt.WaitTimeout)
-	for !srv.Ready() {
-		select {
-		case <-waitTimer.C:
-			klog.Warningf("Wait too long for server ready, restarting")
-			os.Exit(1)
-		default:
-			klog.Infof("Wait for internal server ready")
+	var runErr error
+	done := make(chan struct{})
+	go func() {
+		runErr = srv.Run(ctx)
+		close(done)
+	}()
+
+	// shutdown stops the server and waits for cleaning up
+	shutdown := func(err error) error {
+		cancel()
+		<-done
+		if err != nil {
+			return err
 		}
-		time.Sleep(time.Second)
+
+		return runErr
+	}
+
+	if err := waitForReady(srv, opt.WaitTimeout, done); err != nil {
+		return shutdown(err)
 	}
-	waitTimer.Stop()
 
 	if err := srv.RegisterToKubelet(); err != nil {
-		return err
+		return shutdown(err)
 	}
 
 	devicePluginSocket := filepath.Join(cfg.DevicePluginPath, types.KubeletSocket)
 	watcher, err := utils.NewFSWatcher(cfg.DevicePluginPath)
 	if err != nil {
-		log.Println("Failed to created FS watcher.")
-		os.Exit(1)
+		return shutdown(fmt.Errorf("failed to create FS watcher, %v", err))
 	}
 	defer watcher.Close()
 
@@ -111,12 +148,56 @@ func Run(opt *options.Options) error {
 		select {
 		case event := <-watcher.Events:
 			if event.Name == devicePluginSocket && event.Op&fsnotify.Create == fsnotify.Create {
+				klog.Infof("inotify: %s created, register to kubelet again", devicePluginSocket)
 				time.Sleep(time.Second)
-				klog.Fatalf("inotify: %s created, restarting.", devicePluginSocket)
+
+				// kubelet removes all sockets in device plugin directory when it
+				// restarts, so resource servers have to be served again
+				srv.RestartResourceServers()
+				if err := waitForReady(srv, opt.WaitTimeout, done); err != nil {
+					return shutdown(err)
+				}
+
+				if err := registerToKubelet(srv, opt.WaitTimeout); err != nil {
+					return shutdown(err)
+				}
 			}
 		case err := <-watcher.Errors:
-			klog.Fatalf("inotify: %s", err)
+			klog.Errorf("inotify: %s", err)
+		case sig := <-sigCh:
+			klog.Infof("Received signal %s, shutting down", sig)
+			return shutdown(nil)
+		case <-done:
+			return runErr
+		}
+	}
+}
+
+func waitForReady(srv server.Manager, timeout time.Duration, done <-chan struct{}) error {
+	waitTimer := time.NewTimer(timeout)
+	defer waitTimer.Stop()
+
+	for !srv.Ready() {
+		klog.Infof("Wait for internal server ready")
+		select {
+		case <-waitTimer.C:
+			return fmt.Errorf("wait too long for server ready")
+		case <-done:
+			return fmt.Errorf("server exits before ready")
+		case <-time.After(time.Second):
 		}
 	}
+
 	return nil
 }
+
+func registerToKubelet(srv server.Manager, timeout time.Duration) error {
+	return wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		if err := srv.RegisterToKubelet(); err != nil {
+			klog.Warningf("Failed to register to kubelet, %v", err)
+			return false, nil
+		}
+
+		return true, nil
+	})
+}
@@ -45,6 +45,11 @@ func main() {
 
 	version.PrintAndExitIfRequested()
 
+	if err := opt.LoadConfigFile(pflag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
 	if err := app.Run(opt); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package options
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"tkestack.io/gpu-manager/pkg/config/v1alpha1"
+)
+
+//LoadConfigFile reads the versioned configuration file and sets options
+//which are not specified by command line flags, so the precedence is
+//flags > configuration file > defaults.
+func (opt *Options) LoadConfigFile(fs *pflag.FlagSet) error {
+	if len(opt.ConfigFile) == 0 {
+		return nil
+	}
+
+	cfg, err := v1alpha1.Load(opt.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	opt.applyConfiguration(cfg, fs)
+
+	return nil
+}
+
+// #lizard forgives
+func (opt *Options) applyConfiguration(cfg *v1alpha1.GPUManagerConfiguration, fs *pflag.FlagSet) {
+	set := func(name string, fn func()) {
+		if !fs.Changed(name) {
+			fn()
+		}
+	}
+
+	set("driver", func() { opt.Driver = cfg.Driver })
+	set("extra-config", func() { opt.ExtraPath = cfg.ExtraConfigPath })
+	set("volume-config", func() { opt.VolumeConfigPath = cfg.VolumeConfigPath })
+	set("query-port", func() { opt.QueryPort = cfg.QueryPort })
+	set("query-addr", func() { opt.QueryAddr = cfg.QueryAddr })
+	set("kubeconfig", func() { opt.KubeConfigFile = cfg.KubeConfig })
+	set("sample-period", func() { opt.SamplePeriod = int(cfg.SamplePeriod.Seconds()) })
+	set("node-labels", func() { opt.NodeLabels = joinLabels(cfg.NodeLabels) })
+	set("hostname-override", func() { opt.HostnameOverride = cfg.HostnameOverride })
+	set("virtual-manager-path", func() { opt.VirtualManagerPath = cfg.VirtualManagerPath })
+	set("device-plugin-path", func() { opt.DevicePluginPath = cfg.DevicePluginPath })
+	set("checkpoint-path", func() { opt.CheckpointPath = cfg.CheckpointPath })
+	set("share-mode", func() { opt.EnableShare = cfg.EnableShare })
+	set("oversubscription-ratio", func() { opt.OversubscriptionRatio = cfg.OversubscriptionRatio })
+	set("memory-oversubscription-ratio", func() { opt.MemoryOversubscriptionRatio = cfg.MemoryOversubscriptionRatio })
+	set("memory-block-size", func() { opt.MemoryBlockSize = cfg.MemoryBlockSize.Value() >> 20 })
+	set("uvm-swap", func() { opt.EnableUVMSwap = cfg.EnableUVMSwap })
+	opt.CardMemoryOversubscriptionRatios = cfg.CardMemoryOversubscriptionRatios
+	set("allocation-check-period", func() { opt.AllocationCheckPeriod = int(cfg.AllocationCheckPeriod.Seconds()) })
+	set("container-runtime-endpoint", func() { opt.ContainerRuntimeEndpoint = cfg.ContainerRuntimeEndpoint })
+	set("cgroup-driver", func() { opt.CgroupDriver = cfg.CgroupDriver })
+	set("runtime-request-timeout", func() { opt.RequestTimeout = cfg.RuntimeRequestTimeout.Duration })
+	set("wait-timeout", func() { opt.WaitTimeout = cfg.WaitTimeout.Duration })
+	set("recycle-grace-period", func() { opt.RecycleGracePeriod = cfg.RecycleGracePeriod.Duration })
+	set("candidate-wait-timeout", func() { opt.CandidateWaitTimeout = cfg.CandidateWaitTimeout.Duration })
+}
+
+func joinLabels(labels map[string]string) string {
+	items := make([]string, 0, len(labels))
+	for k, v := range labels {
+		items = append(items, k+"="+v)
+	}
+	sort.Strings(items)
+
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package options
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "options")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.yaml")
+	data := `apiVersion: gpu-manager.tkestack.io/v1alpha1
+kind: GPUManagerConfiguration
+queryPort: 6789
+queryAddr: 0.0.0.0
+enableShare: true
+samplePeriod: 3s
+waitTimeout: 2m
+memoryOversubscriptionRatio: 2
+enableUVMSwap: true
+memoryBlockSize: 1Gi
+cardMemoryOversubscriptionRatios:
+  /dev/nvidia0: 1.5
+nodeLabels:
+  b: "2"
+  a: "1"
+`
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("can't write config file: %v", err)
+	}
+
+	opt := NewOptions()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	opt.AddFlags(fs)
+	if err := fs.Parse([]string{"--config=" + file, "--query-port=1234", "--wait-timeout=10s"}); err != nil {
+		t.Fatalf("can't parse flags: %v", err)
+	}
+
+	if err := opt.LoadConfigFile(fs); err != nil {
+		t.Fatalf("can't load config file: %v", err)
+	}
+
+	// flags override the config file
+	if opt.QueryPort != 1234 || opt.WaitTimeout != 10*time.Second {
+		t.Fatalf("flags should take precedence, got port %d, wait timeout %s", opt.QueryPort, opt.WaitTimeout)
+	}
+
+	// config file overrides the defaults
+	if opt.QueryAddr != "0.0.0.0" || !opt.EnableShare || opt.SamplePeriod != 3 || opt.NodeLabels != "a=1,b=2" {
+		t.Fatalf("config file is not applied: %+v", opt)
+	}
+
+	if opt.MemoryBlockSize != 1024 {
+		t.Fatalf("memory block size is not applied: %d", opt.MemoryBlockSize)
+	}
+
+	if opt.MemoryOversubscriptionRatio != 2 || !opt.EnableUVMSwap || opt.CardMemoryOversubscriptionRatios["/dev/nvidia0"] != 1.5 {
+		t.Fatalf("memory oversubscription is not applied: %+v", opt)
+	}
+
+	// defaults are kept
+	if opt.Driver != DefaultDriver || opt.AllocationCheckPeriod != DefaultAllocationCheckPeriod ||
+		opt.CheckpointPath != DefaultCheckpointPath {
+		t.Fatalf("defaults are not kept: %+v", opt)
+	}
+}
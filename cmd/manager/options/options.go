@@ -30,12 +30,18 @@ const (
 	DefaultVirtualManagerPath       = "/etc/gpu-manager/vm"
 	DefaultAllocationCheckPeriod    = 30
 	DefaultCheckpointPath           = "/etc/gpu-manager/checkpoint"
-	DefaultContainerRuntimeEndpoint = "/var/run/dockershim.sock"
+	DefaultContainerRuntimeEndpoint = ""
 	DefaultCgroupDriver             = "cgroupfs"
+	DefaultRecycleGracePeriod       = 5 * time.Second
+	DefaultCandidateWaitTimeout     = 2 * time.Second
+
+	DefaultMemoryOversubscriptionRatio = 1
+	DefaultMemoryBlockSize             = 256
 )
 
 // Options contains plugin information
 type Options struct {
+	ConfigFile               string
 	Driver                   string
 	ExtraPath                string
 	VolumeConfigPath         string
@@ -48,12 +54,24 @@ type Options struct {
 	VirtualManagerPath       string
 	DevicePluginPath         string
 	EnableShare              bool
+	OversubscriptionRatio    float64
 	AllocationCheckPeriod    int
 	CheckpointPath           string
 	ContainerRuntimeEndpoint string
 	CgroupDriver             string
 	RequestTimeout           time.Duration
 	WaitTimeout              time.Duration
+	RecycleGracePeriod       time.Duration
+	CandidateWaitTimeout     time.Duration
+
+	//MemoryOversubscriptionRatio is the ratio of memory can be committed to
+	//a card to its physical memory, CardMemoryOversubscriptionRatios can
+	//only be set by configuration file
+	MemoryOversubscriptionRatio      float64
+	CardMemoryOversubscriptionRatios map[string]float64
+	EnableUVMSwap                    bool
+	//MemoryBlockSize is the size of a vmemory block, unit MiB
+	MemoryBlockSize int64
 }
 
 // NewOptions gives a default options template.
@@ -70,11 +88,17 @@ func NewOptions() *Options {
 		CgroupDriver:             DefaultCgroupDriver,
 		RequestTimeout:           time.Second * 5,
 		WaitTimeout:              time.Minute,
+		RecycleGracePeriod:       DefaultRecycleGracePeriod,
+		CandidateWaitTimeout:     DefaultCandidateWaitTimeout,
+
+		MemoryOversubscriptionRatio: DefaultMemoryOversubscriptionRatio,
+		MemoryBlockSize:             DefaultMemoryBlockSize,
 	}
 }
 
 // AddFlags add some commandline flags.
 func (opt *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opt.ConfigFile, "config", opt.ConfigFile, "The versioned configuration file location, command line flags override the values in this file")
 	fs.StringVar(&opt.Driver, "driver", opt.Driver, "The driver name for manager")
 	fs.StringVar(&opt.ExtraPath, "extra-config", opt.ExtraPath, "The extra config file location")
 	fs.StringVar(&opt.VolumeConfigPath, "volume-config", opt.VolumeConfigPath, "The volume config file location")
@@ -82,17 +106,29 @@ func (opt *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&opt.QueryAddr, "query-addr", opt.QueryAddr, "address for query statistics information")
 	fs.StringVar(&opt.KubeConfigFile, "kubeconfig", opt.KubeConfigFile, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
 	fs.IntVar(&opt.SamplePeriod, "sample-period", opt.SamplePeriod, "Sample period for each card, unit second")
-	fs.StringVar(&opt.NodeLabels, "node-labels", opt.NodeLabels, "automated label for this node, if empty, node will be only labeled by gpu model")
+	fs.StringVar(&opt.NodeLabels, "node-labels", opt.NodeLabels, "automated label for this node, computed GPU labels are always applied")
 	fs.StringVar(&opt.HostnameOverride, "hostname-override", opt.HostnameOverride, "If non-empty, will use this string as identification instead of the actual hostname.")
 	fs.StringVar(&opt.VirtualManagerPath, "virtual-manager-path", opt.VirtualManagerPath, "configuration path for virtual manager store files")
 	fs.StringVar(&opt.DevicePluginPath, "device-plugin-path", opt.DevicePluginPath, "the path for kubelet receive device plugin registration")
 	fs.StringVar(&opt.CheckpointPath, "checkpoint-path", opt.CheckpointPath, "configuration path for checkpoint store file")
 	fs.BoolVar(&opt.EnableShare, "share-mode", opt.EnableShare, "enable share mode allocation")
+	fs.Float64Var(&opt.OversubscriptionRatio, "oversubscription-ratio", opt.OversubscriptionRatio,
+		"ratio of cores used by guaranteed and burstable work that best-effort work can oversubscribe on a shared card, 0 disables it")
+	fs.Float64Var(&opt.MemoryOversubscriptionRatio, "memory-oversubscription-ratio", opt.MemoryOversubscriptionRatio,
+		"ratio of memory can be committed to a card to its physical memory, 1 disables it, requires --uvm-swap if greater than 1")
+	fs.BoolVar(&opt.EnableUVMSwap, "uvm-swap", opt.EnableUVMSwap, "enable unified memory swap of vcuda library for oversubscribed memory")
+	fs.Int64Var(&opt.MemoryBlockSize, "memory-block-size", opt.MemoryBlockSize,
+		"size of a vmemory block advertised to kubelet, unit MiB. Pods allocated with the old size keep their memory if it's changed")
 	fs.IntVar(&opt.AllocationCheckPeriod, "allocation-check-period", opt.AllocationCheckPeriod, "allocation check period, unit second")
-	fs.StringVar(&opt.ContainerRuntimeEndpoint, "container-runtime-endpoint", opt.ContainerRuntimeEndpoint, "container runtime endpoint")
+	fs.StringVar(&opt.ContainerRuntimeEndpoint, "container-runtime-endpoint", opt.ContainerRuntimeEndpoint,
+		"container runtime endpoint, sockets of containerd, CRI-O and cri-dockerd are detected in order if it's empty")
 	fs.StringVar(&opt.CgroupDriver, "cgroup-driver", opt.CgroupDriver, "Driver that the kubelet uses to manipulate cgroups on the host.  "+
 		"Possible values: 'cgroupfs', 'systemd'")
 	fs.DurationVar(&opt.RequestTimeout, "runtime-request-timeout", opt.RequestTimeout,
 		"request timeout for communicating with container runtime endpoint")
 	fs.DurationVar(&opt.WaitTimeout, "wait-timeout", opt.WaitTimeout, "wait timeout for resource server ready")
+	fs.DurationVar(&opt.RecycleGracePeriod, "recycle-grace-period", opt.RecycleGracePeriod,
+		"time to wait before GPU and vcuda directory of a terminated pod are recycled")
+	fs.DurationVar(&opt.CandidateWaitTimeout, "candidate-wait-timeout", opt.CandidateWaitTimeout,
+		"time to wait for the pod of an allocate request to be seen by informer, 0 disables waiting")
 }
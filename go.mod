@@ -26,5 +26,6 @@ require (
 	k8s.io/klog v1.0.0
 	k8s.io/kubectl v0.17.4
 	k8s.io/kubelet v0.17.4
+	sigs.k8s.io/yaml v1.1.0
 	tkestack.io/nvml v0.0.0-00010101000000-000000000000
 )
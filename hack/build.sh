@@ -36,7 +36,7 @@ function plugin::run_test() {
 }
 
 function plugin::build_binary() {
-  go build -o "${ROOT}/go/bin/gpu-$arg" -ldflags "$(plugin::version::ldflags) -s -w" ${PACKAGE}/cmd/$arg
+  go build -o "${ROOT}/go/bin/gpu-${arg#gpu-}" -ldflags "$(plugin::version::ldflags) -s -w" ${PACKAGE}/cmd/$arg
 }
 
 function plugin::generate_img() {
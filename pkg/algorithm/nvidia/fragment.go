@@ -40,7 +40,7 @@ func NewFragmentMode(t *nvidia.NvidiaTree) *fragmentMode {
 	return &fragmentMode{t}
 }
 
-func (al *fragmentMode) Evaluate(cores int64, _ int64) []*nvidia.NvidiaNode {
+func (al *fragmentMode) Evaluate(cores int64, _ int64, filter nvidia.NodeFilter) []*nvidia.NvidiaNode {
 	var (
 		candidate = al.tree.Root()
 		next      *nvidia.NvidiaNode
@@ -55,7 +55,7 @@ func (al *fragmentMode) Evaluate(cores int64, _ int64) []*nvidia.NvidiaNode {
 		sorter.Sort(candidate.Children)
 
 		for _, node := range candidate.Children {
-			if len(node.Children) == 0 || node.Available() < num {
+			if len(node.Children) == 0 || node.AvailableWith(filter) < num {
 				continue
 			}
 
@@ -65,7 +65,7 @@ func (al *fragmentMode) Evaluate(cores int64, _ int64) []*nvidia.NvidiaNode {
 		}
 	}
 
-	for _, n := range candidate.GetAvailableLeaves() {
+	for _, n := range candidate.GetAvailableLeavesWith(filter) {
 		if num == 0 {
 			break
 		}
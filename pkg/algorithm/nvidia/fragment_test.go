@@ -51,7 +51,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores := int64(2 * nvidia.HundredCore)
-	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0))
+	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
@@ -67,7 +67,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores = int64(nvidia.HundredCore)
-	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0))
+	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
@@ -90,7 +90,7 @@ GPU0   x`
 	}
 
 	cores := int64(nvidia.HundredCore)
-	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0))
+	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
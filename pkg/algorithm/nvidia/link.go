@@ -37,7 +37,7 @@ func NewLinkMode(t *nvidia.NvidiaTree) *linkMode {
 	return &linkMode{t}
 }
 
-func (al *linkMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
+func (al *linkMode) Evaluate(cores int64, memory int64, filter nvidia.NodeFilter) []*nvidia.NvidiaNode {
 	var (
 		sorter   = linkSort(nvidia.ByType, nvidia.ByAvailable, nvidia.ByAllocatableMemory, nvidia.ByPids, nvidia.ByMinorID)
 		tmpStore = make(map[int]*nvidia.NvidiaNode)
@@ -47,9 +47,13 @@ func (al *linkMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
 	)
 
 	for _, node := range al.tree.Leaves() {
+		if filter != nil && !filter(node) {
+			continue
+		}
+
 		for node != root {
 			klog.V(2).Infof("Test %d mask %b", node.Meta.ID, node.Mask)
-			if node.Available() < num {
+			if node.AvailableWith(filter) < num {
 				node = node.Parent
 				continue
 			}
@@ -71,7 +75,7 @@ func (al *linkMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
 
 	sorter.Sort(candidates)
 
-	for _, n := range candidates[0].GetAvailableLeaves() {
+	for _, n := range candidates[0].GetAvailableLeavesWith(filter) {
 		if num == 0 {
 			break
 		}
@@ -53,7 +53,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores := int64(3 * nvidia.HundredCore)
-	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0))
+	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
@@ -70,8 +70,47 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores = int64(2 * nvidia.HundredCore)
-	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0))
+	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
 }
+
+func TestLinkWithModelFilter(t *testing.T) {
+	flag.Parse()
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+
+	testCase1 :=
+		`    GPU0    GPU1    GPU2    GPU3
+GPU0      X      PIX     PHB     PHB
+GPU1     PIX      X      PHB     PHB
+GPU2     PHB     PHB      X      PIX
+GPU3     PHB     PHB     PIX      X
+`
+	tree.Init(testCase1)
+	for i, n := range tree.Leaves() {
+		if i == 1 || i == 3 {
+			n.Meta.Model = "Tesla V100-PCIE-16GB"
+		} else {
+			n.Meta.Model = "Tesla T4"
+		}
+	}
+	algo := NewLinkMode(tree)
+
+	expectCase1 := []string{
+		"/dev/nvidia1",
+		"/dev/nvidia3",
+	}
+
+	cores := int64(2 * nvidia.HundredCore)
+	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0, nvidia.ModelFilter([]string{"v100"})))
+	if !pass {
+		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
+	}
+
+	cores = int64(3 * nvidia.HundredCore)
+	if nodes := algo.Evaluate(cores, 0, nvidia.ModelFilter([]string{"t4"})); len(nodes) != 0 {
+		t.Fatalf("only 2 T4 cards, but got %d", len(nodes))
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"fmt"
+
+	"tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+const (
+	//LinkMode is the name of link mode evaluator
+	LinkMode = "link"
+	//FragmentMode is the name of fragment mode evaluator
+	FragmentMode = "fragment"
+	//ShareMode is the name of share mode evaluator
+	ShareMode = "share"
+	//BestEffortMode is the name of share mode evaluator for best-effort work
+	BestEffortMode = "best-effort"
+)
+
+//ValidateRequest checks the vcore and vmemory request of a container
+//regardless of the mode of gpu-manager. The memory is ignored if the
+//request takes whole cards.
+func ValidateRequest(cores int64, memory int64) error {
+	switch {
+	case cores > nvidia.HundredCore && cores%nvidia.HundredCore > 0:
+		return fmt.Errorf("cores are greater than %d, must be multiple of %d", nvidia.HundredCore, nvidia.HundredCore)
+	case cores == 0 && memory > 0:
+		return fmt.Errorf("memory is requested without cores")
+	case cores > 0 && cores < nvidia.HundredCore && memory == 0:
+		return fmt.Errorf("cores are less than %d, memory must be requested", nvidia.HundredCore)
+	}
+
+	return nil
+}
+
+//SelectMode returns the evaluator name for the request. Both of allocator
+//and scheduler extender use it, so they always make the same placement.
+func SelectMode(cores int64, memory int64, enableShare bool) (string, error) {
+	if err := ValidateRequest(cores, memory); err != nil {
+		return "", err
+	}
+
+	switch {
+	case cores > nvidia.HundredCore:
+		return LinkMode, nil
+	case cores == nvidia.HundredCore:
+		return FragmentMode, nil
+	}
+
+	if !enableShare {
+		return "", fmt.Errorf("share mode is not enabled")
+	}
+
+	if cores == 0 || memory == 0 {
+		return "", fmt.Errorf("that cores or memory is zero is not permitted in share mode")
+	}
+
+	return ShareMode, nil
+}
+
+//SelectModeWithQoS is SelectMode which places best-effort work in share
+//mode with BestEffortMode if oversubscription is enabled.
+func SelectModeWithQoS(cores int64, memory int64, enableShare bool, qos types.QoSClass, ratio float64) (string, error) {
+	mode, err := SelectMode(cores, memory, enableShare)
+	if err != nil {
+		return "", err
+	}
+
+	if mode == ShareMode && qos == types.QoSBestEffort && ratio > 0 {
+		return BestEffortMode, nil
+	}
+
+	return mode, nil
+}
@@ -27,6 +27,9 @@ import (
 
 type shareMode struct {
 	tree *nvidia.NvidiaTree
+
+	bestEffort            bool
+	oversubscriptionRatio float64
 }
 
 //NewShareMode returns a new shareMode struct.
@@ -37,10 +40,23 @@ type shareMode struct {
 //Share mode means multiple application may share one GPU node which uses
 //GPU more efficiently.
 func NewShareMode(t *nvidia.NvidiaTree) *shareMode {
-	return &shareMode{t}
+	return &shareMode{tree: t}
+}
+
+//NewBestEffortMode returns a new shareMode struct for best-effort work.
+//
+//Best-effort work runs in idle time slices of a node, it can use at most
+//oversubscribe ratio times of cores used by guaranteed work, so a node
+//without guaranteed work doesn't accept best-effort work.
+func NewBestEffortMode(t *nvidia.NvidiaTree, ratio float64) *shareMode {
+	return &shareMode{
+		tree:                  t,
+		bestEffort:            true,
+		oversubscriptionRatio: ratio,
+	}
 }
 
-func (al *shareMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
+func (al *shareMode) Evaluate(cores int64, memory int64, filter nvidia.NodeFilter) []*nvidia.NvidiaNode {
 	var (
 		nodes    []*nvidia.NvidiaNode
 		tmpStore = make([]*nvidia.NvidiaNode, al.tree.Total())
@@ -54,7 +70,11 @@ func (al *shareMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
 	sorter.Sort(tmpStore)
 
 	for _, node := range tmpStore {
-		if node.AllocatableMeta.Cores >= cores && node.AllocatableMeta.Memory >= memory {
+		if filter != nil && !filter(node) {
+			continue
+		}
+
+		if al.availableCores(node) >= cores && node.AllocatableMeta.Memory >= memory {
 			klog.V(2).Infof("Pick up %d mask %b, cores: %d, memory: %d", node.Meta.ID, node.Mask, node.AllocatableMeta.Cores, node.AllocatableMeta.Memory)
 			nodes = append(nodes, node)
 			break
@@ -64,6 +84,16 @@ func (al *shareMode) Evaluate(cores int64, memory int64) []*nvidia.NvidiaNode {
 	return nodes
 }
 
+func (al *shareMode) availableCores(node *nvidia.NvidiaNode) int64 {
+	if !al.bestEffort {
+		return node.AllocatableMeta.Cores
+	}
+
+	limit := int64(float64(node.GuaranteedCores) * al.oversubscriptionRatio)
+
+	return limit - node.BestEffortCores
+}
+
 type shareModePriority struct {
 	data []*nvidia.NvidiaNode
 	less []nvidia.LessFunc
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
 )
 
 func init() {
@@ -55,7 +56,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores := int64(0.5 * nvidia.HundredCore)
-	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0))
+	pass, should, but := examining(expectCase1, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
@@ -71,8 +72,109 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	cores = int64(0.6 * nvidia.HundredCore)
-	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0))
+	pass, should, but = examining(expectCase2, algo.Evaluate(cores, 0, nil))
 	if !pass {
 		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
 	}
 }
+
+func TestBestEffortShare(t *testing.T) {
+	flag.Parse()
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+
+	tree.Init("\tGPU0\tGPU1\tGPU2\nGPU0\tX\tPIX\tPIX\nGPU1\tPIX\tX\tPIX\nGPU2\tPIX\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.AllocatableMeta.Cores = nvidia.HundredCore
+		n.AllocatableMeta.Memory = 1024
+		n.Meta.TotalMemory = 1024
+	}
+	algo := NewBestEffortMode(tree, 0.5)
+
+	// An idle card doesn't accept best-effort work
+	if nodes := algo.Evaluate(10, 256, nil); len(nodes) != 0 {
+		t.Fatalf("idle cards should reject best-effort work, got %v", nodes)
+	}
+
+	// Burstable work doesn't let best-effort work in
+	tree.MarkOccupied(tree.Leaves()[2], 80, 256)
+	if nodes := algo.Evaluate(10, 256, nil); len(nodes) != 0 {
+		t.Fatalf("card with only burstable work should reject best-effort work, got %v", nodes)
+	}
+
+	// 80 cores of guaranteed work on card 0, best-effort can use 40
+	// oversubscribed cores of it
+	tree.MarkOccupiedGuaranteed(tree.Leaves()[0], 80, 256)
+
+	if nodes := algo.Evaluate(60, 256, nil); len(nodes) != 0 {
+		t.Fatalf("best-effort work above the cap should be rejected, got %v", nodes)
+	}
+
+	pass, should, but := examining([]string{"/dev/nvidia0"}, algo.Evaluate(40, 256, nil))
+	if !pass {
+		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
+	}
+
+	tree.MarkOccupiedBestEffort(tree.Leaves()[0], 40, 256)
+	if tree.Leaves()[0].AllocatableMeta.Cores != 20 || tree.Leaves()[0].AllocatableMeta.Memory != 512 {
+		t.Fatalf("best-effort work should only consume memory, got %+v", tree.Leaves()[0].AllocatableMeta)
+	}
+
+	if nodes := algo.Evaluate(10, 256, nil); len(nodes) != 0 {
+		t.Fatalf("best-effort work should be rejected when the cap is used up, got %v", nodes)
+	}
+
+	// 20 cores of guaranteed work on card 1 allow 10 best-effort cores
+	tree.MarkOccupiedGuaranteed(tree.Leaves()[1], 20, 256)
+
+	if nodes := algo.Evaluate(20, 256, nil); len(nodes) != 0 {
+		t.Fatalf("best-effort work above the cap of lightly used card should be rejected, got %v", nodes)
+	}
+
+	pass, should, but = examining([]string{"/dev/nvidia1"}, algo.Evaluate(10, 256, nil))
+	if !pass {
+		t.Fatalf("Evaluate function got wrong, should be %s, but %s", should, but)
+	}
+
+	// card 0 is not free for exclusive work until best-effort work leaves
+	tree.MarkFree(tree.Leaves()[2], 80, 256)
+	tree.MarkFreeGuaranteed(tree.Leaves()[1], 20, 256)
+	tree.MarkFreeGuaranteed(tree.Leaves()[0], 80, 256)
+	if tree.Root().Available() != 2 || tree.Leaves()[0].GuaranteedCores != 0 {
+		t.Fatalf("card with best-effort work should not be free, got %+v", tree.Leaves()[0])
+	}
+
+	tree.MarkFreeBestEffort(tree.Leaves()[0], 40, 256)
+	if tree.Root().Available() != 3 || tree.Leaves()[0].BestEffortCores != 0 || tree.Leaves()[0].AllocatableMeta.Memory != 1024 {
+		t.Fatalf("card should be free, got %+v", tree.Leaves()[0])
+	}
+
+	if mode, _ := SelectModeWithQoS(10, 256, true, types.QoSBestEffort, 0); mode != ShareMode {
+		t.Fatalf("best-effort mode should be disabled without oversubscription, got %s", mode)
+	}
+
+	if mode, _ := SelectModeWithQoS(10, 256, true, types.QoSBestEffort, 0.5); mode != BestEffortMode {
+		t.Fatalf("expect best-effort mode, got %s", mode)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	testCases := []struct {
+		cores, memory int64
+		valid         bool
+	}{
+		{cores: 50, memory: 256, valid: true},
+		{cores: 100, valid: true},
+		{cores: 200, memory: 256, valid: true},
+		{cores: 150},
+		{cores: 50},
+		{memory: 256},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateRequest(tc.cores, tc.memory)
+		if tc.valid != (err == nil) {
+			t.Errorf("vcore %d vmemory %d: expect valid %t, got %v", tc.cores, tc.memory, tc.valid, err)
+		}
+	}
+}
@@ -15,6 +15,9 @@ It has these top-level messages:
 	DeviceInfo
 	VersionResponse
 	Spec
+	SimulateRequest
+	SimulateResponse
+	Fragmentation
 */
 package display
 
@@ -232,6 +235,130 @@ func (m *Spec) GetMem() float32 {
 	return 0
 }
 
+type SimulateRequest struct {
+	Cores int64 `protobuf:"varint,1,opt,name=cores" json:"cores,omitempty"`
+	// bytes
+	Memory int64 `protobuf:"varint,2,opt,name=memory" json:"memory,omitempty"`
+	// guaranteed if it's empty
+	QosClass       string   `protobuf:"bytes,3,opt,name=qos_class,json=qosClass" json:"qos_class,omitempty"`
+	ModelSelectors []string `protobuf:"bytes,4,rep,name=model_selectors,json=modelSelectors" json:"model_selectors,omitempty"`
+}
+
+func (m *SimulateRequest) Reset()                    { *m = SimulateRequest{} }
+func (m *SimulateRequest) String() string            { return proto.CompactTextString(m) }
+func (*SimulateRequest) ProtoMessage()               {}
+func (*SimulateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SimulateRequest) GetCores() int64 {
+	if m != nil {
+		return m.Cores
+	}
+	return 0
+}
+
+func (m *SimulateRequest) GetMemory() int64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *SimulateRequest) GetQosClass() string {
+	if m != nil {
+		return m.QosClass
+	}
+	return ""
+}
+
+func (m *SimulateRequest) GetModelSelectors() []string {
+	if m != nil {
+		return m.ModelSelectors
+	}
+	return nil
+}
+
+type SimulateResponse struct {
+	Mode    string         `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
+	Devices []string       `protobuf:"bytes,2,rep,name=devices" json:"devices,omitempty"`
+	Before  *Fragmentation `protobuf:"bytes,3,opt,name=before" json:"before,omitempty"`
+	After   *Fragmentation `protobuf:"bytes,4,opt,name=after" json:"after,omitempty"`
+}
+
+func (m *SimulateResponse) Reset()                    { *m = SimulateResponse{} }
+func (m *SimulateResponse) String() string            { return proto.CompactTextString(m) }
+func (*SimulateResponse) ProtoMessage()               {}
+func (*SimulateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *SimulateResponse) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *SimulateResponse) GetDevices() []string {
+	if m != nil {
+		return m.Devices
+	}
+	return nil
+}
+
+func (m *SimulateResponse) GetBefore() *Fragmentation {
+	if m != nil {
+		return m.Before
+	}
+	return nil
+}
+
+func (m *SimulateResponse) GetAfter() *Fragmentation {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
+type Fragmentation struct {
+	FreeCards int32 `protobuf:"varint,1,opt,name=free_cards,json=freeCards" json:"free_cards,omitempty"`
+	FreeCores int64 `protobuf:"varint,2,opt,name=free_cores,json=freeCores" json:"free_cores,omitempty"`
+	// bytes
+	FreeMemory int64 `protobuf:"varint,3,opt,name=free_memory,json=freeMemory" json:"free_memory,omitempty"`
+	// ratio of free cores on partially used cards to all free cores
+	Ratio float32 `protobuf:"fixed32,4,opt,name=ratio" json:"ratio,omitempty"`
+}
+
+func (m *Fragmentation) Reset()                    { *m = Fragmentation{} }
+func (m *Fragmentation) String() string            { return proto.CompactTextString(m) }
+func (*Fragmentation) ProtoMessage()               {}
+func (*Fragmentation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Fragmentation) GetFreeCards() int32 {
+	if m != nil {
+		return m.FreeCards
+	}
+	return 0
+}
+
+func (m *Fragmentation) GetFreeCores() int64 {
+	if m != nil {
+		return m.FreeCores
+	}
+	return 0
+}
+
+func (m *Fragmentation) GetFreeMemory() int64 {
+	if m != nil {
+		return m.FreeMemory
+	}
+	return 0
+}
+
+func (m *Fragmentation) GetRatio() float32 {
+	if m != nil {
+		return m.Ratio
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*GraphResponse)(nil), "display.GraphResponse")
 	proto.RegisterType((*UsageResponse)(nil), "display.UsageResponse")
@@ -240,6 +367,9 @@ func init() {
 	proto.RegisterType((*DeviceInfo)(nil), "display.DeviceInfo")
 	proto.RegisterType((*VersionResponse)(nil), "display.VersionResponse")
 	proto.RegisterType((*Spec)(nil), "display.Spec")
+	proto.RegisterType((*SimulateRequest)(nil), "display.SimulateRequest")
+	proto.RegisterType((*SimulateResponse)(nil), "display.SimulateResponse")
+	proto.RegisterType((*Fragmentation)(nil), "display.Fragmentation")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -259,6 +389,9 @@ type GPUDisplayClient interface {
 	PrintUsages(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*UsageResponse, error)
 	// Version
 	Version(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*VersionResponse, error)
+	// Simulate returns the cards chosen for the request on a snapshot of
+	// allocator state, nothing is allocated
+	Simulate(ctx context.Context, in *SimulateRequest, opts ...grpc.CallOption) (*SimulateResponse, error)
 }
 
 type gPUDisplayClient struct {
@@ -296,6 +429,15 @@ func (c *gPUDisplayClient) Version(ctx context.Context, in *google_protobuf1.Emp
 	return out, nil
 }
 
+func (c *gPUDisplayClient) Simulate(ctx context.Context, in *SimulateRequest, opts ...grpc.CallOption) (*SimulateResponse, error) {
+	out := new(SimulateResponse)
+	err := grpc.Invoke(ctx, "/display.GPUDisplay/Simulate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for GPUDisplay service
 
 type GPUDisplayServer interface {
@@ -305,6 +447,9 @@ type GPUDisplayServer interface {
 	PrintUsages(context.Context, *google_protobuf1.Empty) (*UsageResponse, error)
 	// Version
 	Version(context.Context, *google_protobuf1.Empty) (*VersionResponse, error)
+	// Simulate returns the cards chosen for the request on a snapshot of
+	// allocator state, nothing is allocated
+	Simulate(context.Context, *SimulateRequest) (*SimulateResponse, error)
 }
 
 func RegisterGPUDisplayServer(s *grpc.Server, srv GPUDisplayServer) {
@@ -365,6 +510,24 @@ func _GPUDisplay_Version_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GPUDisplay_Simulate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GPUDisplayServer).Simulate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/display.GPUDisplay/Simulate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GPUDisplayServer).Simulate(ctx, req.(*SimulateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _GPUDisplay_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "display.GPUDisplay",
 	HandlerType: (*GPUDisplayServer)(nil),
@@ -381,6 +544,10 @@ var _GPUDisplay_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Version",
 			Handler:    _GPUDisplay_Version_Handler,
 		},
+		{
+			MethodName: "Simulate",
+			Handler:    _GPUDisplay_Simulate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/api/runtime/display/api.proto",
@@ -389,42 +556,55 @@ var _GPUDisplay_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("pkg/api/runtime/display/api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 580 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x53, 0xdf, 0x8a, 0xd3, 0x4e,
-	0x18, 0x25, 0x69, 0xbb, 0xdd, 0x7e, 0xfd, 0x75, 0x7f, 0xcb, 0x28, 0xcb, 0x18, 0x15, 0xb2, 0x91,
-	0x95, 0xa2, 0x92, 0x48, 0x15, 0x14, 0x6f, 0xdd, 0x75, 0x29, 0x58, 0x28, 0x91, 0xf5, 0x76, 0xc9,
-	0x26, 0xb3, 0x71, 0xdc, 0x26, 0x19, 0x32, 0x93, 0xb2, 0xbd, 0xf5, 0x01, 0xbc, 0xf1, 0xce, 0xd7,
-	0x12, 0x7c, 0x02, 0x1f, 0xc2, 0x4b, 0x99, 0x3f, 0x49, 0x9b, 0xb5, 0x8a, 0x37, 0x65, 0xbe, 0x33,
-	0x27, 0xe7, 0x3b, 0x9c, 0xce, 0x81, 0x43, 0x76, 0x95, 0x06, 0x11, 0xa3, 0x41, 0x59, 0xe5, 0x82,
-	0x66, 0x24, 0x48, 0x28, 0x67, 0x8b, 0x68, 0x25, 0x31, 0x9f, 0x95, 0x85, 0x28, 0x50, 0xdf, 0x40,
-	0xce, 0xbd, 0xb4, 0x28, 0xd2, 0x05, 0x51, 0xf4, 0x28, 0xcf, 0x0b, 0x11, 0x09, 0x5a, 0xe4, 0x5c,
-	0xd3, 0x9c, 0xbb, 0xe6, 0x56, 0x4d, 0x17, 0xd5, 0x65, 0x40, 0x32, 0x26, 0x56, 0xfa, 0xd2, 0x3b,
-	0x82, 0xd1, 0x69, 0x19, 0xb1, 0x0f, 0x21, 0xe1, 0xac, 0xc8, 0x39, 0x41, 0xb7, 0xa1, 0x97, 0x4a,
-	0x00, 0x5b, 0xae, 0x35, 0x1e, 0x84, 0x7a, 0xf0, 0xbe, 0x5a, 0x30, 0x3a, 0xe3, 0x51, 0x4a, 0x1a,
-	0xde, 0x0b, 0xe8, 0x55, 0x12, 0xc0, 0x96, 0xdb, 0x19, 0x0f, 0x27, 0x87, 0xbe, 0x31, 0xe3, 0xb7,
-	0x68, 0x7a, 0x3a, 0xc9, 0x45, 0xb9, 0x0a, 0x35, 0xdf, 0x99, 0x03, 0xac, 0x41, 0xb4, 0x0f, 0x9d,
-	0x2b, 0xb2, 0x32, 0xcb, 0xe4, 0x11, 0x3d, 0x81, 0xde, 0x32, 0x5a, 0x54, 0x04, 0xdb, 0xae, 0x35,
-	0x1e, 0x4e, 0x0e, 0x1a, 0xe1, 0xd7, 0x45, 0x2e, 0x22, 0x9a, 0x93, 0xf2, 0x9d, 0x88, 0x44, 0xa8,
-	0x49, 0xaf, 0xec, 0x97, 0x96, 0xf7, 0xdd, 0x86, 0x51, 0xeb, 0x12, 0x3d, 0x87, 0x2e, 0x17, 0x91,
-	0x30, 0xde, 0xdc, 0xed, 0x12, 0xbe, 0xfc, 0xd1, 0xd6, 0x14, 0x1b, 0x61, 0xe8, 0xb3, 0xb2, 0xf8,
-	0x48, 0x62, 0xa1, 0x76, 0x0f, 0xc2, 0x7a, 0x44, 0x08, 0xba, 0x15, 0x27, 0x25, 0xee, 0x28, 0x58,
-	0x9d, 0x25, 0x3b, 0x5e, 0x54, 0x5c, 0x90, 0x12, 0x77, 0x35, 0xdb, 0x8c, 0x6a, 0x3b, 0x23, 0x31,
-	0xee, 0xfd, 0x7d, 0x3b, 0x23, 0x71, 0xbd, 0x9d, 0x91, 0xd8, 0x99, 0xc2, 0xa0, 0x31, 0xb4, 0x25,
-	0x96, 0x87, 0xed, 0x58, 0xf6, 0x1b, 0xd5, 0x63, 0xb2, 0xa4, 0x31, 0xe1, 0x1b, 0x81, 0x38, 0x6f,
-	0x60, 0xd0, 0xa8, 0x6f, 0x91, 0x7a, 0xd0, 0x96, 0x1a, 0x35, 0x52, 0xf2, 0xa3, 0xcd, 0x60, 0x9f,
-	0x42, 0xdf, 0xa8, 0xa3, 0x23, 0xe8, 0x24, 0x64, 0x69, 0x02, 0xbd, 0x75, 0x63, 0xf9, 0x34, 0xbf,
-	0x2c, 0x42, 0x79, 0xef, 0x7d, 0xb6, 0x00, 0xd6, 0x18, 0xda, 0x03, 0x9b, 0x26, 0x66, 0xb5, 0x4d,
-	0x13, 0x74, 0x07, 0x76, 0xe3, 0xa8, 0x4c, 0xce, 0x69, 0x72, 0x5d, 0x47, 0x2c, 0xe7, 0x69, 0x72,
-	0x2d, 0x6d, 0xa6, 0xac, 0xc2, 0xe0, 0x5a, 0x63, 0x3b, 0x94, 0x47, 0x89, 0x64, 0x24, 0xc3, 0x43,
-	0x8d, 0x64, 0x24, 0x93, 0x7f, 0x03, 0xa3, 0x09, 0xc7, 0xff, 0xb9, 0x9d, 0x71, 0x2f, 0x54, 0x67,
-	0x74, 0x1f, 0x20, 0x51, 0x0b, 0xcf, 0x25, 0x79, 0xa4, 0xc8, 0x03, 0x8d, 0xcc, 0x48, 0xe6, 0x3d,
-	0x86, 0xff, 0xdf, 0x93, 0x92, 0xd3, 0x22, 0x6f, 0x5e, 0x2e, 0x86, 0xfe, 0x52, 0x43, 0xc6, 0x59,
-	0x3d, 0x7a, 0x8f, 0xa0, 0x2b, 0x23, 0xa8, 0xbd, 0x58, 0xbf, 0x79, 0xb1, 0x1b, 0x2f, 0x93, 0x9f,
-	0x16, 0xc0, 0xe9, 0xfc, 0xec, 0x58, 0x07, 0x81, 0xde, 0x02, 0xcc, 0x4b, 0x9a, 0x0b, 0x55, 0x26,
-	0x74, 0xe0, 0xeb, 0xce, 0xf9, 0x75, 0xe7, 0xfc, 0x13, 0xd9, 0x39, 0x67, 0xfd, 0x98, 0x5b, 0xa5,
-	0xf3, 0xf6, 0x3e, 0x7d, 0xfb, 0xf1, 0xc5, 0xde, 0x45, 0x3b, 0x81, 0xaa, 0x1b, 0x9a, 0xc1, 0x50,
-	0xa9, 0xa9, 0xa2, 0xf0, 0x7f, 0x90, 0x6b, 0x95, 0x6e, 0x43, 0x4e, 0x55, 0x0e, 0xcd, 0xa0, 0x6f,
-	0x42, 0xf8, 0xa3, 0x14, 0x6e, 0xa4, 0x6e, 0xc4, 0xe5, 0xed, 0x2b, 0x31, 0x40, 0xbb, 0x81, 0x89,
-	0xe9, 0x62, 0x47, 0x7d, 0xfb, 0xec, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0xa7, 0x59, 0x46, 0x2f,
-	0xa3, 0x04, 0x00, 0x00,
+	// 800 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x54, 0x4d, 0x6f, 0xeb, 0x44,
+	0x14, 0x95, 0xed, 0x7c, 0xf9, 0x86, 0xa4, 0xd1, 0x50, 0x55, 0x7e, 0x79, 0x20, 0xf2, 0x8c, 0x1e,
+	0x44, 0x0f, 0xe4, 0xa0, 0x82, 0x04, 0x7a, 0xdb, 0xbe, 0xb6, 0xaa, 0x44, 0xa4, 0xca, 0x55, 0xd9,
+	0x46, 0xae, 0x7d, 0x13, 0x4c, 0x6d, 0x8f, 0x3b, 0x33, 0x8e, 0x9a, 0x2d, 0x82, 0x2d, 0x1b, 0x76,
+	0x2c, 0xf8, 0x4b, 0x2c, 0x90, 0xf8, 0x05, 0xfc, 0x10, 0x34, 0x1f, 0x76, 0x9a, 0x90, 0x22, 0x36,
+	0xd1, 0xdc, 0x73, 0xcf, 0x9c, 0x7b, 0xe7, 0xe4, 0xfa, 0xc2, 0xab, 0xf2, 0x7e, 0x35, 0x8b, 0xca,
+	0x74, 0xc6, 0xaa, 0x42, 0xa4, 0x39, 0xce, 0x92, 0x94, 0x97, 0x59, 0xb4, 0x91, 0x58, 0x50, 0x32,
+	0x2a, 0x28, 0xe9, 0x1a, 0x68, 0xfc, 0xc1, 0x8a, 0xd2, 0x55, 0x86, 0x8a, 0x1e, 0x15, 0x05, 0x15,
+	0x91, 0x48, 0x69, 0xc1, 0x35, 0x6d, 0xfc, 0xd2, 0x64, 0x55, 0x74, 0x57, 0x2d, 0x67, 0x98, 0x97,
+	0x62, 0xa3, 0x93, 0xfe, 0x6b, 0x18, 0x5c, 0xb2, 0xa8, 0xfc, 0x3e, 0x44, 0x5e, 0xd2, 0x82, 0x23,
+	0x39, 0x86, 0xf6, 0x4a, 0x02, 0x9e, 0x35, 0xb1, 0xa6, 0x6e, 0xa8, 0x03, 0xff, 0x37, 0x0b, 0x06,
+	0xb7, 0x3c, 0x5a, 0x61, 0xc3, 0xfb, 0x1a, 0xda, 0x95, 0x04, 0x3c, 0x6b, 0xe2, 0x4c, 0xfb, 0xa7,
+	0xaf, 0x02, 0xd3, 0x4c, 0xb0, 0x43, 0xd3, 0xd1, 0x79, 0x21, 0xd8, 0x26, 0xd4, 0xfc, 0xf1, 0x35,
+	0xc0, 0x16, 0x24, 0x23, 0x70, 0xee, 0x71, 0x63, 0x8a, 0xc9, 0x23, 0xf9, 0x1c, 0xda, 0xeb, 0x28,
+	0xab, 0xd0, 0xb3, 0x27, 0xd6, 0xb4, 0x7f, 0x7a, 0xd2, 0x08, 0x9f, 0xd1, 0x42, 0x44, 0x69, 0x81,
+	0xec, 0x46, 0x44, 0x22, 0xd4, 0xa4, 0xb7, 0xf6, 0x37, 0x96, 0xff, 0x97, 0x0d, 0x83, 0x9d, 0x24,
+	0xf9, 0x0a, 0x5a, 0x5c, 0x44, 0xc2, 0xf4, 0x36, 0x39, 0x2c, 0x11, 0xc8, 0x1f, 0xdd, 0x9a, 0x62,
+	0x13, 0x0f, 0xba, 0x25, 0xa3, 0x3f, 0x60, 0x2c, 0x54, 0x6d, 0x37, 0xac, 0x43, 0x42, 0xa0, 0x55,
+	0x71, 0x64, 0x9e, 0xa3, 0x60, 0x75, 0x96, 0xec, 0x38, 0xab, 0xb8, 0x40, 0xe6, 0xb5, 0x34, 0xdb,
+	0x84, 0xaa, 0x7a, 0x89, 0xb1, 0xd7, 0xfe, 0xef, 0xea, 0x25, 0xc6, 0x75, 0xf5, 0x12, 0xe3, 0xf1,
+	0x15, 0xb8, 0x4d, 0x43, 0x07, 0x6c, 0xf9, 0x64, 0xd7, 0x96, 0x51, 0xa3, 0xfa, 0x0e, 0xd7, 0x69,
+	0x8c, 0xfc, 0x89, 0x21, 0xe3, 0x0b, 0x70, 0x1b, 0xf5, 0x03, 0x52, 0x1f, 0xef, 0x4a, 0x0d, 0x1a,
+	0x29, 0x79, 0xe9, 0xa9, 0xb1, 0x5f, 0x40, 0xd7, 0xa8, 0x93, 0xd7, 0xe0, 0x24, 0xb8, 0x36, 0x86,
+	0xbe, 0xbf, 0x57, 0xfc, 0xaa, 0x58, 0xd2, 0x50, 0xe6, 0xfd, 0x5f, 0x2c, 0x80, 0x2d, 0x46, 0x86,
+	0x60, 0xa7, 0x89, 0x29, 0x6d, 0xa7, 0x09, 0x79, 0x01, 0xbd, 0x38, 0x62, 0xc9, 0x22, 0x4d, 0x1e,
+	0x6b, 0x8b, 0x65, 0x7c, 0x95, 0x3c, 0xca, 0x36, 0x57, 0x65, 0xe5, 0xc1, 0xc4, 0x9a, 0xda, 0xa1,
+	0x3c, 0x4a, 0x24, 0xc7, 0xdc, 0xeb, 0x6b, 0x24, 0xc7, 0x5c, 0xfe, 0x0d, 0x65, 0x9a, 0x70, 0xef,
+	0xbd, 0x89, 0x33, 0x6d, 0x87, 0xea, 0x4c, 0x3e, 0x04, 0x48, 0x54, 0xc1, 0x85, 0x24, 0x0f, 0x14,
+	0xd9, 0xd5, 0xc8, 0x1c, 0x73, 0xff, 0x33, 0x38, 0xfa, 0x0e, 0x19, 0x4f, 0x69, 0xd1, 0x4c, 0xae,
+	0x07, 0xdd, 0xb5, 0x86, 0x4c, 0x67, 0x75, 0xe8, 0xbf, 0x81, 0x96, 0xb4, 0xa0, 0xee, 0xc5, 0xfa,
+	0x57, 0x2f, 0x76, 0xd3, 0x8b, 0xff, 0xb3, 0x05, 0x47, 0x37, 0x69, 0x5e, 0x65, 0x91, 0xc0, 0x10,
+	0x1f, 0x2a, 0xe4, 0x42, 0x7e, 0x3b, 0x31, 0x65, 0xc8, 0xd5, 0x4d, 0x27, 0xd4, 0x01, 0x39, 0x81,
+	0x4e, 0x8e, 0x39, 0x65, 0x1b, 0x75, 0xdd, 0x09, 0x4d, 0x44, 0x5e, 0x82, 0xfb, 0x40, 0xf9, 0x22,
+	0xce, 0x22, 0xce, 0xcd, 0x64, 0xf5, 0x1e, 0x28, 0x3f, 0x93, 0x31, 0xf9, 0x14, 0x8e, 0x72, 0x9a,
+	0x60, 0xb6, 0xe0, 0x98, 0x61, 0x2c, 0x28, 0xe3, 0x5e, 0x6b, 0xe2, 0x4c, 0xdd, 0x70, 0xa8, 0xe0,
+	0x9b, 0x1a, 0xf5, 0x7f, 0xb7, 0x60, 0xb4, 0xed, 0xc3, 0x3c, 0x91, 0x40, 0x4b, 0xd2, 0xcc, 0xfb,
+	0xd4, 0x59, 0x3e, 0x5b, 0xdb, 0xc2, 0x3d, 0x5b, 0x29, 0xd5, 0x21, 0x09, 0xa0, 0x73, 0x87, 0x4b,
+	0xca, 0xd0, 0x73, 0xf6, 0x3e, 0xb9, 0x0b, 0x16, 0xad, 0x72, 0x2c, 0xf4, 0x3e, 0x09, 0x0d, 0x4b,
+	0x7e, 0xa1, 0xd1, 0xb2, 0x9e, 0xfb, 0xe7, 0xe9, 0x9a, 0xe4, 0xff, 0x64, 0xc1, 0x60, 0x27, 0x21,
+	0xff, 0xb2, 0x25, 0x43, 0x5c, 0xc8, 0xbf, 0x5e, 0x7b, 0xd5, 0x0e, 0x5d, 0x89, 0x9c, 0x49, 0x60,
+	0x9b, 0x56, 0x56, 0x6a, 0xcf, 0x74, 0x5a, 0xd9, 0xf9, 0x11, 0xf4, 0x55, 0xda, 0x78, 0xea, 0xa8,
+	0xbc, 0xba, 0x31, 0xd7, 0xbe, 0x1e, 0x43, 0x9b, 0xc9, 0x42, 0xaa, 0x3d, 0x3b, 0xd4, 0xc1, 0xe9,
+	0x1f, 0x36, 0xc0, 0xe5, 0xf5, 0xed, 0x3b, 0xdd, 0x2a, 0xf9, 0x16, 0xe0, 0x9a, 0xa5, 0x85, 0x50,
+	0xcb, 0x8f, 0x9c, 0x04, 0x7a, 0x47, 0x06, 0xf5, 0x8e, 0x0c, 0xce, 0xe5, 0x8e, 0x1c, 0x6f, 0x9f,
+	0xb6, 0xb3, 0x24, 0xfd, 0xe1, 0x8f, 0x7f, 0xfe, 0xfd, 0xab, 0xdd, 0x23, 0x9d, 0x99, 0x5a, 0x8f,
+	0x64, 0x0e, 0x7d, 0xa5, 0xa6, 0x16, 0x1b, 0xff, 0x1f, 0x72, 0x3b, 0x4b, 0xf2, 0x89, 0x9c, 0x5a,
+	0x91, 0x64, 0x0e, 0x5d, 0x33, 0xb4, 0xcf, 0x4a, 0x79, 0x8d, 0xd4, 0xde, 0x78, 0xfb, 0x23, 0x25,
+	0x06, 0xa4, 0x37, 0x33, 0x63, 0x4d, 0x6e, 0xa1, 0x57, 0x4f, 0x08, 0xd9, 0xde, 0xdb, 0x1b, 0xde,
+	0xf1, 0x8b, 0x03, 0x19, 0x23, 0x79, 0xac, 0x24, 0x87, 0x6f, 0xad, 0x37, 0xbe, 0x3b, 0xe3, 0x26,
+	0x7b, 0xd7, 0x51, 0x2d, 0x7d, 0xf9, 0xcf, 0x00, 0x5b, 0x26, 0x97, 0x39, 0xaa, 0x06, 0x00, 0x00,
 }
@@ -10,6 +10,13 @@ It is generated from these files:
 It has these top-level messages:
 	VDeviceRequest
 	VDeviceResponse
+	ConfigRequest
+	ContainerConfig
+	ProcessUsage
+	UsageRequest
+	UsageResponse
+	HeartbeatRequest
+	HeartbeatResponse
 */
 package vcuda
 
@@ -81,9 +88,281 @@ func (m *VDeviceResponse) String() string            { return proto.CompactTextS
 func (*VDeviceResponse) ProtoMessage()               {}
 func (*VDeviceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
+// container_id is the one of caller if it's empty
+type ConfigRequest struct {
+	PodUid      string `protobuf:"bytes,1,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId" json:"container_id,omitempty"`
+}
+
+func (m *ConfigRequest) Reset()                    { *m = ConfigRequest{} }
+func (m *ConfigRequest) String() string            { return proto.CompactTextString(m) }
+func (*ConfigRequest) ProtoMessage()               {}
+func (*ConfigRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *ConfigRequest) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *ConfigRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+// ContainerConfig is the effective limits of a container, it has the same
+// meaning as vcuda.config and pids.config
+type ContainerConfig struct {
+	PodUid        string `protobuf:"bytes,1,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
+	ContainerName string `protobuf:"bytes,2,opt,name=container_name,json=containerName" json:"container_name,omitempty"`
+	ContainerId   string `protobuf:"bytes,3,opt,name=container_id,json=containerId" json:"container_id,omitempty"`
+	// bytes
+	GpuMemory uint64 `protobuf:"varint,4,opt,name=gpu_memory,json=gpuMemory" json:"gpu_memory,omitempty"`
+	// 100 per card
+	Utilization    int32   `protobuf:"varint,5,opt,name=utilization" json:"utilization,omitempty"`
+	HardLimit      bool    `protobuf:"varint,6,opt,name=hard_limit,json=hardLimit" json:"hard_limit,omitempty"`
+	Limit          int32   `protobuf:"varint,7,opt,name=limit" json:"limit,omitempty"`
+	Enable         bool    `protobuf:"varint,8,opt,name=enable" json:"enable,omitempty"`
+	QosClass       string  `protobuf:"bytes,9,opt,name=qos_class,json=qosClass" json:"qos_class,omitempty"`
+	MinUtilization int32   `protobuf:"varint,10,opt,name=min_utilization,json=minUtilization" json:"min_utilization,omitempty"`
+	UvmSwap        bool    `protobuf:"varint,11,opt,name=uvm_swap,json=uvmSwap" json:"uvm_swap,omitempty"`
+	Pids           []int32 `protobuf:"varint,12,rep,packed,name=pids" json:"pids,omitempty"`
+	// increased when any of above is changed
+	Generation int64 `protobuf:"varint,13,opt,name=generation" json:"generation,omitempty"`
+}
+
+func (m *ContainerConfig) Reset()                    { *m = ContainerConfig{} }
+func (m *ContainerConfig) String() string            { return proto.CompactTextString(m) }
+func (*ContainerConfig) ProtoMessage()               {}
+func (*ContainerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *ContainerConfig) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *ContainerConfig) GetContainerName() string {
+	if m != nil {
+		return m.ContainerName
+	}
+	return ""
+}
+
+func (m *ContainerConfig) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *ContainerConfig) GetGpuMemory() uint64 {
+	if m != nil {
+		return m.GpuMemory
+	}
+	return 0
+}
+
+func (m *ContainerConfig) GetUtilization() int32 {
+	if m != nil {
+		return m.Utilization
+	}
+	return 0
+}
+
+func (m *ContainerConfig) GetHardLimit() bool {
+	if m != nil {
+		return m.HardLimit
+	}
+	return false
+}
+
+func (m *ContainerConfig) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ContainerConfig) GetEnable() bool {
+	if m != nil {
+		return m.Enable
+	}
+	return false
+}
+
+func (m *ContainerConfig) GetQosClass() string {
+	if m != nil {
+		return m.QosClass
+	}
+	return ""
+}
+
+func (m *ContainerConfig) GetMinUtilization() int32 {
+	if m != nil {
+		return m.MinUtilization
+	}
+	return 0
+}
+
+func (m *ContainerConfig) GetUvmSwap() bool {
+	if m != nil {
+		return m.UvmSwap
+	}
+	return false
+}
+
+func (m *ContainerConfig) GetPids() []int32 {
+	if m != nil {
+		return m.Pids
+	}
+	return nil
+}
+
+func (m *ContainerConfig) GetGeneration() int64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+type ProcessUsage struct {
+	Pid   int32  `protobuf:"varint,1,opt,name=pid" json:"pid,omitempty"`
+	BusId string `protobuf:"bytes,2,opt,name=bus_id,json=busId" json:"bus_id,omitempty"`
+	// bytes
+	UsedMemory uint64 `protobuf:"varint,3,opt,name=used_memory,json=usedMemory" json:"used_memory,omitempty"`
+	// accumulated SM time in microseconds
+	SmTime uint64 `protobuf:"varint,4,opt,name=sm_time,json=smTime" json:"sm_time,omitempty"`
+}
+
+func (m *ProcessUsage) Reset()                    { *m = ProcessUsage{} }
+func (m *ProcessUsage) String() string            { return proto.CompactTextString(m) }
+func (*ProcessUsage) ProtoMessage()               {}
+func (*ProcessUsage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ProcessUsage) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *ProcessUsage) GetBusId() string {
+	if m != nil {
+		return m.BusId
+	}
+	return ""
+}
+
+func (m *ProcessUsage) GetUsedMemory() uint64 {
+	if m != nil {
+		return m.UsedMemory
+	}
+	return 0
+}
+
+func (m *ProcessUsage) GetSmTime() uint64 {
+	if m != nil {
+		return m.SmTime
+	}
+	return 0
+}
+
+type UsageRequest struct {
+	PodUid      string          `protobuf:"bytes,1,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
+	ContainerId string          `protobuf:"bytes,2,opt,name=container_id,json=containerId" json:"container_id,omitempty"`
+	Processes   []*ProcessUsage `protobuf:"bytes,3,rep,name=processes" json:"processes,omitempty"`
+}
+
+func (m *UsageRequest) Reset()                    { *m = UsageRequest{} }
+func (m *UsageRequest) String() string            { return proto.CompactTextString(m) }
+func (*UsageRequest) ProtoMessage()               {}
+func (*UsageRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *UsageRequest) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *UsageRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *UsageRequest) GetProcesses() []*ProcessUsage {
+	if m != nil {
+		return m.Processes
+	}
+	return nil
+}
+
+type UsageResponse struct {
+}
+
+func (m *UsageResponse) Reset()                    { *m = UsageResponse{} }
+func (m *UsageResponse) String() string            { return proto.CompactTextString(m) }
+func (*UsageResponse) ProtoMessage()               {}
+func (*UsageResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+type HeartbeatRequest struct {
+	PodUid      string `protobuf:"bytes,1,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId" json:"container_id,omitempty"`
+}
+
+func (m *HeartbeatRequest) Reset()                    { *m = HeartbeatRequest{} }
+func (m *HeartbeatRequest) String() string            { return proto.CompactTextString(m) }
+func (*HeartbeatRequest) ProtoMessage()               {}
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *HeartbeatRequest) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *HeartbeatRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+type HeartbeatResponse struct {
+	// generation of ContainerConfig, client calls GetConfig if it's changed
+	Generation int64 `protobuf:"varint,1,opt,name=generation" json:"generation,omitempty"`
+}
+
+func (m *HeartbeatResponse) Reset()                    { *m = HeartbeatResponse{} }
+func (m *HeartbeatResponse) String() string            { return proto.CompactTextString(m) }
+func (*HeartbeatResponse) ProtoMessage()               {}
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *HeartbeatResponse) GetGeneration() int64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*VDeviceRequest)(nil), "vcuda.VDeviceRequest")
 	proto.RegisterType((*VDeviceResponse)(nil), "vcuda.VDeviceResponse")
+	proto.RegisterType((*ConfigRequest)(nil), "vcuda.ConfigRequest")
+	proto.RegisterType((*ContainerConfig)(nil), "vcuda.ContainerConfig")
+	proto.RegisterType((*ProcessUsage)(nil), "vcuda.ProcessUsage")
+	proto.RegisterType((*UsageRequest)(nil), "vcuda.UsageRequest")
+	proto.RegisterType((*UsageResponse)(nil), "vcuda.UsageResponse")
+	proto.RegisterType((*HeartbeatRequest)(nil), "vcuda.HeartbeatRequest")
+	proto.RegisterType((*HeartbeatResponse)(nil), "vcuda.HeartbeatResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -98,6 +377,10 @@ const _ = grpc.SupportPackageIsVersion4
 
 type VCUDAServiceClient interface {
 	RegisterVDevice(ctx context.Context, in *VDeviceRequest, opts ...grpc.CallOption) (*VDeviceResponse, error)
+	GetConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ContainerConfig, error)
+	WatchConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (VCUDAService_WatchConfigClient, error)
+	ReportUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
 }
 
 type vCUDAServiceClient struct {
@@ -117,10 +400,73 @@ func (c *vCUDAServiceClient) RegisterVDevice(ctx context.Context, in *VDeviceReq
 	return out, nil
 }
 
+func (c *vCUDAServiceClient) GetConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ContainerConfig, error) {
+	out := new(ContainerConfig)
+	err := grpc.Invoke(ctx, "/vcuda.VCUDAService/GetConfig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vCUDAServiceClient) WatchConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (VCUDAService_WatchConfigClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_VCUDAService_serviceDesc.Streams[0], c.cc, "/vcuda.VCUDAService/WatchConfig", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vCUDAServiceWatchConfigClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type VCUDAService_WatchConfigClient interface {
+	Recv() (*ContainerConfig, error)
+	grpc.ClientStream
+}
+
+type vCUDAServiceWatchConfigClient struct {
+	grpc.ClientStream
+}
+
+func (x *vCUDAServiceWatchConfigClient) Recv() (*ContainerConfig, error) {
+	m := new(ContainerConfig)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *vCUDAServiceClient) ReportUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	out := new(UsageResponse)
+	err := grpc.Invoke(ctx, "/vcuda.VCUDAService/ReportUsage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vCUDAServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := grpc.Invoke(ctx, "/vcuda.VCUDAService/Heartbeat", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for VCUDAService service
 
 type VCUDAServiceServer interface {
 	RegisterVDevice(context.Context, *VDeviceRequest) (*VDeviceResponse, error)
+	GetConfig(context.Context, *ConfigRequest) (*ContainerConfig, error)
+	WatchConfig(*ConfigRequest, VCUDAService_WatchConfigServer) error
+	ReportUsage(context.Context, *UsageRequest) (*UsageResponse, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
 }
 
 func RegisterVCUDAServiceServer(s *grpc.Server, srv VCUDAServiceServer) {
@@ -145,6 +491,81 @@ func _VCUDAService_RegisterVDevice_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VCUDAService_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VCUDAServiceServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcuda.VCUDAService/GetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VCUDAServiceServer).GetConfig(ctx, req.(*ConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VCUDAService_WatchConfig_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ConfigRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VCUDAServiceServer).WatchConfig(m, &vCUDAServiceWatchConfigServer{stream})
+}
+
+type VCUDAService_WatchConfigServer interface {
+	Send(*ContainerConfig) error
+	grpc.ServerStream
+}
+
+type vCUDAServiceWatchConfigServer struct {
+	grpc.ServerStream
+}
+
+func (x *vCUDAServiceWatchConfigServer) Send(m *ContainerConfig) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _VCUDAService_ReportUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VCUDAServiceServer).ReportUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcuda.VCUDAService/ReportUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VCUDAServiceServer).ReportUsage(ctx, req.(*UsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VCUDAService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VCUDAServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcuda.VCUDAService/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VCUDAServiceServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _VCUDAService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "vcuda.VCUDAService",
 	HandlerType: (*VCUDAServiceServer)(nil),
@@ -153,28 +574,71 @@ var _VCUDAService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterVDevice",
 			Handler:    _VCUDAService_RegisterVDevice_Handler,
 		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _VCUDAService_GetConfig_Handler,
+		},
+		{
+			MethodName: "ReportUsage",
+			Handler:    _VCUDAService_ReportUsage_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _VCUDAService_Heartbeat_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchConfig",
+			Handler:       _VCUDAService_WatchConfig_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/api/runtime/vcuda/api.proto",
 }
 
 func init() { proto.RegisterFile("pkg/api/runtime/vcuda/api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 226 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0xd0, 0xcf, 0x4a, 0xc3, 0x40,
-	0x10, 0x06, 0x70, 0xa3, 0x36, 0xe2, 0x58, 0x5b, 0x5c, 0xa8, 0x06, 0x2f, 0x6a, 0x40, 0xf0, 0x94,
-	0x80, 0x3e, 0x81, 0xda, 0x4b, 0x2f, 0x1e, 0x56, 0xda, 0x6b, 0xd8, 0x64, 0x86, 0x32, 0x48, 0x76,
-	0xd7, 0xfd, 0xd3, 0x87, 0xf0, 0xa9, 0x25, 0xab, 0x44, 0xec, 0xf5, 0x37, 0x1f, 0xc3, 0x7c, 0x03,
-	0x37, 0xf6, 0x63, 0x5b, 0x2b, 0xcb, 0xb5, 0x8b, 0x3a, 0x70, 0x4f, 0xf5, 0xae, 0x8b, 0xa8, 0x06,
-	0xa9, 0xac, 0x33, 0xc1, 0x88, 0x49, 0x82, 0xf2, 0x2b, 0x83, 0xd9, 0x66, 0x49, 0x3b, 0xee, 0x48,
-	0xd2, 0x67, 0x24, 0x1f, 0xc4, 0x02, 0xf2, 0x36, 0xfa, 0x86, 0xb1, 0xc8, 0x6e, 0xb3, 0x87, 0x53,
-	0x39, 0x69, 0xa3, 0x5f, 0xa1, 0xb8, 0x82, 0x13, 0x6b, 0xb0, 0x89, 0x8c, 0xc5, 0x61, 0xf2, 0xdc,
-	0x1a, 0x5c, 0x33, 0x8a, 0x7b, 0x98, 0x75, 0x46, 0x07, 0xc5, 0x9a, 0x5c, 0xa3, 0x55, 0x4f, 0xc5,
-	0x51, 0x9a, 0x9f, 0x8f, 0xfa, 0xa6, 0x7a, 0x12, 0x77, 0x30, 0xfd, 0x8b, 0x31, 0x16, 0xc7, 0x29,
-	0x74, 0x36, 0xda, 0x0a, 0xcb, 0x0b, 0x98, 0x8f, 0xb7, 0x78, 0x6b, 0xb4, 0xa7, 0x47, 0x09, 0xd3,
-	0xcd, 0xeb, 0x7a, 0xf9, 0xfc, 0x4e, 0x6e, 0x70, 0xf1, 0x02, 0x73, 0x49, 0x5b, 0xf6, 0x81, 0xdc,
-	0x6f, 0x54, 0x2c, 0xaa, 0x54, 0xa5, 0xfa, 0x5f, 0xe3, 0xfa, 0x72, 0x9f, 0x7f, 0x36, 0x96, 0x07,
-	0x6d, 0x9e, 0x3e, 0xf0, 0xf4, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x6a, 0xa0, 0x48, 0xf8, 0x24, 0x01,
-	0x00, 0x00,
+	// 627 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x54, 0xdb, 0x6e, 0xd3, 0x40,
+	0x10, 0xad, 0xe3, 0xe6, 0xe2, 0x71, 0xd2, 0xb4, 0x4b, 0x2f, 0xa6, 0x08, 0x6a, 0x2c, 0x21, 0xf2,
+	0xd4, 0x42, 0xfb, 0x06, 0x0f, 0x5c, 0x52, 0x09, 0x2a, 0xa0, 0x42, 0x2e, 0x29, 0x8f, 0xd6, 0x26,
+	0x1e, 0xdc, 0x15, 0xb1, 0x77, 0xe3, 0x5d, 0xa7, 0x02, 0x89, 0x1f, 0xe0, 0x07, 0xf8, 0x2b, 0xbe,
+	0x09, 0x79, 0xed, 0xa4, 0x4e, 0x22, 0x24, 0x84, 0xfa, 0xe6, 0x39, 0x3b, 0x73, 0xe6, 0xcc, 0xcd,
+	0x70, 0x20, 0xbe, 0x46, 0x47, 0x54, 0xb0, 0xa3, 0x34, 0x4b, 0x14, 0x8b, 0xf1, 0x68, 0x3a, 0xca,
+	0x42, 0x9a, 0x23, 0x87, 0x22, 0xe5, 0x8a, 0x93, 0xba, 0x06, 0xbc, 0x9f, 0x06, 0x6c, 0x5c, 0x9e,
+	0xe2, 0x94, 0x8d, 0xd0, 0xc7, 0x49, 0x86, 0x52, 0x91, 0x1d, 0x68, 0x0c, 0x33, 0x19, 0xb0, 0xd0,
+	0x31, 0x5c, 0xa3, 0x67, 0xf9, 0xf5, 0x61, 0x26, 0xcf, 0x42, 0xb2, 0x07, 0x4d, 0xc1, 0xc3, 0x20,
+	0x63, 0xa1, 0x53, 0xd3, 0x78, 0x43, 0xf0, 0x70, 0xc0, 0x42, 0xf2, 0x08, 0x36, 0x46, 0x3c, 0x51,
+	0x94, 0x25, 0x98, 0x06, 0x09, 0x8d, 0xd1, 0x31, 0xf5, 0x7b, 0x67, 0x8e, 0x9e, 0xd3, 0x18, 0xc9,
+	0x43, 0x68, 0xdf, 0xb8, 0xb1, 0xd0, 0x59, 0xd7, 0x4e, 0xf6, 0x1c, 0x3b, 0x0b, 0xbd, 0x2d, 0xe8,
+	0xce, 0xb5, 0x48, 0xc1, 0x13, 0x89, 0xde, 0x3b, 0xe8, 0xf4, 0x79, 0xf2, 0x85, 0x45, 0x33, 0x75,
+	0x15, 0x19, 0xc6, 0x82, 0x8c, 0x65, 0xfe, 0xda, 0x2a, 0xff, 0x2f, 0x13, 0xba, 0xfd, 0x99, 0x5d,
+	0xd0, 0xfe, 0x9d, 0x6f, 0xb5, 0xac, 0xda, 0xbf, 0x94, 0x65, 0xae, 0xa4, 0x25, 0xf7, 0x01, 0x22,
+	0x91, 0x05, 0x31, 0xc6, 0x3c, 0xfd, 0xa6, 0xeb, 0x5e, 0xf7, 0xad, 0x48, 0x64, 0x1f, 0x34, 0x40,
+	0x5c, 0xb0, 0x33, 0xc5, 0xc6, 0xec, 0x3b, 0x55, 0x8c, 0x27, 0x4e, 0xdd, 0x35, 0x7a, 0x75, 0xbf,
+	0x0a, 0xe5, 0x04, 0x57, 0x34, 0x0d, 0x83, 0x31, 0x8b, 0x99, 0x72, 0x1a, 0xae, 0xd1, 0x6b, 0xf9,
+	0x56, 0x8e, 0xbc, 0xcf, 0x01, 0xb2, 0x0d, 0xf5, 0xe2, 0xa5, 0xa9, 0x43, 0x0b, 0x83, 0xec, 0x42,
+	0x03, 0x13, 0x3a, 0x1c, 0xa3, 0xd3, 0xd2, 0x01, 0xa5, 0x45, 0xee, 0x81, 0x35, 0xe1, 0x32, 0x18,
+	0x8d, 0xa9, 0x94, 0x8e, 0xa5, 0xd5, 0xb6, 0x26, 0x5c, 0xf6, 0x73, 0x9b, 0x3c, 0x86, 0x6e, 0xcc,
+	0x92, 0xa0, 0xaa, 0x07, 0x34, 0xe9, 0x46, 0xcc, 0x92, 0x41, 0x45, 0xd2, 0x5d, 0x68, 0x65, 0xd3,
+	0x38, 0x90, 0xd7, 0x54, 0x38, 0xb6, 0xe6, 0x6f, 0x66, 0xd3, 0xf8, 0xe2, 0x9a, 0x0a, 0x42, 0x60,
+	0x5d, 0xb0, 0x50, 0x3a, 0x6d, 0xd7, 0xec, 0xd5, 0x7d, 0xfd, 0x4d, 0x1e, 0x00, 0x44, 0x98, 0x60,
+	0x5a, 0x50, 0x76, 0x5c, 0xa3, 0x67, 0xfa, 0x15, 0xc4, 0x9b, 0x40, 0xfb, 0x63, 0xca, 0x47, 0x28,
+	0xe5, 0x40, 0xd2, 0x08, 0xc9, 0x26, 0x98, 0xa2, 0x9c, 0x48, 0xdd, 0xcf, 0x3f, 0x2b, 0x5b, 0x59,
+	0xab, 0x6e, 0xe5, 0x01, 0xd8, 0x99, 0xc4, 0x70, 0xd6, 0x5c, 0x53, 0x37, 0x17, 0x72, 0xa8, 0xec,
+	0xee, 0x1e, 0x34, 0x65, 0x1c, 0xe4, 0x27, 0x50, 0x76, 0xbe, 0x21, 0xe3, 0x4f, 0x2c, 0x46, 0xef,
+	0x07, 0xb4, 0x75, 0xae, 0x5b, 0x58, 0x2c, 0xf2, 0x14, 0x2c, 0x51, 0xc8, 0x47, 0xe9, 0x98, 0xae,
+	0xd9, 0xb3, 0x8f, 0xef, 0x1c, 0xea, 0x03, 0x3b, 0xac, 0x96, 0xe5, 0xdf, 0x78, 0x79, 0x5d, 0xe8,
+	0x94, 0xe9, 0xcb, 0x4d, 0x3f, 0x87, 0xcd, 0xb7, 0x48, 0x53, 0x35, 0x44, 0xaa, 0x6e, 0x63, 0xd9,
+	0x4f, 0x60, 0xab, 0xc2, 0x57, 0x24, 0x59, 0x9a, 0x83, 0xb1, 0x3c, 0x87, 0xe3, 0xdf, 0x35, 0x68,
+	0x5f, 0xf6, 0x07, 0xa7, 0xaf, 0x2e, 0x30, 0xcd, 0xef, 0x90, 0xbc, 0x86, 0xae, 0x8f, 0x11, 0x93,
+	0x0a, 0xd3, 0xf2, 0x34, 0xc9, 0x4e, 0x59, 0xd9, 0xe2, 0x6f, 0x63, 0x7f, 0x77, 0x19, 0x2e, 0xeb,
+	0x5a, 0x23, 0xcf, 0xc1, 0x7a, 0x83, 0xaa, 0xbc, 0xb7, 0xed, 0xd2, 0x6d, 0xe1, 0xaa, 0xe7, 0xc1,
+	0x4b, 0xd7, 0xe9, 0xad, 0x91, 0x17, 0x60, 0x7f, 0xa6, 0x6a, 0x74, 0xf5, 0x7f, 0xe1, 0x4f, 0x0c,
+	0xf2, 0x0c, 0x6c, 0x1f, 0x05, 0x4f, 0x55, 0xb1, 0x59, 0xb3, 0xb9, 0x54, 0x67, 0xbf, 0xbf, 0xbd,
+	0x08, 0xce, 0x95, 0xbf, 0x04, 0x6b, 0xde, 0x43, 0xb2, 0x57, 0x3a, 0x2d, 0x4f, 0x69, 0xdf, 0x59,
+	0x7d, 0x98, 0x31, 0x0c, 0x1b, 0xfa, 0x6f, 0x7b, 0xf2, 0x67, 0x00, 0x85, 0x30, 0x6b, 0x05, 0x90,
+	0x05, 0x00, 0x00,
 }
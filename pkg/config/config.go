@@ -18,14 +18,23 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Config contains the necessary options for the plugin.
 type Config struct {
 	Driver                   string
+	ConfigFile               string
 	ExtraConfigPath          string
 	QueryPort                int
 	QueryAddr                string
@@ -37,16 +46,211 @@ type Config struct {
 	DevicePluginPath         string
 	VolumeConfigPath         string
 	EnableShare              bool
+	OversubscriptionRatio    float64
 	AllocationCheckPeriod    time.Duration
 	CheckpointPath           string
 	ContainerRuntimeEndpoint string
 	CgroupDriver             string
 	RequestTimeout           time.Duration
+	RecycleGracePeriod       time.Duration
+	CandidateWaitTimeout     time.Duration
+
+	//MemoryOversubscriptionRatio is the ratio of memory can be committed
+	//to a card to its physical memory, CardMemoryOversubscriptionRatios
+	//overrides it by device name
+	MemoryOversubscriptionRatio      float64
+	CardMemoryOversubscriptionRatios map[string]float64
+	EnableUVMSwap                    bool
+	//MemoryBlockSize is the size of a vmemory block in bytes
+	MemoryBlockSize int64
 
 	VCudaRequestsQueue chan *types.VCudaRequest
+	ExtraConfig        *ExtraConfigStore
 }
 
-//ExtraConfig contains extra options other than Config
+// ExtraConfig contains extra options other than Config
 type ExtraConfig struct {
 	Devices []string `json:"devices,omitempty"`
+	//Quotas limits GPU used by namespaces on this node, keyed by namespace,
+	//AllNamespaces applies to namespaces which are not listed
+	Quotas map[string]*GPUQuota `json:"quotas,omitempty"`
+}
+
+// AllNamespaces is the key of GPUQuota for namespaces without their own quota
+const AllNamespaces = "*"
+
+// GPUQuota limits GPU used by pods of a namespace on this node, a zero field
+// means no limit
+type GPUQuota struct {
+	//Cards is the max number of physical cards
+	Cards int `json:"cards,omitempty"`
+	//Cores is the max vcore in total, 100 per card
+	Cores int64 `json:"cores,omitempty"`
+	//CoresPerCard is the max vcore on any single card, e.g. 30 is 30% of
+	//a card
+	CoresPerCard int64 `json:"coresPerCard,omitempty"`
+	//Memory is the max GPU memory in total
+	Memory *resource.Quantity `json:"memory,omitempty"`
+}
+
+// QuotaOf returns quota of namespace, nil if there is no quota
+func (c *ExtraConfig) QuotaOf(namespace string) *GPUQuota {
+	if c == nil {
+		return nil
+	}
+
+	if quota, ok := c.Quotas[namespace]; ok {
+		return quota
+	}
+
+	return c.Quotas[AllNamespaces]
+}
+
+// MemoryLimit returns the max GPU memory in bytes, 0 means no limit
+func (q *GPUQuota) MemoryLimit() int64 {
+	if q.Memory == nil {
+		return 0
+	}
+
+	return q.Memory.Value()
+}
+
+// ParseExtraConfig decodes and validates the content of extra config file
+func ParseExtraConfig(data []byte) (map[string]*ExtraConfig, error) {
+	cfg := make(map[string]*ExtraConfig)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateExtraConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ValidateExtraConfig checks every entry of extra config
+func ValidateExtraConfig(cfg map[string]*ExtraConfig) error {
+	for name, item := range cfg {
+		if item == nil {
+			return fmt.Errorf("extra config %s is empty", name)
+		}
+
+		for _, dev := range item.Devices {
+			if !filepath.IsAbs(dev) || !strings.HasPrefix(filepath.Clean(dev), "/dev/") {
+				return fmt.Errorf("extra config %s has invalid device %q, must be an absolute path under /dev", name, dev)
+			}
+		}
+
+		for ns, quota := range item.Quotas {
+			if err := validateGPUQuota(quota); err != nil {
+				return fmt.Errorf("extra config %s has invalid quota of %q, %v", name, ns, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateGPUQuota(quota *GPUQuota) error {
+	if quota == nil {
+		return fmt.Errorf("quota is empty")
+	}
+
+	if quota.Cards < 0 || quota.Cores < 0 || quota.MemoryLimit() < 0 {
+		return fmt.Errorf("quota can't be negative")
+	}
+
+	if quota.CoresPerCard < 0 || quota.CoresPerCard > 100 {
+		return fmt.Errorf("coresPerCard %d must be in [0, 100]", quota.CoresPerCard)
+	}
+
+	return nil
+}
+
+var cardNameRE = regexp.MustCompile(`^/dev/nvidia[0-9]+$`)
+
+// ValidateMemoryOversubscription checks memory oversubscription ratios, the
+// memory beyond physical memory of a card is backed by unified memory swap,
+// so it can't be enabled without swap
+func ValidateMemoryOversubscription(ratio float64, cardRatios map[string]float64, enableSwap bool) error {
+	if ratio < 1 {
+		return fmt.Errorf("memoryOversubscriptionRatio %v must be at least 1", ratio)
+	}
+
+	oversubscribed := ratio > 1
+	for name, r := range cardRatios {
+		if !cardNameRE.MatchString(name) {
+			return fmt.Errorf("memory oversubscription ratio has invalid device %q, must be like /dev/nvidia0", name)
+		}
+
+		if r < 1 {
+			return fmt.Errorf("memory oversubscription ratio %v of %s must be at least 1", r, name)
+		}
+
+		if r > 1 {
+			oversubscribed = true
+		}
+	}
+
+	if oversubscribed && !enableSwap {
+		return fmt.Errorf("memory oversubscription requires enableUVMSwap")
+	}
+
+	return nil
+}
+
+// GetMemoryBlockSize returns the size of vmemory block, the default size
+// is used if it's not set
+func (c *Config) GetMemoryBlockSize() int64 {
+	if c.MemoryBlockSize <= 0 {
+		return types.MemoryBlockSize
+	}
+
+	return c.MemoryBlockSize
+}
+
+// ValidateMemoryBlockSize checks the size of vmemory block, it must be
+// whole MiB
+func ValidateMemoryBlockSize(size int64) error {
+	if size <= 0 || size%(1<<20) != 0 {
+		return fmt.Errorf("memoryBlockSize %d must be positive whole MiB", size)
+	}
+
+	return nil
+}
+
+// ExtraConfigStore holds the extra config in effect. The content can be
+// replaced at runtime when the extra config file is reloaded.
+type ExtraConfigStore struct {
+	sync.RWMutex
+	data map[string]*ExtraConfig
+}
+
+// NewExtraConfigStore returns an empty ExtraConfigStore
+func NewExtraConfigStore() *ExtraConfigStore {
+	return &ExtraConfigStore{
+		data: make(map[string]*ExtraConfig),
+	}
+}
+
+// Get returns the extra config with specific name, a nil store has nothing
+func (s *ExtraConfigStore) Get(name string) (*ExtraConfig, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	cfg, ok := s.data[name]
+	return cfg, ok
+}
+
+// Set replaces all extra configs at once
+func (s *ExtraConfigStore) Set(data map[string]*ExtraConfig) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.data = data
 }
@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	data := `apiVersion: gpu-manager.tkestack.io/v1alpha1
+kind: GPUManagerConfiguration
+enableShare: true
+samplePeriod: 5s
+memoryBlockSize: 64Mi
+nodeLabels:
+  gpu-model: tesla
+extraConfig:
+  default:
+    devices:
+    - /dev/nvidia-uvm
+volumes:
+- name: nvidia
+  base: /etc/gpu-manager/vdriver
+  mode: ro
+  components:
+    libraries:
+    - libcuda.so
+`
+	cfg, err := Decode([]byte(data))
+	if err != nil {
+		t.Fatalf("can't decode: %v", err)
+	}
+
+	if !cfg.EnableShare || cfg.SamplePeriod.Duration != 5*time.Second || cfg.NodeLabels["gpu-model"] != "tesla" {
+		t.Fatalf("unexpected values: %+v", cfg)
+	}
+
+	if cfg.MemoryBlockSize.Value() != 64<<20 {
+		t.Fatalf("unexpected memory block size %s", cfg.MemoryBlockSize)
+	}
+
+	if cfg.Driver != DefaultDriver || cfg.QueryPort != DefaultQueryPort || cfg.WaitTimeout.Duration != DefaultWaitTimeout ||
+		cfg.AllocationCheckPeriod.Duration != DefaultAllocationCheckPeriod || cfg.CgroupDriver != DefaultCgroupDriver {
+		t.Fatalf("defaults are not applied: %+v", cfg)
+	}
+
+	if len(cfg.ExtraConfig["default"].Devices) != 1 || len(cfg.Volumes) != 1 || cfg.Volumes[0].Name != "nvidia" {
+		t.Fatalf("embedded config is not decoded: %+v", cfg)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	header := "apiVersion: gpu-manager.tkestack.io/v1alpha1\nkind: GPUManagerConfiguration\n"
+	testCases := []struct {
+		data   string
+		reason string
+	}{
+		{"apiVersion: v1\nkind: GPUManagerConfiguration\n", "apiVersion"},
+		{"apiVersion: gpu-manager.tkestack.io/v1alpha1\nkind: Pod\n", "kind"},
+		{header + "unknownField: 1\n", "unknown field"},
+		{header + "queryPort: 70000\n", "queryPort"},
+		{header + "samplePeriod: 1500ms\n", "whole seconds"},
+		{header + "cgroupDriver: foo\n", "cgroupDriver"},
+		{header + "recycleGracePeriod: -1s\n", "recycleGracePeriod"},
+		{header + "candidateWaitTimeout: -1s\n", "candidateWaitTimeout"},
+		{header + "oversubscriptionRatio: -1\n", "oversubscriptionRatio"},
+		{header + "memoryOversubscriptionRatio: 0.5\n", "memoryOversubscriptionRatio"},
+		{header + "memoryBlockSize: 1000Ki\n", "memoryBlockSize"},
+		{header + "memoryOversubscriptionRatio: 2\n", "enableUVMSwap"},
+		{header + "enableUVMSwap: true\ncardMemoryOversubscriptionRatios:\n  nvidia0: 2\n", "invalid device"},
+		{header + "extraConfigPath: /etc/extra.json\nextraConfig:\n  default: {}\n", "mutually exclusive"},
+		{header + "extraConfig:\n  default:\n    devices: [/tmp/foo]\n", "invalid device"},
+		{header + "extraConfig:\n  default:\n    quotas:\n      team-x:\n        coresPerCard: 200\n", "coresPerCard"},
+		{header + "extraConfig:\n  default:\n    quotas:\n      team-x:\n        memory: -1Gi\n", "negative"},
+		{header + "volumes:\n- name: nvidia\n  base: relative\n", "must be absolute"},
+	}
+
+	for _, tc := range testCases {
+		_, err := Decode([]byte(tc.data))
+		if err == nil {
+			t.Fatalf("expect error for %q", tc.data)
+		}
+
+		if !strings.Contains(err.Error(), tc.reason) {
+			t.Fatalf("expect error contains %q, but got %v", tc.reason, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+const (
+	DefaultDriver                   = "nvidia"
+	DefaultQueryPort                = 5678
+	DefaultQueryAddr                = "localhost"
+	DefaultSamplePeriod             = time.Second
+	DefaultVirtualManagerPath       = "/etc/gpu-manager/vm"
+	DefaultAllocationCheckPeriod    = 30 * time.Second
+	DefaultCheckpointPath           = "/etc/gpu-manager/checkpoint"
+	DefaultContainerRuntimeEndpoint = ""
+	DefaultCgroupDriver             = "cgroupfs"
+	DefaultRuntimeRequestTimeout    = 5 * time.Second
+	DefaultWaitTimeout              = time.Minute
+	DefaultMemoryOversubscription   = 1
+	DefaultRecycleGracePeriod       = 5 * time.Second
+	DefaultCandidateWaitTimeout     = 2 * time.Second
+)
+
+//SetDefaults fills the unset fields of GPUManagerConfiguration
+func SetDefaults(cfg *GPUManagerConfiguration) {
+	if len(cfg.Driver) == 0 {
+		cfg.Driver = DefaultDriver
+	}
+
+	if cfg.QueryPort == 0 {
+		cfg.QueryPort = DefaultQueryPort
+	}
+
+	if len(cfg.QueryAddr) == 0 {
+		cfg.QueryAddr = DefaultQueryAddr
+	}
+
+	if cfg.SamplePeriod.Duration == 0 {
+		cfg.SamplePeriod.Duration = DefaultSamplePeriod
+	}
+
+	if len(cfg.VirtualManagerPath) == 0 {
+		cfg.VirtualManagerPath = DefaultVirtualManagerPath
+	}
+
+	if cfg.AllocationCheckPeriod.Duration == 0 {
+		cfg.AllocationCheckPeriod.Duration = DefaultAllocationCheckPeriod
+	}
+
+	if len(cfg.CheckpointPath) == 0 {
+		cfg.CheckpointPath = DefaultCheckpointPath
+	}
+
+	if len(cfg.CgroupDriver) == 0 {
+		cfg.CgroupDriver = DefaultCgroupDriver
+	}
+
+	if cfg.RuntimeRequestTimeout.Duration == 0 {
+		cfg.RuntimeRequestTimeout.Duration = DefaultRuntimeRequestTimeout
+	}
+
+	if cfg.MemoryOversubscriptionRatio == 0 {
+		cfg.MemoryOversubscriptionRatio = DefaultMemoryOversubscription
+	}
+
+	if cfg.MemoryBlockSize == nil {
+		cfg.MemoryBlockSize = resource.NewQuantity(types.MemoryBlockSize, resource.BinarySI)
+	}
+
+	if cfg.WaitTimeout.Duration == 0 {
+		cfg.WaitTimeout.Duration = DefaultWaitTimeout
+	}
+
+	if cfg.RecycleGracePeriod.Duration == 0 {
+		cfg.RecycleGracePeriod.Duration = DefaultRecycleGracePeriod
+	}
+
+	if cfg.CandidateWaitTimeout.Duration == 0 {
+		cfg.CandidateWaitTimeout.Duration = DefaultCandidateWaitTimeout
+	}
+}
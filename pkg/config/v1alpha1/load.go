@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+//Decode parses the content of configuration file, the result is
+//defaulted and validated. Unknown fields are treated as errors.
+func Decode(data []byte) (*GPUManagerConfiguration, error) {
+	cfg := &GPUManagerConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("can't decode configuration: %v", err)
+	}
+
+	SetDefaults(cfg)
+
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	return cfg, nil
+}
+
+//Load reads and decodes the configuration file
+func Load(path string) (*GPUManagerConfiguration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(data)
+}
@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/services/volume"
+)
+
+const (
+	//GroupName is the group name of gpu manager configuration
+	GroupName = "gpu-manager.tkestack.io"
+	//Version is the version of this configuration schema
+	Version = "v1alpha1"
+	//Kind is the kind of gpu manager configuration
+	Kind = "GPUManagerConfiguration"
+)
+
+//APIVersion is the apiVersion should be used in configuration file
+var APIVersion = GroupName + "/" + Version
+
+//GPUManagerConfiguration contains everything to run gpu manager, it
+//can be loaded from the file specified by --config. Command line flags
+//take precedence over the values in this file.
+type GPUManagerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	//Driver is the driver name for manager
+	Driver string `json:"driver,omitempty"`
+	//ExtraConfigPath is the location of extra config file, it can't be used
+	//together with ExtraConfig
+	ExtraConfigPath string `json:"extraConfigPath,omitempty"`
+	//ExtraConfig is the embedded extra config
+	ExtraConfig map[string]*config.ExtraConfig `json:"extraConfig,omitempty"`
+	//VolumeConfigPath is the location of volume config file, it can't be
+	//used together with Volumes
+	VolumeConfigPath string `json:"volumeConfigPath,omitempty"`
+	//Volumes is the embedded volume config
+	Volumes []volume.Config `json:"volumes,omitempty"`
+	//QueryPort is the port for query statistics information
+	QueryPort int `json:"queryPort,omitempty"`
+	//QueryAddr is the address for query statistics information
+	QueryAddr string `json:"queryAddr,omitempty"`
+	//KubeConfig is the path of kubeconfig file
+	KubeConfig string `json:"kubeconfig,omitempty"`
+	//SamplePeriod is the sample period for each card, must be whole seconds
+	SamplePeriod metav1.Duration `json:"samplePeriod,omitempty"`
+	//NodeLabels are the automated labels for this node
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	//HostnameOverride is used as identification instead of the actual hostname
+	HostnameOverride string `json:"hostnameOverride,omitempty"`
+	//VirtualManagerPath is the path for virtual manager store files
+	VirtualManagerPath string `json:"virtualManagerPath,omitempty"`
+	//DevicePluginPath is the path for kubelet receive device plugin registration
+	DevicePluginPath string `json:"devicePluginPath,omitempty"`
+	//EnableShare enables share mode allocation
+	EnableShare bool `json:"enableShare,omitempty"`
+	//OversubscriptionRatio is the ratio of cores used by guaranteed and
+	//burstable work that best-effort work can oversubscribe on a shared
+	//card, 0 disables oversubscription
+	OversubscriptionRatio float64 `json:"oversubscriptionRatio,omitempty"`
+	//MemoryOversubscriptionRatio is the ratio of memory can be committed to
+	//a card to its physical memory, 1 disables memory oversubscription
+	MemoryOversubscriptionRatio float64 `json:"memoryOversubscriptionRatio,omitempty"`
+	//CardMemoryOversubscriptionRatios overrides MemoryOversubscriptionRatio
+	//of cards by device name, e.g. /dev/nvidia0
+	CardMemoryOversubscriptionRatios map[string]float64 `json:"cardMemoryOversubscriptionRatios,omitempty"`
+	//EnableUVMSwap enables unified memory swap of vcuda library, it's
+	//required by memory oversubscription
+	EnableUVMSwap bool `json:"enableUVMSwap,omitempty"`
+	//MemoryBlockSize is the size of a vmemory block, e.g. 256Mi, it must be
+	//whole MiB
+	MemoryBlockSize *resource.Quantity `json:"memoryBlockSize,omitempty"`
+	//AllocationCheckPeriod is the allocation check period, must be whole seconds
+	AllocationCheckPeriod metav1.Duration `json:"allocationCheckPeriod,omitempty"`
+	//CheckpointPath is the path for checkpoint store file
+	CheckpointPath string `json:"checkpointPath,omitempty"`
+	//ContainerRuntimeEndpoint is the container runtime endpoint, it's detected
+	//from known runtimes if empty
+	ContainerRuntimeEndpoint string `json:"containerRuntimeEndpoint,omitempty"`
+	//CgroupDriver is the driver that the kubelet uses to manipulate cgroups
+	CgroupDriver string `json:"cgroupDriver,omitempty"`
+	//RuntimeRequestTimeout is the request timeout for communicating with
+	//container runtime endpoint
+	RuntimeRequestTimeout metav1.Duration `json:"runtimeRequestTimeout,omitempty"`
+	//WaitTimeout is the wait timeout for resource server ready
+	WaitTimeout metav1.Duration `json:"waitTimeout,omitempty"`
+	//RecycleGracePeriod is the time to wait before GPU and vcuda directory
+	//of a terminated pod are recycled
+	RecycleGracePeriod metav1.Duration `json:"recycleGracePeriod,omitempty"`
+	//CandidateWaitTimeout is the time to wait for the pod of an allocate
+	//request to be seen by informer
+	CandidateWaitTimeout metav1.Duration `json:"candidateWaitTimeout,omitempty"`
+}
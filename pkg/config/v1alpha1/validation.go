@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/services/volume"
+)
+
+//Validate checks the defaulted GPUManagerConfiguration and returns all
+//errors found
+func Validate(cfg *GPUManagerConfiguration) error {
+	var errs []error
+
+	if cfg.APIVersion != APIVersion {
+		errs = append(errs, fmt.Errorf("apiVersion %q is not supported, must be %q", cfg.APIVersion, APIVersion))
+	}
+
+	if cfg.Kind != Kind {
+		errs = append(errs, fmt.Errorf("kind %q is not supported, must be %q", cfg.Kind, Kind))
+	}
+
+	if len(cfg.Driver) == 0 {
+		errs = append(errs, fmt.Errorf("driver can't be empty"))
+	}
+
+	if cfg.QueryPort <= 0 || cfg.QueryPort > 65535 {
+		errs = append(errs, fmt.Errorf("queryPort %d is out of range", cfg.QueryPort))
+	}
+
+	errs = append(errs, validatePeriod("samplePeriod", cfg.SamplePeriod.Duration)...)
+	errs = append(errs, validatePeriod("allocationCheckPeriod", cfg.AllocationCheckPeriod.Duration)...)
+
+	if cfg.RuntimeRequestTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("runtimeRequestTimeout must be positive"))
+	}
+
+	if cfg.WaitTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("waitTimeout must be positive"))
+	}
+
+	if cfg.RecycleGracePeriod.Duration < 0 {
+		errs = append(errs, fmt.Errorf("recycleGracePeriod can't be negative"))
+	}
+
+	if cfg.CandidateWaitTimeout.Duration < 0 {
+		errs = append(errs, fmt.Errorf("candidateWaitTimeout can't be negative"))
+	}
+
+	switch cfg.CgroupDriver {
+	case "cgroupfs", "systemd":
+	default:
+		errs = append(errs, fmt.Errorf("cgroupDriver %q is not supported", cfg.CgroupDriver))
+	}
+
+	if cfg.OversubscriptionRatio < 0 {
+		errs = append(errs, fmt.Errorf("oversubscriptionRatio can't be negative"))
+	}
+
+	if err := config.ValidateMemoryOversubscription(cfg.MemoryOversubscriptionRatio,
+		cfg.CardMemoryOversubscriptionRatios, cfg.EnableUVMSwap); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := config.ValidateMemoryBlockSize(cfg.MemoryBlockSize.Value()); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(cfg.ExtraConfig) > 0 {
+		if len(cfg.ExtraConfigPath) > 0 {
+			errs = append(errs, fmt.Errorf("extraConfigPath and extraConfig are mutually exclusive"))
+		}
+
+		if err := config.ValidateExtraConfig(cfg.ExtraConfig); err != nil {
+			errs = append(errs, fmt.Errorf("extraConfig: %v", err))
+		}
+	}
+
+	if len(cfg.Volumes) > 0 {
+		if len(cfg.VolumeConfigPath) > 0 {
+			errs = append(errs, fmt.Errorf("volumeConfigPath and volumes are mutually exclusive"))
+		}
+
+		if err := volume.ValidateConfig(cfg.Volumes); err != nil {
+			errs = append(errs, fmt.Errorf("volumes: %v", err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func validatePeriod(name string, d time.Duration) []error {
+	if d < time.Second {
+		return []error{fmt.Errorf("%s must be at least 1s", name)}
+	}
+
+	if d%time.Second != 0 {
+		return []error{fmt.Errorf("%s must be whole seconds", name)}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"math/bits"
+	"regexp"
+	"strings"
+)
+
+//NodeFilter decides whether a leaf node can be chosen by evaluators,
+//nil NodeFilter accepts all nodes.
+type NodeFilter func(n *NvidiaNode) bool
+
+var modelSplitPattern = regexp.MustCompile("[^A-Za-z0-9]+")
+
+//MatchModel returns true if model name is selected by selector. Selector
+//matches the full model name or one word of it case-insensitively, e.g.
+//"v100" and "tesla-v100-sxm2-16gb" both select "Tesla V100-SXM2-16GB".
+func MatchModel(model, selector string) bool {
+	model, selector = normalizeModel(model), normalizeModel(selector)
+	if len(model) == 0 || len(selector) == 0 {
+		return false
+	}
+
+	if model == selector {
+		return true
+	}
+
+	for _, w := range strings.Split(model, "-") {
+		if w == selector {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeModel(s string) string {
+	return strings.Trim(modelSplitPattern.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+//ModelFilter returns a NodeFilter which accepts cards selected by any of
+//selectors, it returns nil if no selector is given.
+func ModelFilter(selectors []string) NodeFilter {
+	if len(selectors) == 0 {
+		return nil
+	}
+
+	return func(n *NvidiaNode) bool {
+		for _, s := range selectors {
+			if MatchModel(n.Meta.Model, s) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+//FilterMask returns mask of available leaves of this NvidiaNode which
+//are accepted by filter.
+func (n *NvidiaNode) FilterMask(filter NodeFilter) uint32 {
+	if filter == nil {
+		return n.Mask
+	}
+
+	var filtered uint32
+
+	for mask := n.Mask; mask != 0; {
+		id := uint32(bits.TrailingZeros32(mask))
+		if filter(n.tree.leaves[id]) {
+			filtered |= one << id
+		}
+		mask ^= one << id
+	}
+
+	return filtered
+}
+
+//AvailableWith returns count of available leaves of this NvidiaNode
+//which are accepted by filter.
+func (n *NvidiaNode) AvailableWith(filter NodeFilter) int {
+	return bits.OnesCount32(n.FilterMask(filter))
+}
+
+//GetAvailableLeavesWith returns leaves of this NvidiaNode which are
+//available for allocating and accepted by filter.
+func (n *NvidiaNode) GetAvailableLeavesWith(filter NodeFilter) []*NvidiaNode {
+	var leaves []*NvidiaNode
+
+	for mask := n.FilterMask(filter); mask != 0; {
+		id := uint32(bits.TrailingZeros32(mask))
+		leaves = append(leaves, n.tree.leaves[id])
+		mask ^= one << id
+	}
+
+	return leaves
+}
+
+//MaxMemory returns the largest logical memory of leaves accepted by filter
+func (t *NvidiaTree) MaxMemory(filter NodeFilter) int64 {
+	var max int64
+
+	for _, n := range t.leaves {
+		if filter != nil && !filter(n) {
+			continue
+		}
+
+		if n.LogicalMemory() > max {
+			max = n.LogicalMemory()
+		}
+	}
+
+	return max
+}
+
+//AllFilters returns a NodeFilter which accepts nodes accepted by every one
+//of filters, nil filters are skipped and nil is returned if all are nil.
+func AllFilters(filters ...NodeFilter) NodeFilter {
+	var nonNil []NodeFilter
+	for _, f := range filters {
+		if f != nil {
+			nonNil = append(nonNil, f)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+
+	return func(n *NvidiaNode) bool {
+		for _, f := range nonNil {
+			if !f(n) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"testing"
+)
+
+func TestMatchModel(t *testing.T) {
+	testCases := []struct {
+		model    string
+		selector string
+		expect   bool
+	}{
+		{"Tesla V100-SXM2-16GB", "V100", true},
+		{"Tesla V100-SXM2-16GB", "tesla-v100-sxm2-16gb", true},
+		{"Tesla V100-SXM2-16GB", "Tesla V100-SXM2-16GB", true},
+		{"Tesla V100-SXM2-16GB", "V10", false},
+		{"A100-SXM4-40GB", "A10", false},
+		{"NVIDIA A10", "a10", true},
+		{"", "T4", false},
+		{"Tesla T4", "", false},
+	}
+
+	for _, tc := range testCases {
+		if got := MatchModel(tc.model, tc.selector); got != tc.expect {
+			t.Errorf("MatchModel(%q, %q) expect %v, got %v", tc.model, tc.selector, tc.expect, got)
+		}
+	}
+}
+
+func TestModelFilter(t *testing.T) {
+	obj := NewNvidiaTree(nil)
+	tree, _ := obj.(*NvidiaTree)
+	tree.Init("\tGPU0\tGPU1\tGPU2\nGPU0\tX\tPIX\tPIX\nGPU1\tPIX\tX\tPIX\nGPU2\tPIX\tPIX\tX\n")
+
+	models := []string{"Tesla T4", "Tesla V100-PCIE-32GB", "Tesla T4"}
+	for i, n := range tree.Leaves() {
+		n.Meta.Model = models[i]
+		n.Meta.TotalMemory = uint64(16 * (i + 1))
+	}
+
+	if ModelFilter(nil) != nil {
+		t.Fatalf("empty selectors should accept all cards")
+	}
+
+	filter := ModelFilter([]string{"t4"})
+	if mask := tree.Root().FilterMask(filter); mask != 0x5 {
+		t.Fatalf("expect mask 101, got %b", mask)
+	}
+
+	if tree.Root().AvailableWith(filter) != 2 || tree.Root().AvailableWith(nil) != 3 {
+		t.Fatalf("unexpected available count")
+	}
+
+	if leaves := tree.Root().GetAvailableLeavesWith(ModelFilter([]string{"v100"})); len(leaves) != 1 || leaves[0] != tree.Leaves()[1] {
+		t.Fatalf("unexpected available leaves %v", leaves)
+	}
+
+	if tree.MaxMemory(filter) != 48 || tree.MaxMemory(nil) != 48 || tree.MaxMemory(ModelFilter([]string{"v100"})) != 32 {
+		t.Fatalf("unexpected max memory")
+	}
+
+	if tree.MaxMemory(ModelFilter([]string{"a100"})) != 0 {
+		t.Fatalf("no card should be selected")
+	}
+
+	if AllFilters(nil, nil) != nil {
+		t.Fatalf("all nil filters should accept all cards")
+	}
+
+	notFirst := func(n *NvidiaNode) bool { return n.Meta.ID != 0 }
+	if mask := tree.Root().FilterMask(AllFilters(filter, nil, notFirst)); mask != 0x4 {
+		t.Fatalf("expect mask 100, got %b", mask)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+//Inventory contains GPU cards of a node and free capacity of each card,
+//it's published to let scheduler know the state of the tree.
+type Inventory struct {
+	//Matrix is in the format of `nvidia-smi topo -m`
+	Matrix  string            `json:"matrix"`
+	Devices []InventoryDevice `json:"devices"`
+}
+
+//InventoryDevice contains static information and allocatable resource
+//of a GPU card
+type InventoryDevice struct {
+	TopologyDevice
+	UUID        string         `json:"uuid"`
+	Allocatable SchedulerCache `json:"allocatable"`
+	//BestEffortCores are cores of best-effort work on the card, they are
+	//not counted in allocatable cores
+	BestEffortCores int64 `json:"bestEffortCores,omitempty"`
+	//GuaranteedCores are cores of guaranteed work on the card
+	GuaranteedCores int64 `json:"guaranteedCores,omitempty"`
+}
+
+//Inventory returns the inventory of this NvidiaTree
+func (t *NvidiaTree) Inventory() *Inventory {
+	t.Lock()
+	defer t.Unlock()
+
+	topo := t.topology()
+	inv := &Inventory{
+		Matrix:  topo.Matrix,
+		Devices: make([]InventoryDevice, 0, len(topo.Devices)),
+	}
+
+	for i, dev := range topo.Devices {
+		n := t.leaves[i]
+		inv.Devices = append(inv.Devices, InventoryDevice{
+			TopologyDevice:  dev,
+			UUID:            n.Meta.UUID,
+			Allocatable:     n.AllocatableMeta,
+			BestEffortCores: n.BestEffortCores,
+			GuaranteedCores: n.GuaranteedCores,
+		})
+	}
+
+	return inv
+}
+
+//Topology returns the topology part of inventory
+func (inv *Inventory) Topology() *Topology {
+	topo := &Topology{
+		Matrix:  inv.Matrix,
+		Devices: make([]TopologyDevice, 0, len(inv.Devices)),
+	}
+
+	for _, dev := range inv.Devices {
+		topo.Devices = append(topo.Devices, dev.TopologyDevice)
+	}
+
+	return topo
+}
@@ -23,13 +23,14 @@ import (
 
 	"k8s.io/klog"
 
+	"tkestack.io/gpu-manager/pkg/types"
 	"tkestack.io/nvml"
 )
 
 //SchedulerCache contains allocatable resource of GPU
 type SchedulerCache struct {
-	Cores  int64
-	Memory int64
+	Cores  int64 `json:"cores"`
+	Memory int64 `json:"memory"`
 }
 
 //DeviceMeta contains metadata of GPU device
@@ -42,12 +43,21 @@ type DeviceMeta struct {
 	BusId       string
 	Utilization uint
 	UUID        string
+	Model       string
 }
 
 //NvidiaNode represents a node of Nvidia GPU
 type NvidiaNode struct {
 	Meta            DeviceMeta
 	AllocatableMeta SchedulerCache
+	//BestEffortCores are cores of best-effort work sharing this node
+	BestEffortCores int64
+	//GuaranteedCores are cores of guaranteed work on this node, they are
+	//counted in allocatable cores as well
+	GuaranteedCores int64
+	//MemoryRatio is the memory oversubscription ratio of this node, memory
+	//committed beyond physical memory is backed by unified memory swap
+	MemoryRatio float64
 
 	Parent   *NvidiaNode
 	Children []*NvidiaNode
@@ -95,6 +105,53 @@ func (n *NvidiaNode) Type() int {
 	return int(n.ntype)
 }
 
+//LogicalMemory returns memory can be committed to this NvidiaNode, it's
+//rounded down to memory blocks if memory is oversubscribed.
+func (n *NvidiaNode) LogicalMemory() int64 {
+	if n.MemoryRatio <= 1 {
+		return int64(n.Meta.TotalMemory)
+	}
+
+	logical := int64(float64(n.Meta.TotalMemory) * n.MemoryRatio)
+
+	return logical - logical%n.memoryBlockSize()
+}
+
+func (n *NvidiaNode) memoryBlockSize() int64 {
+	if n.tree == nil || n.tree.memoryBlockSize <= 0 {
+		return types.MemoryBlockSize
+	}
+
+	return n.tree.memoryBlockSize
+}
+
+//MemoryOversubscribed returns true if logical memory of this NvidiaNode
+//exceeds physical memory
+func (n *NvidiaNode) MemoryOversubscribed() bool {
+	return n.LogicalMemory() > int64(n.Meta.TotalMemory)
+}
+
+//CommittedMemory returns logical memory committed to this NvidiaNode
+func (n *NvidiaNode) CommittedMemory() int64 {
+	committed := n.LogicalMemory() - n.AllocatableMeta.Memory
+	if committed < 0 {
+		return 0
+	}
+
+	return committed
+}
+
+//CommittedPhysicalMemory returns committed memory backed by physical
+//memory, the rest is backed by unified memory swap
+func (n *NvidiaNode) CommittedPhysicalMemory() int64 {
+	committed := n.CommittedMemory()
+	if committed > int64(n.Meta.TotalMemory) {
+		return int64(n.Meta.TotalMemory)
+	}
+
+	return committed
+}
+
 //GetAvailableLeaves returns leaves of this NvidiaNode
 //which available for allocating.
 func (n *NvidiaNode) GetAvailableLeaves() []*NvidiaNode {
@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+//Fragmentation describes free resource of a NvidiaTree
+type Fragmentation struct {
+	FreeCards  int
+	FreeCores  int64
+	FreeMemory int64
+	//Ratio is the ratio of free cores on partially used cards to all free
+	//cores, they can't be used by exclusive work
+	Ratio float64
+}
+
+//Snapshot returns a copy of this NvidiaTree with the same topology and
+//allocatable resource. The copy never accesses GPU devices, so it can be
+//used to evaluate allocation without changing this tree.
+func (t *NvidiaTree) Snapshot() (*NvidiaTree, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	snapshot, err := NewNvidiaTreeFromTopology(t.topology(), t.memoryBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range t.leaves {
+		c := snapshot.leaves[n.Meta.ID]
+		c.Meta = n.Meta
+		c.Meta.Pids = append([]uint(nil), n.Meta.Pids...)
+		c.AllocatableMeta = n.AllocatableMeta
+		c.BestEffortCores = n.BestEffortCores
+		c.GuaranteedCores = n.GuaranteedCores
+
+		if n.Parent != nil && n.Parent.Mask&n.Mask != n.Mask {
+			snapshot.occupyNode(c)
+		}
+	}
+
+	return snapshot, nil
+}
+
+//Fragmentation returns free resource of this NvidiaTree
+func (t *NvidiaTree) Fragmentation() Fragmentation {
+	t.Lock()
+	defer t.Unlock()
+
+	frag := Fragmentation{
+		FreeCards: t.root.Available(),
+	}
+
+	for _, n := range t.leaves {
+		frag.FreeCores += n.AllocatableMeta.Cores
+		frag.FreeMemory += n.AllocatableMeta.Memory
+	}
+
+	if frag.FreeCores > 0 {
+		frag.Ratio = float64(frag.FreeCores-int64(frag.FreeCards)*HundredCore) / float64(frag.FreeCores)
+	}
+
+	return frag
+}
@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"bytes"
+	"fmt"
+
+	"tkestack.io/nvml"
+)
+
+//Topology is a serializable description of NvidiaTree, it can be
+//published to other hosts to rebuild the same tree without GPU devices.
+type Topology struct {
+	//Matrix is in the format of `nvidia-smi topo -m`
+	Matrix  string           `json:"matrix"`
+	Devices []TopologyDevice `json:"devices"`
+}
+
+//TopologyDevice contains static information of a GPU card
+type TopologyDevice struct {
+	ID          int    `json:"id"`
+	MinorID     int    `json:"minor"`
+	Model       string `json:"model,omitempty"`
+	TotalMemory uint64 `json:"totalMemory"`
+	//MemoryRatio is the memory oversubscription ratio of the card
+	MemoryRatio float64 `json:"memoryRatio,omitempty"`
+}
+
+//Topology returns the description of this NvidiaTree
+func (t *NvidiaTree) Topology() *Topology {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.topology()
+}
+
+func (t *NvidiaTree) topology() *Topology {
+	var buf bytes.Buffer
+
+	for _, n := range t.leaves {
+		buf.WriteString(fmt.Sprintf("\tGPU%d", n.Meta.ID))
+	}
+	buf.WriteString("\n")
+
+	topo := &Topology{
+		Devices: make([]TopologyDevice, 0, len(t.leaves)),
+	}
+
+	for _, a := range t.leaves {
+		buf.WriteString(fmt.Sprintf("GPU%d", a.Meta.ID))
+		for _, b := range t.leaves {
+			if a == b {
+				buf.WriteString("\tX")
+				continue
+			}
+
+			buf.WriteString("\t" + commonAncestor(a, b).String())
+		}
+		buf.WriteString("\n")
+
+		topo.Devices = append(topo.Devices, TopologyDevice{
+			ID:          a.Meta.ID,
+			MinorID:     a.Meta.MinorID,
+			Model:       a.Meta.Model,
+			TotalMemory: a.Meta.TotalMemory,
+			MemoryRatio: a.MemoryRatio,
+		})
+	}
+
+	topo.Matrix = buf.String()
+
+	return topo
+}
+
+func commonAncestor(a, b *NvidiaNode) *NvidiaNode {
+	// Mask of parents is changed by occupied nodes, so compare pointers
+	for p := a.Parent; p != nil; p = p.Parent {
+		for q := b.Parent; q != nil; q = q.Parent {
+			if p == q {
+				return p
+			}
+		}
+	}
+
+	return &NvidiaNode{ntype: nvml.TOPOLOGY_UNKNOWN}
+}
+
+//NewNvidiaTreeFromTopology rebuilds a NvidiaTree from topology without
+//accessing GPU devices, all cards are free in the new tree. The default
+//size is used if memoryBlockSize is 0.
+func NewNvidiaTreeFromTopology(topo *Topology, memoryBlockSize int64) (*NvidiaTree, error) {
+	tree := newNvidiaTree(nil)
+	if memoryBlockSize > 0 {
+		tree.memoryBlockSize = memoryBlockSize
+	}
+
+	if err := tree.parseFromString(topo.Matrix); err != nil {
+		return nil, err
+	}
+
+	if len(topo.Devices) != len(tree.leaves) {
+		return nil, fmt.Errorf("topology has %d cards, but %d devices", len(tree.leaves), len(topo.Devices))
+	}
+
+	tree.query = make(map[string]*NvidiaNode)
+	for _, dev := range topo.Devices {
+		if dev.ID < 0 || dev.ID >= len(tree.leaves) {
+			return nil, fmt.Errorf("invalid device id %d", dev.ID)
+		}
+
+		n := tree.leaves[dev.ID]
+		n.Meta.MinorID = dev.MinorID
+		n.Meta.Model = dev.Model
+		n.Meta.TotalMemory = dev.TotalMemory
+		n.MemoryRatio = dev.MemoryRatio
+		n.AllocatableMeta.Cores = HundredCore
+		n.AllocatableMeta.Memory = n.LogicalMemory()
+
+		if _, ok := tree.query[n.MinorName()]; ok {
+			return nil, fmt.Errorf("duplicated minor id %d", dev.MinorID)
+		}
+		tree.query[n.MinorName()] = n
+	}
+
+	return tree, nil
+}
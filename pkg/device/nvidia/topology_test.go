@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopology(t *testing.T) {
+	testCase :=
+		`    GPU0    GPU1    GPU2    GPU3    GPU4    GPU5
+GPU0      X      PIX     PHB     PHB     SOC     SOC
+GPU1     PIX      X      PHB     PHB     SOC     SOC
+GPU2     PHB     PHB      X      PIX     SOC     SOC
+GPU3     PHB     PHB     PIX      X      SOC     SOC
+GPU4     SOC     SOC     SOC     SOC      X      PIX
+GPU5     SOC     SOC     SOC     SOC     PIX      X
+`
+	obj := NewNvidiaTree(nil)
+	tree, _ := obj.(*NvidiaTree)
+	tree.Init(testCase)
+	for i, n := range tree.Leaves() {
+		n.Meta.MinorID = 5 - i
+		n.Meta.TotalMemory = uint64(1024 * (i + 1))
+		n.AllocatableMeta.Cores = HundredCore
+		n.AllocatableMeta.Memory = int64(n.Meta.TotalMemory)
+	}
+
+	// occupied nodes should not change the topology
+	tree.MarkOccupied(tree.Leaves()[0], HundredCore, 0)
+
+	select {
+	case <-tree.Changed():
+	default:
+		t.Fatalf("change of tree is not notified")
+	}
+
+	inv := tree.Inventory()
+	occupied := 0
+	for i, dev := range inv.Devices {
+		if dev.Allocatable != tree.Leaves()[i].AllocatableMeta {
+			t.Fatalf("allocatable of device %d mismatch, expect %+v, got %+v", i, tree.Leaves()[i].AllocatableMeta, dev.Allocatable)
+		}
+
+		if dev.Allocatable.Cores == 0 {
+			occupied++
+		}
+	}
+
+	if occupied != 1 {
+		t.Fatalf("expect 1 occupied device in inventory, got %d", occupied)
+	}
+
+	topo := tree.Topology()
+	if !reflect.DeepEqual(inv.Topology(), topo) {
+		t.Fatalf("topology of inventory mismatch, expect %+v, got %+v", topo, inv.Topology())
+	}
+
+	rebuilt, err := NewNvidiaTreeFromTopology(topo, 0)
+	if err != nil {
+		t.Fatalf("can't rebuild tree: %v", err)
+	}
+
+	if rebuilt.Available() != tree.Total() {
+		t.Fatalf("all cards should be free in rebuilt tree, got %d", rebuilt.Available())
+	}
+
+	for i, n := range rebuilt.Leaves() {
+		origin := tree.Leaves()[i]
+		if n.Meta.MinorID != origin.Meta.MinorID || n.Meta.TotalMemory != origin.Meta.TotalMemory ||
+			n.AllocatableMeta.Memory != int64(origin.Meta.TotalMemory) || n.AllocatableMeta.Cores != HundredCore {
+			t.Fatalf("device %d mismatch, expect %+v, got %+v", i, origin.Meta, n.Meta)
+		}
+
+		if rebuilt.Query(n.MinorName()) != n {
+			t.Fatalf("can't query %s", n.MinorName())
+		}
+
+		for j, m := range rebuilt.Leaves() {
+			if i == j {
+				continue
+			}
+
+			expect, got := commonAncestor(origin, tree.Leaves()[j]), commonAncestor(n, m)
+			if expect.Type() != got.Type() {
+				t.Fatalf("link between %d and %d mismatch, expect %s, got %s", i, j, expect, got)
+			}
+		}
+	}
+
+	single, err := NewNvidiaTreeFromTopology(&Topology{
+		Matrix:  "\tGPU0\nGPU0\tX\n",
+		Devices: []TopologyDevice{{ID: 0, MinorID: 3, TotalMemory: 1024}},
+	}, 0)
+	if err != nil || single.Query("/dev/nvidia3") == nil {
+		t.Fatalf("can't rebuild single card tree, %v", err)
+	}
+
+	if _, err := NewNvidiaTreeFromTopology(&Topology{Matrix: testCase}, 0); err == nil {
+		t.Fatalf("mismatched devices should be rejected")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	testCase :=
+		`    GPU0    GPU1    GPU2    GPU3
+GPU0      X      PIX     PHB     PHB
+GPU1     PIX      X      PHB     PHB
+GPU2     PHB     PHB      X      PIX
+GPU3     PHB     PHB     PIX      X
+`
+	obj := NewNvidiaTree(nil)
+	tree, _ := obj.(*NvidiaTree)
+	tree.Init(testCase)
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = 1024
+		n.AllocatableMeta.Cores = HundredCore
+		n.AllocatableMeta.Memory = 1024
+	}
+
+	tree.MarkOccupied(tree.Leaves()[0], HundredCore, 0)
+	tree.MarkOccupied(tree.Leaves()[2], 40, 256)
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		t.Fatalf("can't snapshot tree: %v", err)
+	}
+
+	expect := Fragmentation{FreeCards: 2, FreeCores: 260, FreeMemory: 2816, Ratio: 60.0 / 260}
+	if frag := snapshot.Fragmentation(); frag != expect || tree.Fragmentation() != expect {
+		t.Fatalf("expect fragmentation %+v, got %+v", expect, frag)
+	}
+
+	if snapshot.Root().Mask != tree.Root().Mask {
+		t.Fatalf("mask of snapshot mismatch, expect %b, got %b", tree.Root().Mask, snapshot.Root().Mask)
+	}
+
+	snapshot.MarkOccupied(snapshot.Leaves()[1], HundredCore, 0)
+	snapshot.MarkFree(snapshot.Leaves()[2], 40, 256)
+	if tree.Available() != 2 || tree.Leaves()[2].AllocatableMeta.Cores != 60 {
+		t.Fatalf("tree should not be changed by snapshot")
+	}
+
+	if snapshot.Available() != 2 || snapshot.Fragmentation().Ratio != 0 {
+		t.Fatalf("unexpected snapshot %+v", snapshot.Fragmentation())
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"tkestack.io/gpu-manager/pkg/config"
 	"tkestack.io/gpu-manager/pkg/device"
+	"tkestack.io/gpu-manager/pkg/types"
 
 	"k8s.io/klog"
 	"tkestack.io/nvml"
@@ -58,6 +59,11 @@ type NvidiaTree struct {
 	query        map[string]*NvidiaNode
 	index        int
 	samplePeriod time.Duration
+	changed      chan struct{}
+
+	memoryRatio      float64
+	cardMemoryRatios map[string]float64
+	memoryBlockSize  int64
 }
 
 func init() {
@@ -73,12 +79,18 @@ func NewNvidiaTree(cfg *config.Config) device.GPUTree {
 
 func newNvidiaTree(cfg *config.Config) *NvidiaTree {
 	tree := &NvidiaTree{
-		query: make(map[string]*NvidiaNode),
-		index: 0,
+		query:   make(map[string]*NvidiaNode),
+		index:   0,
+		changed: make(chan struct{}, 1),
+
+		memoryBlockSize: types.MemoryBlockSize,
 	}
 
 	if cfg != nil {
 		tree.samplePeriod = cfg.SamplePeriod
+		tree.memoryRatio = cfg.MemoryOversubscriptionRatio
+		tree.cardMemoryRatios = cfg.CardMemoryOversubscriptionRatios
+		tree.memoryBlockSize = cfg.GetMemoryBlockSize()
 	}
 
 	return tree
@@ -124,7 +136,7 @@ func (t *NvidiaTree) Update() {
 	for i := range t.Leaves() {
 		node := t.updateNode(i)
 
-		if node.pendingReset && node.AllocatableMeta.Cores == HundredCore {
+		if node.pendingReset && node.AllocatableMeta.Cores == HundredCore && node.BestEffortCores == 0 {
 			resetGPUFeature(node, t.realMode)
 
 			if !node.pendingReset {
@@ -190,14 +202,17 @@ func (t *NvidiaTree) parseFromLibrary() error {
 		pciInfo, _ := dev.DeviceGetPciInfo()
 		minorID, _ := dev.DeviceGetMinorNumber()
 		uuid, _ := dev.DeviceGetUUID()
+		name, _ := dev.DeviceGetName()
 
 		n := t.allocateNode(i)
-		n.AllocatableMeta.Cores = HundredCore
-		n.AllocatableMeta.Memory = int64(totalMem)
 		n.Meta.TotalMemory = totalMem
 		n.Meta.BusId = pciInfo.BusID
 		n.Meta.MinorID = int(minorID)
 		n.Meta.UUID = uuid
+		n.Meta.Model = name
+		n.MemoryRatio = t.memoryRatioOf(n.MinorName())
+		n.AllocatableMeta.Cores = HundredCore
+		n.AllocatableMeta.Memory = n.LogicalMemory()
 
 		t.addNode(n)
 	}
@@ -236,6 +251,16 @@ func (t *NvidiaTree) parseFromLibrary() error {
 	return nil
 }
 
+//memoryRatioOf returns the memory oversubscription ratio of card, the
+//ratio of card takes precedence over the ratio of node
+func (t *NvidiaTree) memoryRatioOf(name string) float64 {
+	if ratio, ok := t.cardMemoryRatios[name]; ok {
+		return ratio
+	}
+
+	return t.memoryRatio
+}
+
 func (t *NvidiaTree) parseFromString(input string) error {
 	if input == "" {
 		return fmt.Errorf("no input")
@@ -423,13 +448,40 @@ func (t *NvidiaTree) MarkFree(node *NvidiaNode, util int64, memory int64) {
 		return
 	}
 
+	defer t.notifyChanged()
+
+	t.free(n, util, memory)
+}
+
+//MarkFreeGuaranteed updates a NvidiaNode like MarkFree for guaranteed work.
+func (t *NvidiaTree) MarkFreeGuaranteed(node *NvidiaNode, util int64, memory int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	n, ok := t.query[node.MinorName()]
+	if !ok {
+		klog.V(2).Infof("Can not find node with name(%s)", node.MinorName())
+		return
+	}
+
+	defer t.notifyChanged()
+
+	n.GuaranteedCores -= guaranteedCores(util)
+	if n.GuaranteedCores < 0 {
+		n.GuaranteedCores = 0
+	}
+
+	t.free(n, util, memory)
+}
+
+func (t *NvidiaTree) free(n *NvidiaNode, util int64, memory int64) {
 	klog.V(2).Infof("Free %s with %d %d", n.MinorName(), util, memory)
 	// exclusive mode
 	if util >= HundredCore {
 		klog.V(2).Infof("%s cores %d->%d", n.MinorName(), n.AllocatableMeta.Cores, HundredCore)
 		n.AllocatableMeta.Cores = HundredCore
-		klog.V(2).Infof("%s memory %d->%d", n.MinorName(), n.AllocatableMeta.Memory, n.Meta.TotalMemory)
-		n.AllocatableMeta.Memory = int64(n.Meta.TotalMemory)
+		klog.V(2).Infof("%s memory %d->%d", n.MinorName(), n.AllocatableMeta.Memory, n.LogicalMemory())
+		n.AllocatableMeta.Memory = n.LogicalMemory()
 	} else {
 		klog.V(2).Infof("%s cores %d->%d", n.MinorName(), n.AllocatableMeta.Cores, n.AllocatableMeta.Cores+util)
 		n.AllocatableMeta.Cores += util
@@ -439,28 +491,63 @@ func (t *NvidiaTree) MarkFree(node *NvidiaNode, util int64, memory int64) {
 
 		n.AllocatableMeta.Memory += memory
 		klog.V(2).Infof("%s memory %d->%d", n.MinorName(), n.AllocatableMeta.Memory, n.AllocatableMeta.Memory+memory)
-		if n.AllocatableMeta.Memory > int64(n.Meta.TotalMemory) {
-			n.AllocatableMeta.Memory = int64(n.Meta.TotalMemory)
+		if n.AllocatableMeta.Memory > n.LogicalMemory() {
+			n.AllocatableMeta.Memory = n.LogicalMemory()
 		}
 	}
 
-	if n.AllocatableMeta.Cores == HundredCore {
-		if t.realMode {
-			n.pendingReset = true
-			// We need to clear user settings
-			if err := resetGPUFeature(n, t.realMode); err != nil {
-				klog.Warningf("can't reset GPU %s, %v", n.Meta.BusId, err)
-			}
+	t.freeIfIdle(n)
+}
 
-			if n.pendingReset {
-				klog.Warningf("GPU %s has some functional error, waiting for reset", n.Meta.BusId)
-				return
-			}
+//MarkFreeBestEffort updates a NvidiaNode by freeing best-effort cores and
+//memory.
+func (t *NvidiaTree) MarkFreeBestEffort(node *NvidiaNode, util int64, memory int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	n, ok := t.query[node.MinorName()]
+	if !ok {
+		klog.V(2).Infof("Can not find node with name(%s)", node.MinorName())
+		return
+	}
+
+	defer t.notifyChanged()
+
+	klog.V(2).Infof("Free best-effort %s with %d %d", n.MinorName(), util, memory)
+	n.BestEffortCores -= util
+	if n.BestEffortCores < 0 {
+		n.BestEffortCores = 0
+	}
+
+	n.AllocatableMeta.Memory += memory
+	if n.AllocatableMeta.Memory > n.LogicalMemory() {
+		n.AllocatableMeta.Memory = n.LogicalMemory()
+	}
+
+	t.freeIfIdle(n)
+}
+
+//freeIfIdle updates mask of all parents if no work is on the node
+func (t *NvidiaTree) freeIfIdle(n *NvidiaNode) {
+	if n.AllocatableMeta.Cores != HundredCore || n.BestEffortCores > 0 {
+		return
+	}
+
+	if t.realMode {
+		n.pendingReset = true
+		// We need to clear user settings
+		if err := resetGPUFeature(n, t.realMode); err != nil {
+			klog.Warningf("can't reset GPU %s, %v", n.Meta.BusId, err)
 		}
 
-		klog.V(2).Infof("Free %s, mask %b", n.MinorName(), n.Mask)
-		t.freeNode(n)
+		if n.pendingReset {
+			klog.Warningf("GPU %s has some functional error, waiting for reset", n.Meta.BusId)
+			return
+		}
 	}
+
+	klog.V(2).Infof("Free %s, mask %b", n.MinorName(), n.Mask)
+	t.freeNode(n)
 }
 
 func (t *NvidiaTree) freeNode(n *NvidiaNode) {
@@ -484,6 +571,30 @@ func (t *NvidiaTree) MarkOccupied(node *NvidiaNode, util int64, memory int64) {
 		return
 	}
 
+	defer t.notifyChanged()
+
+	t.occupy(n, util, memory)
+}
+
+//MarkOccupiedGuaranteed updates a NvidiaNode like MarkOccupied for
+//guaranteed work, whose cores cap best-effort work of the node.
+func (t *NvidiaTree) MarkOccupiedGuaranteed(node *NvidiaNode, util int64, memory int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	n, ok := t.query[node.MinorName()]
+	if !ok {
+		klog.V(2).Infof("Can not find node with name(%s)", node.MinorName())
+		return
+	}
+
+	defer t.notifyChanged()
+
+	t.occupy(n, util, memory)
+	n.GuaranteedCores += guaranteedCores(util)
+}
+
+func (t *NvidiaTree) occupy(n *NvidiaNode, util int64, memory int64) {
 	klog.V(2).Infof("Occupy %s with %d %d, mask %b", n.MinorName(), util, memory, n.Mask)
 	t.occupyNode(n)
 
@@ -508,6 +619,41 @@ func (t *NvidiaTree) MarkOccupied(node *NvidiaNode, util int64, memory int64) {
 	}
 }
 
+//guaranteedCores returns cores of a card taken by a request, exclusive
+//requests take the whole card
+func guaranteedCores(util int64) int64 {
+	if util >= HundredCore {
+		return HundredCore
+	}
+
+	return util
+}
+
+//MarkOccupiedBestEffort updates a NvidiaNode by adding best-effort cores
+//and memory. Best-effort cores only run in idle time slices, so they don't
+//reduce allocatable cores, but the node is not free for exclusive use.
+func (t *NvidiaTree) MarkOccupiedBestEffort(node *NvidiaNode, util int64, memory int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	n, ok := t.query[node.MinorName()]
+	if !ok {
+		klog.V(2).Infof("Can not find node with name(%s)", node.MinorName())
+		return
+	}
+
+	defer t.notifyChanged()
+
+	klog.V(2).Infof("Occupy best-effort %s with %d %d, mask %b", n.MinorName(), util, memory, n.Mask)
+	t.occupyNode(n)
+
+	n.BestEffortCores += util
+	n.AllocatableMeta.Memory -= memory
+	if n.AllocatableMeta.Memory < 0 {
+		n.AllocatableMeta.Memory = 0
+	}
+}
+
 func (t *NvidiaTree) occupyNode(n *NvidiaNode) {
 	for p := n.Parent; p != nil; p = p.Parent {
 		if p.Mask&n.Mask == n.Mask {
@@ -517,6 +663,24 @@ func (t *NvidiaTree) occupyNode(n *NvidiaNode) {
 	}
 }
 
+//Changed returns a channel which is notified after allocatable resource
+//of the tree is changed, notifications are coalesced if not received.
+func (t *NvidiaTree) Changed() <-chan struct{} {
+	return t.changed
+}
+
+func (t *NvidiaTree) notifyChanged() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+//MemoryBlockSize returns the size of vmemory block of tree
+func (t *NvidiaTree) MemoryBlockSize() int64 {
+	return t.memoryBlockSize
+}
+
 //Leaves returns leaves of tree
 func (t *NvidiaTree) Leaves() []*NvidiaNode {
 	return t.leaves
@@ -603,9 +767,11 @@ func printNode(node *NvidiaNode) string {
 			node.AllocatableMeta.Cores, node.AllocatableMeta.Memory)
 	}
 
-	return fmt.Sprintf("%s (pids: %+v, usedMemory: %d, totalMemory: %d, allocatableCores: %d, allocatableMemory: %d)\n",
+	return fmt.Sprintf("%s (pids: %+v, usedMemory: %d, totalMemory: %d, allocatableCores: %d, allocatableMemory: %d, "+
+		"logicalMemory: %d, committedMemory: %d, committedPhysicalMemory: %d)\n",
 		node.String(), node.Meta.Pids, node.Meta.UsedMemory, node.Meta.TotalMemory,
-		node.AllocatableMeta.Cores, node.AllocatableMeta.Memory)
+		node.AllocatableMeta.Cores, node.AllocatableMeta.Memory,
+		node.LogicalMemory(), node.CommittedMemory(), node.CommittedPhysicalMemory())
 }
 
 func resetGPUFeature(node *NvidiaNode, realMode bool) error {
@@ -19,8 +19,10 @@ package nvidia
 
 import (
 	"flag"
+	"strings"
 	"testing"
 
+	"tkestack.io/gpu-manager/pkg/config"
 	"tkestack.io/gpu-manager/pkg/types"
 )
 
@@ -100,3 +102,50 @@ func testTree(t *testing.T, testCase string, nodeNum int) {
 		t.Fatalf("method Query get wrong node")
 	}
 }
+
+func TestMemoryOversubscription(t *testing.T) {
+	flag.Parse()
+	tree := newNvidiaTree(&config.Config{
+		MemoryOversubscriptionRatio:      1.5,
+		CardMemoryOversubscriptionRatios: map[string]float64{"/dev/nvidia1": 1},
+	})
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+
+	physical := int64(4 * types.MemoryBlockSize)
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = uint64(physical)
+		n.MemoryRatio = tree.memoryRatioOf(n.MinorName())
+		n.AllocatableMeta.Cores = HundredCore
+		n.AllocatableMeta.Memory = n.LogicalMemory()
+	}
+
+	card0, card1 := tree.Query("/dev/nvidia0"), tree.Query("/dev/nvidia1")
+	if card0.LogicalMemory() != 6*types.MemoryBlockSize || !card0.MemoryOversubscribed() {
+		t.Fatalf("card0 should be oversubscribed, got logical memory %d", card0.LogicalMemory())
+	}
+
+	if card1.LogicalMemory() != physical || card1.MemoryOversubscribed() {
+		t.Fatalf("ratio of card1 should override the ratio of node, got logical memory %d", card1.LogicalMemory())
+	}
+
+	tree.MarkOccupied(card0, 50, 3*types.MemoryBlockSize)
+	if card0.CommittedMemory() != 3*types.MemoryBlockSize || card0.CommittedPhysicalMemory() != 3*types.MemoryBlockSize {
+		t.Fatalf("committed memory is wrong, logical %d, physical %d", card0.CommittedMemory(), card0.CommittedPhysicalMemory())
+	}
+
+	tree.MarkOccupied(card0, 50, 3*types.MemoryBlockSize)
+	if card0.AllocatableMeta.Memory != 0 || card0.CommittedMemory() != 6*types.MemoryBlockSize ||
+		card0.CommittedPhysicalMemory() != physical {
+		t.Fatalf("committed memory is wrong, logical %d, physical %d", card0.CommittedMemory(), card0.CommittedPhysicalMemory())
+	}
+
+	tree.MarkFree(card0, 50, 3*types.MemoryBlockSize)
+	tree.MarkFree(card0, 50, 3*types.MemoryBlockSize)
+	if card0.AllocatableMeta.Memory != card0.LogicalMemory() || tree.Available() != 2 {
+		t.Fatalf("card0 should be free, got %+v", card0.AllocatableMeta)
+	}
+
+	if !strings.Contains(tree.PrintGraph(), "logicalMemory: 1610612736") {
+		t.Fatalf("graph should show logical memory, got %s", tree.PrintGraph())
+	}
+}
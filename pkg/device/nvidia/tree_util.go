@@ -31,8 +31,10 @@ func parseToGpuTopologyLevel(str string) nvml.GpuTopologyLevel {
 		return nvml.TOPOLOGY_MULTIPLE
 	case "PHB":
 		return nvml.TOPOLOGY_HOSTBRIDGE
-	case "SOC":
+	case "SOC", "CPU", "NODE":
 		return nvml.TOPOLOGY_CPU
+	case "SYS":
+		return nvml.TOPOLOGY_SYSTEM
 	}
 
 	if strings.HasPrefix(str, "GPU") {
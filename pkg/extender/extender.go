@@ -0,0 +1,323 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package extender
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+	"tkestack.io/gpu-manager/pkg/utils"
+)
+
+const (
+	nodeNameIndex = "nodeName"
+	//assumeTimeout is the time to wait for informer to see a bound pod
+	assumeTimeout = time.Minute
+)
+
+type assumedPod struct {
+	pod      *v1.Pod
+	deadline time.Time
+}
+
+//Extender implements filter, prioritize and bind of scheduler extender,
+//it rebuilds GPU tree of each node from node annotation and pods on it.
+type Extender struct {
+	sync.Mutex
+
+	client      kubernetes.Interface
+	podIndexer  cache.Indexer
+	nodeLister  corelisters.NodeLister
+	enableShare bool
+	//oversubscriptionRatio should be the same as gpu-manager
+	oversubscriptionRatio float64
+
+	assumedLock sync.Mutex
+	assumed     map[k8stypes.UID]*assumedPod
+}
+
+//NewExtender returns a new Extender, informers should be started by caller
+func NewExtender(client kubernetes.Interface, factory informers.SharedInformerFactory, enableShare bool,
+	oversubscriptionRatio float64) (*Extender, error) {
+	podInformer := factory.Core().V1().Pods().Informer()
+	err := podInformer.AddIndexers(cache.Indexers{
+		nodeNameIndex: func(obj interface{}) ([]string, error) {
+			pod, ok := obj.(*v1.Pod)
+			if !ok || len(pod.Spec.NodeName) == 0 {
+				return nil, nil
+			}
+
+			return []string{pod.Spec.NodeName}, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Extender{
+		client:                client,
+		podIndexer:            podInformer.GetIndexer(),
+		nodeLister:            factory.Core().V1().Nodes().Lister(),
+		enableShare:           enableShare,
+		oversubscriptionRatio: oversubscriptionRatio,
+		assumed:               make(map[k8stypes.UID]*assumedPod),
+	}, nil
+}
+
+//Filter removes nodes which can't hold the GPU request of pod
+func (e *Extender) Filter(args *ExtenderArgs) *ExtenderFilterResult {
+	result := &ExtenderFilterResult{
+		FailedNodes: make(FailedNodesMap),
+	}
+
+	if args.Pod == nil {
+		result.Error = "pod is empty"
+		return result
+	}
+
+	nodes, err := e.getNodes(args)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var (
+		filtered  []v1.Node
+		nodeNames []string
+	)
+
+	for _, node := range nodes {
+		if !utils.IsGPURequiredPod(args.Pod) {
+			filtered = append(filtered, *node)
+			nodeNames = append(nodeNames, node.Name)
+			continue
+		}
+
+		if _, err := e.evaluate(node, args.Pod); err != nil {
+			klog.V(4).Infof("Filter out node %s for pod %s/%s, %v", node.Name, args.Pod.Namespace, args.Pod.Name, err)
+			result.FailedNodes[node.Name] = err.Error()
+			continue
+		}
+
+		filtered = append(filtered, *node)
+		nodeNames = append(nodeNames, node.Name)
+	}
+
+	if args.NodeNames != nil {
+		result.NodeNames = &nodeNames
+	} else {
+		result.Nodes = &v1.NodeList{Items: filtered}
+	}
+
+	return result
+}
+
+//Prioritize scores nodes, the node has less free cores after placing the
+//pod gets higher score
+func (e *Extender) Prioritize(args *ExtenderArgs) (HostPriorityList, error) {
+	if args.Pod == nil {
+		return nil, fmt.Errorf("pod is empty")
+	}
+
+	nodes, err := e.getNodes(args)
+	if err != nil {
+		return nil, err
+	}
+
+	priorities := make(HostPriorityList, 0, len(nodes))
+	for _, node := range nodes {
+		priority := HostPriority{Host: node.Name}
+
+		if utils.IsGPURequiredPod(args.Pod) {
+			if tree, err := e.evaluate(node, args.Pod); err == nil {
+				priority.Score = score(tree)
+			}
+		}
+
+		priorities = append(priorities, priority)
+	}
+
+	return priorities, nil
+}
+
+//Bind writes down the placement to pod annotations, then binds the pod
+//to node. Bindings are serialized to avoid over committing devices.
+func (e *Extender) Bind(args *ExtenderBindingArgs) *ExtenderBindingResult {
+	e.Lock()
+	defer e.Unlock()
+
+	if err := e.bind(args); err != nil {
+		klog.Errorf("Failed to bind pod %s/%s to %s, %v", args.PodNamespace, args.PodName, args.Node, err)
+		return &ExtenderBindingResult{Error: err.Error()}
+	}
+
+	return &ExtenderBindingResult{}
+}
+
+func (e *Extender) bind(args *ExtenderBindingArgs) error {
+	pod, err := e.client.CoreV1().Pods(args.PodNamespace).Get(args.PodName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if pod.UID != args.PodUID {
+		return fmt.Errorf("pod uid mismatch, expect %s, got %s", args.PodUID, pod.UID)
+	}
+
+	if utils.IsGPURequiredPod(pod) {
+		node, err := e.client.CoreV1().Nodes().Get(args.Node, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		tree, err := buildTree(node, e.podsOnNode(node.Name), e.oversubscriptionRatio)
+		if err != nil {
+			return err
+		}
+
+		result, err := place(tree, pod, e.enableShare, e.oversubscriptionRatio)
+		if err != nil {
+			return err
+		}
+
+		pod = pod.DeepCopy()
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+
+		for k, v := range predicateAnnotations(result) {
+			pod.Annotations[k] = v
+		}
+		pod.Annotations[types.PredicateTimeAnnotation] = strconv.FormatInt(time.Now().UnixNano(), 10)
+		pod.Annotations[types.GPUAssigned] = "false"
+
+		pod, err = e.client.CoreV1().Pods(pod.Namespace).Update(pod)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = e.client.CoreV1().Pods(pod.Namespace).Bind(&v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+		Target:     v1.ObjectReference{Kind: "Node", Name: args.Node},
+	})
+	if err != nil {
+		return err
+	}
+
+	assumed := pod.DeepCopy()
+	assumed.Spec.NodeName = args.Node
+
+	e.assumedLock.Lock()
+	e.assumed[pod.UID] = &assumedPod{pod: assumed, deadline: time.Now().Add(assumeTimeout)}
+	e.assumedLock.Unlock()
+	klog.V(2).Infof("Bind pod %s/%s to %s", pod.Namespace, pod.Name, args.Node)
+
+	return nil
+}
+
+func (e *Extender) evaluate(node *v1.Node, pod *v1.Pod) (*nvtree.NvidiaTree, error) {
+	tree, err := buildTree(node, e.podsOnNode(node.Name), e.oversubscriptionRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := place(tree, pod, e.enableShare, e.oversubscriptionRatio); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+func (e *Extender) getNodes(args *ExtenderArgs) ([]*v1.Node, error) {
+	var nodes []*v1.Node
+
+	if args.NodeNames != nil {
+		for _, name := range *args.NodeNames {
+			node, err := e.nodeLister.Get(name)
+			if err != nil {
+				return nil, fmt.Errorf("can't get node %s, %v", name, err)
+			}
+
+			nodes = append(nodes, node)
+		}
+
+		return nodes, nil
+	}
+
+	if args.Nodes == nil {
+		return nil, fmt.Errorf("nodes are empty")
+	}
+
+	for i := range args.Nodes.Items {
+		nodes = append(nodes, &args.Nodes.Items[i])
+	}
+
+	return nodes, nil
+}
+
+//podsOnNode returns pods which may hold GPU devices on the node, including
+//pods bound by this extender but not seen by informer yet
+func (e *Extender) podsOnNode(nodeName string) []*v1.Pod {
+	objs, err := e.podIndexer.ByIndex(nodeNameIndex, nodeName)
+	if err != nil {
+		klog.Warningf("Can't list pods on node %s, %v", nodeName, err)
+	}
+
+	var pods []*v1.Pod
+	seen := make(map[k8stypes.UID]bool)
+	for _, obj := range objs {
+		pod, ok := obj.(*v1.Pod)
+		if !ok || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		seen[pod.UID] = true
+		pods = append(pods, pod)
+	}
+
+	e.assumedLock.Lock()
+	defer e.assumedLock.Unlock()
+
+	now := time.Now()
+	for uid, ap := range e.assumed {
+		if seen[uid] || now.After(ap.deadline) {
+			delete(e.assumed, uid)
+			continue
+		}
+
+		if ap.pod.Spec.NodeName == nodeName {
+			pods = append(pods, ap.pod)
+		}
+	}
+
+	return pods
+}
@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package extender
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func init() {
+	flag.Set("v", "4")
+	flag.Set("logtostderr", "true")
+}
+
+func newTestNode(t *testing.T, name string) *v1.Node {
+	testCase :=
+		`    GPU0    GPU1    GPU2    GPU3
+GPU0      X      PIX     PHB     PHB
+GPU1     PIX      X      PHB     PHB
+GPU2     PHB     PHB      X      PIX
+GPU3     PHB     PHB     PIX      X
+`
+	tree, _ := nvtree.NewNvidiaTree(nil).(*nvtree.NvidiaTree)
+	tree.Init(testCase)
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = 4 * types.MemoryBlockSize
+	}
+
+	data, err := json.Marshal(tree.Topology())
+	if err != nil {
+		t.Fatalf("can't marshal topology: %v", err)
+	}
+
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{types.GPUTopologyAnnotation: string(data)},
+		},
+	}
+}
+
+func newTestPod(name, nodeName string, cores, memory int, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test-ns",
+			UID:         k8stypes.UID(name),
+			Annotations: annotations,
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Containers: []v1.Container{
+				{
+					Name: "container-0",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							types.VCoreAnnotation:   resource.MustParse(fmt.Sprintf("%d", cores)),
+							types.VMemoryAnnotation: resource.MustParse(fmt.Sprintf("%d", memory)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestExtender(t *testing.T) {
+	flag.Parse()
+
+	node1, node2 := newTestNode(t, "node1"), newTestNode(t, "node2")
+	usedPod := newTestPod("used", "node1", 100, 4, map[string]string{types.PredicateGPUIndexPrefix + "0": "0"})
+	sharePod := newTestPod("share", "", 50, 1, nil)
+	largePod := newTestPod("large", "", 300, 12, nil)
+
+	client := fake.NewSimpleClientset(node1, node2, usedPod, sharePod)
+	bindings := make(map[string]string)
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+
+		binding := action.(k8stesting.CreateAction).GetObject().(*v1.Binding)
+		bindings[binding.Name] = binding.Target.Name
+		return true, binding, nil
+	})
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	ext, err := NewExtender(client, factory, true, 0)
+	if err != nil {
+		t.Fatalf("can't create extender: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	// filter with node names
+	nodeNames := []string{"node1", "node2"}
+	result := ext.Filter(&ExtenderArgs{Pod: largePod, NodeNames: &nodeNames})
+	if result.Error != "" || result.NodeNames == nil || len(*result.NodeNames) != 2 {
+		t.Fatalf("both nodes should pass, got %+v", result)
+	}
+
+	// filter with nodes, the 4th card of node1 is occupied
+	fullPod := newTestPod("full", "", 400, 16, nil)
+	result = ext.Filter(&ExtenderArgs{Pod: fullPod, Nodes: &v1.NodeList{Items: []v1.Node{*node1, *node2}}})
+	if result.Nodes == nil || len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "node2" {
+		t.Fatalf("only node2 should pass, got %+v", result)
+	}
+
+	if _, ok := result.FailedNodes["node1"]; !ok {
+		t.Fatalf("node1 should be filtered out, got %+v", result.FailedNodes)
+	}
+
+	// node1 has less free cores, so it's preferred
+	priorities, err := ext.Prioritize(&ExtenderArgs{Pod: sharePod, NodeNames: &nodeNames})
+	if err != nil {
+		t.Fatalf("can't prioritize: %v", err)
+	}
+
+	if len(priorities) != 2 || priorities[0].Score <= priorities[1].Score {
+		t.Fatalf("node1 should get higher score, got %+v", priorities)
+	}
+
+	// bind writes down predicate annotations
+	bindResult := ext.Bind(&ExtenderBindingArgs{PodName: "share", PodNamespace: "test-ns", PodUID: "share", Node: "node1"})
+	if bindResult.Error != "" || bindings["share"] != "node1" {
+		t.Fatalf("can't bind pod, %+v", bindResult)
+	}
+
+	bound, _ := client.CoreV1().Pods("test-ns").Get("share", metav1.GetOptions{})
+	if idx := bound.Annotations[types.PredicateGPUIndexPrefix+"0"]; idx == "" || idx == "0" {
+		t.Fatalf("invalid predicate index %q", idx)
+	}
+
+	if bound.Annotations[types.PredicateTimeAnnotation] == "" || bound.Annotations[types.GPUAssigned] != "false" {
+		t.Fatalf("predicate annotations are not written, %+v", bound.Annotations)
+	}
+
+	// the bound pod is taken into account before informer sees it
+	result = ext.Filter(&ExtenderArgs{Pod: largePod, NodeNames: &[]string{"node1"}})
+	if len(*result.NodeNames) != 0 {
+		t.Fatalf("node1 should be filtered out after binding, got %+v", result)
+	}
+}
+
+func TestMemoryBlockSize(t *testing.T) {
+	flag.Parse()
+
+	// every card has 1GiB memory, it's one block of the node
+	node := newTestNode(t, "node1")
+	node.Annotations[types.VMemoryBlockAnnotation] = fmt.Sprintf("%d", 4*types.MemoryBlockSize)
+
+	tree, err := buildTree(node, nil, 0)
+	if err != nil {
+		t.Fatalf("can't build tree: %v", err)
+	}
+
+	if _, err := place(tree, newTestPod("fit", "", 50, 1, nil), true, 0); err != nil {
+		t.Fatalf("one block should fit a card, %v", err)
+	}
+
+	if _, err := place(tree, newTestPod("large", "", 50, 2, nil), true, 0); err == nil {
+		t.Fatalf("two blocks should not fit a card")
+	}
+
+	// pod allocated with 256MiB blocks keeps its memory after the block
+	// size of node is changed
+	allocated := newTestPod("allocated", "node1", 50, 2, map[string]string{
+		types.PredicateGPUIndexPrefix + "0": "0",
+		types.VMemoryAllocatedPrefix + "0":  fmt.Sprintf("%d", 2*types.MemoryBlockSize),
+	})
+	tree, err = buildTree(node, []*v1.Pod{allocated}, 0)
+	if err != nil {
+		t.Fatalf("can't build tree: %v", err)
+	}
+
+	if memory := tree.Leaves()[0].AllocatableMeta.Memory; memory != 2*types.MemoryBlockSize {
+		t.Fatalf("expect %d memory left on card 0, got %d", 2*types.MemoryBlockSize, memory)
+	}
+
+	node.Annotations[types.VMemoryBlockAnnotation] = "invalid"
+	if _, err := buildTree(node, nil, 0); err == nil {
+		t.Fatalf("invalid memory block size should be rejected")
+	}
+}
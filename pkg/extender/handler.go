@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog"
+)
+
+const (
+	//FilterPath is the url path of filter verb
+	FilterPath = "/filter"
+	//PrioritizePath is the url path of prioritize verb
+	PrioritizePath = "/prioritize"
+	//BindPath is the url path of bind verb
+	BindPath = "/bind"
+)
+
+//Handler returns a http handler serves the extender verbs
+func (e *Extender) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(FilterPath, func(w http.ResponseWriter, r *http.Request) {
+		args := &ExtenderArgs{}
+		if !decode(w, r, args) {
+			return
+		}
+
+		encode(w, e.Filter(args))
+	})
+
+	mux.HandleFunc(PrioritizePath, func(w http.ResponseWriter, r *http.Request) {
+		args := &ExtenderArgs{}
+		if !decode(w, r, args) {
+			return
+		}
+
+		priorities, err := e.Prioritize(args)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		encode(w, priorities)
+	})
+
+	mux.HandleFunc(BindPath, func(w http.ResponseWriter, r *http.Request) {
+		args := &ExtenderBindingArgs{}
+		if !decode(w, r, args) {
+			return
+		}
+
+		encode(w, e.Bind(args))
+	})
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	return mux
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func encode(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("Failed to encode response, %v", err)
+	}
+}
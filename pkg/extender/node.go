@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	nveval "tkestack.io/gpu-manager/pkg/algorithm/nvidia"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+	"tkestack.io/gpu-manager/pkg/utils"
+)
+
+type evaluator interface {
+	Evaluate(cores int64, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode
+}
+
+//buildTree reconstructs the GPU tree of node from its topology annotation,
+//then marks devices used by pods on the node as occupied.
+func buildTree(node *v1.Node, pods []*v1.Pod, ratio float64) (*nvtree.NvidiaTree, error) {
+	data, ok := node.Annotations[types.GPUTopologyAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("no gpu topology found")
+	}
+
+	topo := &nvtree.Topology{}
+	if err := json.Unmarshal([]byte(data), topo); err != nil {
+		return nil, fmt.Errorf("invalid gpu topology, %v", err)
+	}
+
+	blockSize, err := memoryBlockSize(node)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := nvtree.NewNvidiaTreeFromTopology(topo, blockSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gpu topology, %v", err)
+	}
+
+	for _, pod := range pods {
+		occupy(tree, pod, ratio)
+	}
+
+	return tree, nil
+}
+
+func occupy(tree *nvtree.NvidiaTree, pod *v1.Pod, ratio float64) {
+	qos, err := utils.GetQoSClassOfPod(pod)
+	if err != nil {
+		klog.Warningf("Can't get qos class of pod %s/%s, %v", pod.Namespace, pod.Name, err)
+		qos = types.QoSBurstable
+	}
+
+	for i, c := range pod.Spec.Containers {
+		if !utils.IsGPURequiredContainer(&c) {
+			continue
+		}
+
+		idxStr, ok := pod.Annotations[types.PredicateGPUIndexPrefix+strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		// Memory of allocated pod doesn't change with block size of node
+		cores := int64(utils.GetGPUResourceOfContainer(&c, types.VCoreAnnotation))
+		memory := utils.GetGPUMemoryOfContainer(pod, i, tree.MemoryBlockSize())
+		mode, _ := nveval.SelectModeWithQoS(cores, memory, true, qos, ratio)
+		for _, idx := range strings.Split(idxStr, ",") {
+			n := tree.Query(types.NvidiaDevicePrefix + idx)
+			if n == nil {
+				klog.Warningf("Can't find device %s of pod %s/%s", idx, pod.Namespace, pod.Name)
+				continue
+			}
+
+			mark(tree, n, mode, qos, cores, memory)
+		}
+	}
+}
+
+func mark(tree *nvtree.NvidiaTree, n *nvtree.NvidiaNode, mode string, qos types.QoSClass, cores, memory int64) {
+	switch {
+	case mode == nveval.BestEffortMode:
+		tree.MarkOccupiedBestEffort(n, cores, memory)
+	case qos == types.QoSGuaranteed:
+		tree.MarkOccupiedGuaranteed(n, cores, memory)
+	default:
+		tree.MarkOccupied(n, cores, memory)
+	}
+}
+
+//memoryBlockSize returns the size of vmemory block advertised by node,
+//nodes without the annotation use the default size
+func memoryBlockSize(node *v1.Node) (int64, error) {
+	data, ok := node.Annotations[types.VMemoryBlockAnnotation]
+	if !ok {
+		return types.MemoryBlockSize, nil
+	}
+
+	size, err := strconv.ParseInt(data, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid memory block size %q", data)
+	}
+
+	return size, nil
+}
+
+func containerRequest(c *v1.Container, blockSize int64) (cores int64, memory int64) {
+	cores = int64(utils.GetGPUResourceOfContainer(c, types.VCoreAnnotation))
+	memory = int64(utils.GetGPUResourceOfContainer(c, types.VMemoryAnnotation)) * blockSize
+
+	return cores, memory
+}
+
+//place evaluates every GPU container of pod in order with the same
+//evaluators used by allocator, the result is keyed by container index.
+func place(tree *nvtree.NvidiaTree, pod *v1.Pod, enableShare bool, ratio float64) (map[int][]*nvtree.NvidiaNode, error) {
+	evaluators := map[string]evaluator{
+		nveval.LinkMode:       nveval.NewLinkMode(tree),
+		nveval.FragmentMode:   nveval.NewFragmentMode(tree),
+		nveval.ShareMode:      nveval.NewShareMode(tree),
+		nveval.BestEffortMode: nveval.NewBestEffortMode(tree, ratio),
+	}
+
+	qos, err := utils.GetQoSClassOfPod(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := nvtree.ModelFilter(utils.GetGPUModelSelectorsOfPod(pod))
+	result := make(map[int][]*nvtree.NvidiaNode)
+	for i, c := range pod.Spec.Containers {
+		if !utils.IsGPURequiredContainer(&c) {
+			continue
+		}
+
+		cores, memory := containerRequest(&c, tree.MemoryBlockSize())
+		mode, err := nveval.SelectModeWithQoS(cores, memory, enableShare, qos, ratio)
+		if err != nil {
+			return nil, err
+		}
+
+		var nodes []*nvtree.NvidiaNode
+		if mode == nveval.ShareMode || mode == nveval.BestEffortMode {
+			nodes = evaluators[mode].Evaluate(cores, memory, filter)
+		} else {
+			nodes = evaluators[mode].Evaluate(cores, 0, filter)
+		}
+
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("no free gpu for container %s", c.Name)
+		}
+
+		for _, n := range nodes {
+			mark(tree, n, mode, qos, cores, memory)
+		}
+
+		result[i] = nodes
+	}
+
+	return result, nil
+}
+
+//score gives higher score to the node which has more used cores, so
+//fragments are filled first
+func score(tree *nvtree.NvidiaTree) int64 {
+	var used, total int64
+
+	for _, n := range tree.Leaves() {
+		total += nvtree.HundredCore
+		used += nvtree.HundredCore - n.AllocatableMeta.Cores
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return used * MaxPriority / total
+}
+
+func predicateAnnotations(result map[int][]*nvtree.NvidiaNode) map[string]string {
+	annotations := make(map[string]string)
+
+	for i, nodes := range result {
+		minors := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			minors = append(minors, strconv.Itoa(n.Meta.MinorID))
+		}
+
+		annotations[types.PredicateGPUIndexPrefix+strconv.Itoa(i)] = strings.Join(minors, ",")
+	}
+
+	return annotations
+}
@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package extender
+
+import (
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// These types follow the scheduler extender protocol of kube-scheduler,
+// see k8s.io/kube-scheduler/extender/v1.
+
+//MaxPriority is the maximum score returned by prioritize
+const MaxPriority = 10
+
+//ExtenderArgs represents the arguments needed by the extender to filter
+//and prioritize nodes for a pod
+type ExtenderArgs struct {
+	Pod *v1.Pod `json:"pod"`
+	//Nodes is used if the extender is not node cache capable
+	Nodes *v1.NodeList `json:"nodes,omitempty"`
+	//NodeNames is used if the extender is node cache capable
+	NodeNames *[]string `json:"nodenames,omitempty"`
+}
+
+//FailedNodesMap represents the filtered out nodes, with node names and
+//failure messages
+type FailedNodesMap map[string]string
+
+//ExtenderFilterResult represents the results of a filter call
+type ExtenderFilterResult struct {
+	Nodes       *v1.NodeList   `json:"nodes,omitempty"`
+	NodeNames   *[]string      `json:"nodenames,omitempty"`
+	FailedNodes FailedNodesMap `json:"failedNodes,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+//HostPriority represents the priority of scheduling to a particular host
+type HostPriority struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+//HostPriorityList declares a []HostPriority type
+type HostPriorityList []HostPriority
+
+//ExtenderBindingArgs represents the arguments to an extender for binding
+//a pod to a node
+type ExtenderBindingArgs struct {
+	PodName      string    `json:"podName"`
+	PodNamespace string    `json:"podNamespace"`
+	PodUID       types.UID `json:"podUID"`
+	Node         string    `json:"node"`
+}
+
+//ExtenderBindingResult represents the result of binding of a pod to a
+//node from an extender
+type ExtenderBindingResult struct {
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,179 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/klog"
+)
+
+//Capability describes how a container runtime names its containers
+type Capability struct {
+	//Name is the runtime name reported by CRI Version
+	Name string
+	//Endpoints are the default CRI sockets of runtime
+	Endpoints []string
+	//SystemdScopePrefix is the prefix of container scope with systemd
+	//cgroup driver, e.g. cri-containerd-<id>.scope
+	SystemdScopePrefix string
+	//ContainerIDPrefix is the prefix of container ID in pod status
+	ContainerIDPrefix string
+}
+
+//Capabilities of known container runtimes, endpoints are detected in order
+var Capabilities = []Capability{
+	{
+		Name:               "containerd",
+		Endpoints:          []string{"/var/run/containerd/containerd.sock"},
+		SystemdScopePrefix: "cri-containerd",
+		ContainerIDPrefix:  "containerd://",
+	},
+	{
+		Name:               "cri-o",
+		Endpoints:          []string{"/var/run/crio/crio.sock"},
+		SystemdScopePrefix: "crio",
+		ContainerIDPrefix:  "cri-o://",
+	},
+	{
+		Name:               "docker",
+		Endpoints:          []string{"/var/run/cri-dockerd.sock", "/var/run/dockershim.sock"},
+		SystemdScopePrefix: "docker",
+		ContainerIDPrefix:  "docker://",
+	},
+}
+
+//CRI API versions, messages of v1 are wire compatible with v1alpha2, only
+//the service name is different
+const (
+	CRIv1       = "v1"
+	CRIv1alpha2 = "v1alpha2"
+)
+
+//CapabilityOf returns the capability of runtime name, runtimes which are not
+//in the matrix are assumed to use their names as prefixes
+func CapabilityOf(name string) Capability {
+	for _, c := range Capabilities {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return Capability{
+		Name:               name,
+		SystemdScopePrefix: name,
+		ContainerIDPrefix:  name + "://",
+	}
+}
+
+//TrimContainerIDPrefix returns the container ID of pod status without its
+//runtime prefix
+func TrimContainerIDPrefix(containerID string) string {
+	for _, c := range Capabilities {
+		if strings.HasPrefix(containerID, c.ContainerIDPrefix) {
+			return strings.TrimPrefix(containerID, c.ContainerIDPrefix)
+		}
+	}
+
+	if i := strings.Index(containerID, "://"); i >= 0 {
+		return containerID[i+len("://"):]
+	}
+
+	return containerID
+}
+
+//DetectEndpoint returns the first existing socket of known runtimes
+func DetectEndpoint() (string, error) {
+	candidates := make([]string, 0)
+	for _, c := range Capabilities {
+		candidates = append(candidates, c.Endpoints...)
+	}
+
+	return detectEndpoint(candidates)
+}
+
+func detectEndpoint(candidates []string) (string, error) {
+	for _, endpoint := range candidates {
+		if info, err := os.Stat(endpoint); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return endpoint, nil
+		}
+	}
+
+	return "", fmt.Errorf("no container runtime socket found in %v", candidates)
+}
+
+//criClient is the part of CRI runtime service used by gpu-manager
+type criClient interface {
+	Version(ctx context.Context, in *criapi.VersionRequest, opts ...grpc.CallOption) (*criapi.VersionResponse, error)
+	ContainerStatus(ctx context.Context, in *criapi.ContainerStatusRequest, opts ...grpc.CallOption) (*criapi.ContainerStatusResponse, error)
+}
+
+//criV1Client calls runtime.v1.RuntimeService with v1alpha2 messages
+type criV1Client struct {
+	cc *grpc.ClientConn
+}
+
+var _ criClient = &criV1Client{}
+
+func (c *criV1Client) Version(ctx context.Context, in *criapi.VersionRequest, opts ...grpc.CallOption) (*criapi.VersionResponse, error) {
+	out := new(criapi.VersionResponse)
+	if err := c.cc.Invoke(ctx, "/runtime.v1.RuntimeService/Version", in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *criV1Client) ContainerStatus(ctx context.Context, in *criapi.ContainerStatusRequest, opts ...grpc.CallOption) (*criapi.ContainerStatusResponse, error) {
+	out := new(criapi.ContainerStatusResponse)
+	if err := c.cc.Invoke(ctx, "/runtime.v1.RuntimeService/ContainerStatus", in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+//negotiateCRI prefers CRI v1 and falls back to v1alpha2 if runtime doesn't
+//implement it
+func negotiateCRI(ctx context.Context, conn *grpc.ClientConn) (criClient, string, *criapi.VersionResponse, error) {
+	req := &criapi.VersionRequest{Version: "0.1.0"}
+
+	v1Client := &criV1Client{cc: conn}
+	resp, err := v1Client.Version(ctx, req)
+	if err == nil {
+		return v1Client, CRIv1, resp, nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, "", nil, err
+	}
+
+	klog.V(2).Infof("CRI %s is not implemented, fall back to %s", CRIv1, CRIv1alpha2)
+	v1alpha2Client := criapi.NewRuntimeServiceClient(conn)
+	resp, err = v1alpha2Client.Version(ctx, req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return v1alpha2Client, CRIv1alpha2, resp, nil
+}
@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package runtime
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"tkestack.io/gpu-manager/pkg/utils"
+)
+
+func TestCapabilityOf(t *testing.T) {
+	if c := CapabilityOf("containerd"); c.SystemdScopePrefix != "cri-containerd" {
+		t.Fatalf("unexpected prefix %s of containerd", c.SystemdScopePrefix)
+	}
+
+	if c := CapabilityOf("kata"); c.SystemdScopePrefix != "kata" || c.ContainerIDPrefix != "kata://" {
+		t.Fatalf("unexpected capability %+v of unknown runtime", c)
+	}
+
+	for id, expected := range map[string]string{
+		"containerd://abc": "abc",
+		"cri-o://abc":      "abc",
+		"docker://abc":     "abc",
+		"kata://abc":       "abc",
+		"abc":              "abc",
+	} {
+		if trimmed := TrimContainerIDPrefix(id); trimmed != expected {
+			t.Fatalf("expect %s, got %s", expected, trimmed)
+		}
+	}
+}
+
+func TestDetectEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cri")
+	if err != nil {
+		t.Fatalf("can't create dir, %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	containerd := filepath.Join(dir, "containerd.sock")
+	crio := filepath.Join(dir, "crio.sock")
+	dockerd := filepath.Join(dir, "cri-dockerd.sock")
+
+	// a regular file is not a socket
+	ioutil.WriteFile(containerd, nil, 0644)
+	l, err := net.Listen("unix", crio)
+	if err != nil {
+		t.Fatalf("can't listen %s, %v", crio, err)
+	}
+	defer l.Close()
+
+	endpoint, err := detectEndpoint([]string{containerd, crio, dockerd})
+	if err != nil || endpoint != crio {
+		t.Fatalf("expect %s, got %s, %v", crio, endpoint, err)
+	}
+
+	if _, err := detectEndpoint([]string{containerd, dockerd}); err == nil {
+		t.Fatalf("expect error without socket")
+	}
+}
+
+type fakeV1alpha2Server struct {
+	criapi.RuntimeServiceServer
+}
+
+func (s *fakeV1alpha2Server) Version(context.Context, *criapi.VersionRequest) (*criapi.VersionResponse, error) {
+	return &criapi.VersionResponse{RuntimeName: "docker", RuntimeApiVersion: CRIv1alpha2}, nil
+}
+
+//registerV1Server serves Version of runtime.v1.RuntimeService
+func registerV1Server(srv *grpc.Server) {
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "runtime.v1.RuntimeService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Version",
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(criapi.VersionRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return &criapi.VersionResponse{RuntimeName: "containerd", RuntimeApiVersion: CRIv1}, nil
+			},
+		}},
+	}, struct{}{})
+}
+
+func TestNegotiateCRI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cri")
+	if err != nil {
+		t.Fatalf("can't create dir, %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name        string
+		register    func(*grpc.Server)
+		apiVersion  string
+		runtimeName string
+	}{
+		{"v1", registerV1Server, CRIv1, "containerd"},
+		{"v1alpha2", func(srv *grpc.Server) {
+			criapi.RegisterRuntimeServiceServer(srv, &fakeV1alpha2Server{})
+		}, CRIv1alpha2, "docker"},
+	}
+
+	for _, tc := range testCases {
+		socket := filepath.Join(dir, tc.name+".sock")
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Fatalf("can't listen %s, %v", socket, err)
+		}
+
+		srv := grpc.NewServer()
+		tc.register(srv)
+		go srv.Serve(l)
+
+		conn, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithDialer(utils.UnixDial), grpc.WithBlock(), grpc.WithTimeout(time.Second*5))
+		if err != nil {
+			t.Fatalf("can't dial %s, %v", socket, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, apiVersion, resp, err := negotiateCRI(ctx, conn)
+		cancel()
+		conn.Close()
+		srv.Stop()
+
+		if err != nil || apiVersion != tc.apiVersion || resp.RuntimeName != tc.runtimeName {
+			t.Fatalf("%s: unexpected CRI %s of %v, %v", tc.name, apiVersion, resp, err)
+		}
+	}
+}
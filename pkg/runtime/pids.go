@@ -0,0 +1,328 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/klog"
+
+	"tkestack.io/gpu-manager/pkg/types"
+	"tkestack.io/gpu-manager/pkg/utils/cgroup"
+)
+
+//pidsStrategy is a way to find pids of a container, status is nil if the
+//runtime can't report status of container. The cgroup path is returned if
+//it's found, so later lookups read it directly.
+type pidsStrategy struct {
+	name    string
+	resolve func(containerID string, status *criapi.ContainerStatusResponse) ([]int, string, error)
+}
+
+//procScan is the result of scanning processes for a container whose cgroup
+//isn't found
+type procScan struct {
+	pids []int
+	time time.Time
+}
+
+//procScanInterval is how long pids found by scanning processes are reused,
+//processes are scanned again after it to find new ones
+const procScanInterval = 30 * time.Second
+
+//verboseInfo is the part of verbose container status reported by
+//containerd and cri-o
+type verboseInfo struct {
+	Pid         int `json:"pid"`
+	RuntimeSpec struct {
+		Linux struct {
+			CgroupsPath string `json:"cgroupsPath"`
+		} `json:"linux"`
+	} `json:"runtimeSpec"`
+}
+
+//GetPidsInContainers returns pids in container. The cgroup path resolved
+//before is used if it still exists, otherwise it tries the cgroup reported
+//by container runtime first, then scans processes in /proc, and falls back
+//to the cgroup path computed from QoS class of pod.
+func (m *containerRuntimeManager) GetPidsInContainers(containerID string) ([]int, error) {
+	if cgroupPath, _ := m.cachedCgroup(containerID); len(cgroupPath) > 0 {
+		pids, err := m.pidsInCgroup(cgroupPath)
+		if err == nil {
+			return pids, nil
+		}
+
+		klog.V(4).Infof("cgroup %s of %s is gone, %v", cgroupPath, containerID, err)
+		m.forgetCgroup(containerID)
+	}
+
+	req := &criapi.ContainerStatusRequest{
+		ContainerId: containerID,
+		Verbose:     true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.requestTimeout)
+	defer cancel()
+
+	status, err := m.client.ContainerStatus(ctx, req)
+	if err != nil {
+		klog.Errorf("can't get container %s status, %v", containerID, err)
+		status = nil
+	}
+
+	strategies := []pidsStrategy{
+		{"runtime", m.pidsFromRuntime},
+		{"proc", m.pidsFromProc},
+		{"qos", m.pidsFromQoS},
+	}
+
+	var lastErr error
+	for _, strategy := range strategies {
+		pids, cgroupPath, err := strategy.resolve(containerID, status)
+		if err != nil {
+			klog.V(4).Infof("can't get pids of %s from %s, %v", containerID, strategy.name, err)
+			lastErr = err
+			continue
+		}
+
+		if len(cgroupPath) > 0 {
+			m.cacheCgroup(containerID, cgroupPath)
+		}
+
+		if len(pids) > 0 {
+			klog.V(4).Infof("Get pids of %s from %s, %v", containerID, strategy.name, pids)
+			return pids, nil
+		}
+		lastErr = nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return []int{}, nil
+}
+
+func (m *containerRuntimeManager) cachedCgroup(containerID string) (string, bool) {
+	m.cgroupLock.Lock()
+	defer m.cgroupLock.Unlock()
+
+	cgroupPath, ok := m.cgroupPaths[containerID]
+	return cgroupPath, ok
+}
+
+func (m *containerRuntimeManager) cacheCgroup(containerID, cgroupPath string) {
+	m.cgroupLock.Lock()
+	defer m.cgroupLock.Unlock()
+
+	if m.cgroupPaths == nil {
+		m.cgroupPaths = make(map[string]string)
+	}
+	m.cgroupPaths[containerID] = cgroupPath
+	delete(m.procScans, containerID)
+}
+
+//cachedScan returns the last scan of processes for container if it's done
+//within procScanInterval
+func (m *containerRuntimeManager) cachedScan(containerID string) (procScan, bool) {
+	m.cgroupLock.Lock()
+	defer m.cgroupLock.Unlock()
+
+	scan, ok := m.procScans[containerID]
+	if !ok || time.Since(scan.time) >= procScanInterval {
+		return procScan{}, false
+	}
+
+	return scan, true
+}
+
+//cacheScan remembers pids found by scanning processes, expired scans of
+//other containers are dropped
+func (m *containerRuntimeManager) cacheScan(containerID string, pids []int) {
+	m.cgroupLock.Lock()
+	defer m.cgroupLock.Unlock()
+
+	if m.procScans == nil {
+		m.procScans = make(map[string]procScan)
+	}
+	for id, scan := range m.procScans {
+		if time.Since(scan.time) >= procScanInterval {
+			delete(m.procScans, id)
+		}
+	}
+	m.procScans[containerID] = procScan{pids: pids, time: time.Now()}
+}
+
+func (m *containerRuntimeManager) forgetCgroup(containerID string) {
+	m.cgroupLock.Lock()
+	defer m.cgroupLock.Unlock()
+
+	delete(m.cgroupPaths, containerID)
+}
+
+//pidsFromRuntime uses the cgroup and pid in verbose container status
+func (m *containerRuntimeManager) pidsFromRuntime(containerID string, status *criapi.ContainerStatusResponse) ([]int, string, error) {
+	if status == nil {
+		return nil, "", fmt.Errorf("no container status")
+	}
+
+	data, ok := status.Info["info"]
+	if !ok {
+		return nil, "", fmt.Errorf("no verbose info")
+	}
+
+	info := &verboseInfo{}
+	if err := json.Unmarshal([]byte(data), info); err != nil {
+		return nil, "", fmt.Errorf("can't parse verbose info, %v", err)
+	}
+
+	cgroupPath := ""
+	if cgroupsPath := info.RuntimeSpec.Linux.CgroupsPath; len(cgroupsPath) > 0 {
+		p, err := cgroup.ConvertCgroupsPath(cgroupsPath)
+		if err != nil {
+			return nil, "", err
+		}
+		cgroupPath = p
+	} else if info.Pid > 0 {
+		data, err := ioutil.ReadFile(filepath.Join(m.procRoot, strconv.Itoa(info.Pid), "cgroup"))
+		if err != nil {
+			return nil, "", err
+		}
+
+		if cgroupPath, err = cgroup.ParseProcessCgroup(data, m.cgroupMode); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if len(cgroupPath) > 0 {
+		pids, err := m.pidsInCgroup(cgroupPath)
+		if err == nil {
+			return pids, cgroupPath, nil
+		}
+		if info.Pid <= 0 {
+			return nil, "", err
+		}
+	}
+
+	// Sandboxed runtimes may only report pid of the container
+	if info.Pid > 0 {
+		return []int{info.Pid}, "", nil
+	}
+
+	return nil, "", fmt.Errorf("neither pid nor cgroup is reported")
+}
+
+//pidsFromProc scans cgroup of every process for container ID, the cgroup
+//of found processes is returned so the scan isn't needed anymore. If no
+//cgroup is found, the found pids which are still alive are reused until
+//procScanInterval passes, then processes are scanned again.
+func (m *containerRuntimeManager) pidsFromProc(containerID string, _ *criapi.ContainerStatusResponse) ([]int, string, error) {
+	if len(containerID) == 0 {
+		return nil, "", fmt.Errorf("empty container id")
+	}
+
+	if scan, ok := m.cachedScan(containerID); ok {
+		pids := make([]int, 0, len(scan.pids))
+		for _, pid := range scan.pids {
+			if _, err := os.Stat(filepath.Join(m.procRoot, strconv.Itoa(pid))); err == nil {
+				pids = append(pids, pid)
+			}
+		}
+
+		return pids, "", nil
+	}
+
+	entries, err := ioutil.ReadDir(m.procRoot)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var cgroupPath string
+	pids := make([]int, 0)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		// processes may exit during scanning
+		data, err := ioutil.ReadFile(filepath.Join(m.procRoot, entry.Name(), "cgroup"))
+		if err != nil {
+			continue
+		}
+
+		if strings.Contains(string(data), containerID) {
+			pids = append(pids, pid)
+			if len(cgroupPath) == 0 {
+				cgroupPath, _ = cgroup.ParseProcessCgroup(data, m.cgroupMode)
+			}
+		}
+	}
+	sort.Ints(pids)
+
+	if len(cgroupPath) > 0 {
+		if _, err := m.pidsInCgroup(cgroupPath); err != nil {
+			klog.V(4).Infof("can't use cgroup %s of %s, %v", cgroupPath, containerID, err)
+			cgroupPath = ""
+		}
+	}
+
+	if len(cgroupPath) == 0 {
+		m.cacheScan(containerID, pids)
+	}
+
+	return pids, cgroupPath, nil
+}
+
+//pidsFromQoS uses the cgroup path computed from QoS class of pod
+func (m *containerRuntimeManager) pidsFromQoS(containerID string, status *criapi.ContainerStatusResponse) ([]int, string, error) {
+	if status == nil || status.Status == nil {
+		return nil, "", fmt.Errorf("no container status")
+	}
+
+	ns := status.Status.Labels[types.PodNamespaceLabelKey]
+	podName := status.Status.Labels[types.PodNameLabelKey]
+
+	pod, err := m.podLister.GetPod(ns, podName)
+	if err != nil {
+		klog.Errorf("can't get pod %s/%s, %v", ns, podName, err)
+		return nil, "", err
+	}
+
+	cgroupPath, err := m.getCgroupName(pod, containerID)
+	if err != nil {
+		klog.Errorf("can't get cgroup parent, %v", err)
+		return nil, "", err
+	}
+
+	pids, err := m.pidsInCgroup(cgroupPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pids, cgroupPath, nil
+}
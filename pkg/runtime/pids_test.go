@@ -0,0 +1,237 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
+	"tkestack.io/gpu-manager/pkg/types"
+	"tkestack.io/gpu-manager/pkg/utils/cgroup"
+)
+
+type fakeRuntimeService struct {
+	criapi.RuntimeServiceClient
+	status *criapi.ContainerStatusResponse
+}
+
+func (s *fakeRuntimeService) ContainerStatus(_ context.Context, req *criapi.ContainerStatusRequest, _ ...grpc.CallOption) (*criapi.ContainerStatusResponse, error) {
+	if s.status == nil {
+		return nil, fmt.Errorf("container %s not found", req.ContainerId)
+	}
+
+	return s.status, nil
+}
+
+//writeFixture creates files under root, directories are created as needed
+func writeFixture(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatalf("can't create dir of %s, %v", filename, err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("can't write %s, %v", filename, err)
+		}
+	}
+}
+
+func TestGetPidsInContainers(t *testing.T) {
+	containerID := "4c1f7a6b2e9d8c0a1b3e5f7a9c2d4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b5c7d"
+	systemdScope := "system.slice/cri-containerd-" + containerID + ".scope"
+	qosPath := "kubepods/besteffort/pod0d1e5a3f-2c4b-4e8a-9f10-1234567890ab/" + containerID
+
+	// a static pod with custom cgroup parent
+	podLister := watchdog.NewFakePodLister(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "testpod", Namespace: "test-ns", UID: k8stypes.UID("0d1e5a3f-2c4b-4e8a-9f10-1234567890ab")},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name: "test-container",
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					types.VCoreAnnotation:   resource.MustParse("1"),
+					types.VMemoryAnnotation: resource.MustParse("1"),
+				},
+			},
+		}}},
+		Status: v1.PodStatus{Phase: v1.PodRunning, QOSClass: v1.PodQOSBestEffort},
+	})
+
+	labels := map[string]string{
+		types.PodNamespaceLabelKey: "test-ns",
+		types.PodNameLabelKey:      "testpod",
+	}
+
+	testCases := []struct {
+		name   string
+		status *criapi.ContainerStatusResponse
+		cgroup map[string]string
+		proc   map[string]string
+		pids   []int
+	}{
+		{
+			name: "cgroups path of runtime",
+			status: &criapi.ContainerStatusResponse{Info: map[string]string{
+				"info": `{"pid": 100, "runtimeSpec": {"linux": {"cgroupsPath": "system.slice:cri-containerd:` + containerID + `"}}}`,
+			}},
+			cgroup: map[string]string{systemdScope + "/cgroup.procs": "100\n101\n"},
+			pids:   []int{100, 101},
+		},
+		{
+			name: "cgroup of runtime pid",
+			status: &criapi.ContainerStatusResponse{Info: map[string]string{
+				"info": `{"pid": 100}`,
+			}},
+			cgroup: map[string]string{"custom/parent/cgroup.procs": "100\n102\n"},
+			proc:   map[string]string{"100/cgroup": "0::/custom/parent\n"},
+			pids:   []int{100, 102},
+		},
+		{
+			name: "sandboxed runtime",
+			status: &criapi.ContainerStatusResponse{Info: map[string]string{
+				"info": `{"pid": 100, "runtimeSpec": {"linux": {"cgroupsPath": "/vc/sandbox"}}}`,
+			}},
+			pids: []int{100},
+		},
+		{
+			name:   "scan proc",
+			status: nil,
+			proc: map[string]string{
+				"1/cgroup":    "0::/init.scope\n",
+				"20/cgroup":   "0::/" + systemdScope + "\n",
+				"3/cgroup":    "0::/" + systemdScope + "\n",
+				"self/cgroup": "0::/" + systemdScope + "\n",
+			},
+			pids: []int{3, 20},
+		},
+		{
+			name:   "qos of pod",
+			status: &criapi.ContainerStatusResponse{Status: &criapi.ContainerStatus{Labels: labels}},
+			cgroup: map[string]string{qosPath + "/cgroup.procs": "7\n"},
+			pids:   []int{7},
+		},
+	}
+
+	for _, tc := range testCases {
+		cgroupRoot, _ := ioutil.TempDir("", "cgroup")
+		procRoot, _ := ioutil.TempDir("", "proc")
+		defer os.RemoveAll(cgroupRoot)
+		defer os.RemoveAll(procRoot)
+
+		writeFixture(t, cgroupRoot, map[string]string{"cgroup.controllers": "memory pids\n"})
+		writeFixture(t, cgroupRoot, tc.cgroup)
+		writeFixture(t, procRoot, tc.proc)
+
+		m := &containerRuntimeManager{
+			cgroupDriver:   "cgroupfs",
+			runtimeName:    "containerd",
+			requestTimeout: time.Second,
+			client:         &fakeRuntimeService{status: tc.status},
+			cgroupRoot:     cgroupRoot,
+			cgroupMode:     cgroup.Unified,
+			procRoot:       procRoot,
+			podLister:      podLister,
+		}
+
+		pids, err := m.GetPidsInContainers(containerID)
+		if err != nil || !reflect.DeepEqual(pids, tc.pids) {
+			t.Fatalf("%s: expect %v, got %v, %v", tc.name, tc.pids, pids, err)
+		}
+	}
+}
+
+func TestPidsCgroupCache(t *testing.T) {
+	containerID := "4c1f7a6b2e9d8c0a1b3e5f7a9c2d4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b5c7d"
+	systemdScope := "system.slice/cri-containerd-" + containerID + ".scope"
+
+	cgroupRoot, _ := ioutil.TempDir("", "cgroup")
+	procRoot, _ := ioutil.TempDir("", "proc")
+	defer os.RemoveAll(cgroupRoot)
+	defer os.RemoveAll(procRoot)
+
+	writeFixture(t, cgroupRoot, map[string]string{
+		"cgroup.controllers":                "memory pids\n",
+		systemdScope + "/cgroup.procs":      "3\n20\n",
+		"other.scope/cgroup.procs":          "1\n",
+		"system.slice/missing/cgroup.procs": "",
+	})
+	writeFixture(t, procRoot, map[string]string{
+		"1/cgroup":  "0::/other.scope\n",
+		"3/cgroup":  "0::/" + systemdScope + "\n",
+		"20/cgroup": "0::/" + systemdScope + "\n",
+	})
+
+	m := &containerRuntimeManager{
+		cgroupDriver:   "cgroupfs",
+		runtimeName:    "containerd",
+		requestTimeout: time.Second,
+		client:         &fakeRuntimeService{},
+		cgroupRoot:     cgroupRoot,
+		cgroupMode:     cgroup.Unified,
+		procRoot:       procRoot,
+	}
+
+	pids, err := m.GetPidsInContainers(containerID)
+	if err != nil || !reflect.DeepEqual(pids, []int{3, 20}) {
+		t.Fatalf("expect pids from proc, got %v, %v", pids, err)
+	}
+
+	// cgroup found by scanning is read directly later
+	os.RemoveAll(procRoot)
+	writeFixture(t, cgroupRoot, map[string]string{systemdScope + "/cgroup.procs": "3\n20\n21\n"})
+	pids, err = m.GetPidsInContainers(containerID)
+	if err != nil || !reflect.DeepEqual(pids, []int{3, 20, 21}) {
+		t.Fatalf("expect pids from cached cgroup, got %v, %v", pids, err)
+	}
+
+	// a gone cgroup is forgotten, processes are scanned again
+	os.RemoveAll(filepath.Join(cgroupRoot, systemdScope))
+	writeFixture(t, procRoot, map[string]string{"30/cgroup": "0::/" + systemdScope + "\n"})
+	if pids, err := m.GetPidsInContainers(containerID); !reflect.DeepEqual(pids, []int{30}) {
+		t.Fatalf("expect one more scan after cgroup is gone, got %v, %v", pids, err)
+	}
+
+	// pids without cgroup are reused until the scan expires
+	writeFixture(t, procRoot, map[string]string{"31/cgroup": "0::/" + systemdScope + "\n"})
+	if pids, err := m.GetPidsInContainers(containerID); !reflect.DeepEqual(pids, []int{30}) {
+		t.Fatalf("expect pids of last scan, got %v, %v", pids, err)
+	}
+
+	m.procScans[containerID] = procScan{pids: []int{30}, time: time.Now().Add(-procScanInterval)}
+	if pids, err := m.GetPidsInContainers(containerID); !reflect.DeepEqual(pids, []int{30, 31}) {
+		t.Fatalf("expect processes scanned again after expiration, got %v, %v", pids, err)
+	}
+
+	// exited processes are dropped from the last scan
+	os.RemoveAll(filepath.Join(procRoot, "30"))
+	if pids, err := m.GetPidsInContainers(containerID); !reflect.DeepEqual(pids, []int{31}) {
+		t.Fatalf("expect alive pids of last scan, got %v, %v", pids, err)
+	}
+}
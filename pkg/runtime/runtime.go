@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
@@ -35,7 +36,19 @@ type containerRuntimeManager struct {
 	cgroupDriver   string
 	runtimeName    string
 	requestTimeout time.Duration
-	client         criapi.RuntimeServiceClient
+	client         criClient
+	cgroupRoot     string
+	cgroupMode     cgroup.Mode
+	procRoot       string
+	capability     Capability
+
+	podLister watchdog.PodLister
+
+	//cgroupPaths caches resolved cgroup path of containers, procScans
+	//caches pids found by scanning processes if no cgroup is found
+	cgroupLock  sync.Mutex
+	cgroupPaths map[string]string
+	procScans   map[string]procScan
 }
 
 var _ ContainerRuntimeInterface = (*containerRuntimeManager)(nil)
@@ -44,65 +57,74 @@ var (
 	containerRoot = cgroup.NewCgroupName([]string{}, "kubepods")
 )
 
-func NewContainerRuntimeManager(cgroupDriver, endpoint string, requestTimeout time.Duration) (*containerRuntimeManager, error) {
+//NewContainerRuntimeManager connects to CRI endpoint, the endpoint is
+//detected from known runtimes if it's empty. podLister is used to find the
+//cgroup of containers by their pods.
+func NewContainerRuntimeManager(cgroupDriver, endpoint string, requestTimeout time.Duration,
+	podLister watchdog.PodLister) (*containerRuntimeManager, error) {
+	if len(endpoint) == 0 {
+		detected, err := DetectEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		endpoint = detected
+	}
+	klog.V(2).Infof("Container runtime endpoint is %s", endpoint)
+
 	dialOptions := []grpc.DialOption{grpc.WithInsecure(), grpc.WithDialer(utils.UnixDial), grpc.WithBlock(), grpc.WithTimeout(time.Second * 5)}
 	conn, err := grpc.Dial(endpoint, dialOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := criapi.NewRuntimeServiceClient(conn)
-
 	m := &containerRuntimeManager{
 		cgroupDriver:   cgroupDriver,
-		client:         client,
 		requestTimeout: requestTimeout,
+		cgroupRoot:     types.CGROUP_ROOT,
+		procRoot:       types.PROC_ROOT,
+		podLister:      podLister,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), m.requestTimeout)
-	defer cancel()
-	resp, err := client.Version(ctx, &criapi.VersionRequest{Version: "0.1.0"})
+	m.cgroupMode, err = cgroup.DetectMode(m.cgroupRoot)
 	if err != nil {
 		return nil, err
 	}
-
-	klog.V(2).Infof("Container runtime is %s", resp.RuntimeName)
-	m.runtimeName = resp.RuntimeName
-
-	return m, nil
-}
-
-func (m *containerRuntimeManager) GetPidsInContainers(containerID string) ([]int, error) {
-	req := &criapi.ContainerStatusRequest{
-		ContainerId: containerID,
-	}
+	klog.V(2).Infof("Cgroup mode is %s", m.cgroupMode)
 
 	ctx, cancel := context.WithTimeout(context.Background(), m.requestTimeout)
 	defer cancel()
-
-	resp, err := m.client.ContainerStatus(ctx, req)
+	client, apiVersion, resp, err := negotiateCRI(ctx, conn)
 	if err != nil {
-		klog.Errorf("can't get container %s status, %v", containerID, err)
 		return nil, err
 	}
 
-	ns := resp.Status.Labels[types.PodNamespaceLabelKey]
-	podName := resp.Status.Labels[types.PodNameLabelKey]
+	klog.V(2).Infof("Container runtime is %s, CRI %s", resp.RuntimeName, apiVersion)
+	m.client = client
+	m.runtimeName = resp.RuntimeName
+	m.capability = CapabilityOf(resp.RuntimeName)
 
-	pod, err := watchdog.GetPod(ns, podName)
-	if err != nil {
-		klog.Errorf("can't get pod %s/%s, %v", ns, podName, err)
-		return nil, err
-	}
+	return m, nil
+}
 
+//pidsOfContainer returns pids of container by the cgroup path which kubelet
+//computes from QoS class of pod
+func (m *containerRuntimeManager) pidsOfContainer(pod *v1.Pod, containerID string) ([]int, error) {
 	cgroupPath, err := m.getCgroupName(pod, containerID)
 	if err != nil {
 		klog.Errorf("can't get cgroup parent, %v", err)
 		return nil, err
 	}
 
+	return m.pidsInCgroup(cgroupPath)
+}
+
+//pidsInCgroup returns pids in all cgroup.procs under cgroupPath
+func (m *containerRuntimeManager) pidsInCgroup(cgroupPath string) ([]int, error) {
 	pids := make([]int, 0)
-	baseDir := filepath.Clean(filepath.Join(types.CGROUP_BASE, cgroupPath))
+	baseDir := filepath.Clean(filepath.Join(cgroup.ProcsBaseDir(m.cgroupRoot, m.cgroupMode), cgroupPath))
+	if _, err := os.Stat(baseDir); err != nil {
+		return nil, fmt.Errorf("can't find cgroup %s, %v", cgroupPath, err)
+	}
 	filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if info == nil {
 			return nil
@@ -164,7 +186,7 @@ func (m *containerRuntimeManager) getCgroupName(pod *v1.Pod, containerID string)
 
 	switch m.cgroupDriver {
 	case "systemd":
-		return fmt.Sprintf("%s/%s-%s.scope", cgroupName.ToSystemd(), cgroup.SystemdPathPrefixOfRuntime(m.runtimeName), containerID), nil
+		return fmt.Sprintf("%s/%s-%s.scope", cgroupName.ToSystemd(), m.capability.SystemdScopePrefix, containerID), nil
 	case "cgroupfs":
 		return fmt.Sprintf("%s/%s", cgroupName.ToCgroupfs(), containerID), nil
 	default:
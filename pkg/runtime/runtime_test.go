@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"tkestack.io/gpu-manager/pkg/utils/cgroup"
+)
+
+func TestPidsOfContainer(t *testing.T) {
+	containerID := "4c1f7a6b2e9d8c0a1b3e5f7a9c2d4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b5c7d"
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID("0d1e5a3f-2c4b-4e8a-9f10-1234567890ab")},
+		Status:     v1.PodStatus{QOSClass: v1.PodQOSBurstable},
+	}
+
+	testCases := []struct {
+		name        string
+		mode        cgroup.Mode
+		driver      string
+		runtimeName string
+		path        string
+	}{
+		{"legacy cgroupfs", cgroup.Legacy, "cgroupfs", "docker",
+			"memory/kubepods/burstable/pod0d1e5a3f-2c4b-4e8a-9f10-1234567890ab/" + containerID},
+		{"hybrid systemd", cgroup.Hybrid, "systemd", "docker",
+			"memory/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0d1e5a3f_2c4b_4e8a_9f10_1234567890ab.slice/docker-" + containerID + ".scope"},
+		{"unified cgroupfs", cgroup.Unified, "cgroupfs", "containerd",
+			"kubepods/burstable/pod0d1e5a3f-2c4b-4e8a-9f10-1234567890ab/" + containerID},
+		{"unified systemd", cgroup.Unified, "systemd", "containerd",
+			"kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0d1e5a3f_2c4b_4e8a_9f10_1234567890ab.slice/cri-containerd-" + containerID + ".scope"},
+	}
+
+	for _, tc := range testCases {
+		root, err := ioutil.TempDir("", "cgroup")
+		if err != nil {
+			t.Fatalf("can't create dir, %v", err)
+		}
+		defer os.RemoveAll(root)
+
+		// processes in a nested cgroup belong to the container too
+		containerDir := filepath.Join(root, tc.path)
+		os.MkdirAll(filepath.Join(containerDir, "nested"), 0755)
+		ioutil.WriteFile(filepath.Join(containerDir, "cgroup.procs"), []byte("1\n20\n"), 0644)
+		ioutil.WriteFile(filepath.Join(containerDir, "nested", "cgroup.procs"), []byte("300\n"), 0644)
+
+		m := &containerRuntimeManager{
+			cgroupDriver: tc.driver,
+			runtimeName:  tc.runtimeName,
+			cgroupRoot:   root,
+			cgroupMode:   tc.mode,
+			capability:   CapabilityOf(tc.runtimeName),
+		}
+
+		pids, err := m.pidsOfContainer(pod, containerID)
+		if err != nil || !reflect.DeepEqual(pids, []int{1, 20, 300}) {
+			t.Fatalf("%s: unexpected pids %v, %v", tc.name, pids, err)
+		}
+
+		// a mode mismatch must not be silently ignored
+		m.cgroupMode = (tc.mode + 2) % 3
+		if cgroup.ProcsBaseDir(root, m.cgroupMode) != cgroup.ProcsBaseDir(root, tc.mode) {
+			if _, err := m.pidsOfContainer(pod, containerID); err == nil {
+				t.Fatalf("%s: expect error for wrong mode", tc.name)
+			}
+		}
+	}
+}
@@ -18,7 +18,6 @@
 package server
 
 import (
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -27,12 +26,15 @@ import (
 	"path"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	displayapi "tkestack.io/gpu-manager/pkg/api/runtime/display"
 	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/config/v1alpha1"
 	deviceFactory "tkestack.io/gpu-manager/pkg/device"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
 	containerRuntime "tkestack.io/gpu-manager/pkg/runtime"
 	allocFactory "tkestack.io/gpu-manager/pkg/services/allocator"
 	"tkestack.io/gpu-manager/pkg/services/response"
@@ -40,6 +42,7 @@ import (
 	// Register allocator controller
 	_ "tkestack.io/gpu-manager/pkg/services/allocator/register"
 	"tkestack.io/gpu-manager/pkg/services/display"
+	"tkestack.io/gpu-manager/pkg/services/reloader"
 	"tkestack.io/gpu-manager/pkg/services/virtual-manager"
 	"tkestack.io/gpu-manager/pkg/services/volume"
 	"tkestack.io/gpu-manager/pkg/services/watchdog"
@@ -69,6 +72,11 @@ type managerImpl struct {
 
 	bundleServer map[string]ResourceServer
 	srv          *grpc.Server
+	httpServer   *http.Server
+	reloaders    []*reloader.Reloader
+	//stopCh stops the pod informer
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 //NewManager creates and returns a new managerImpl struct
@@ -77,6 +85,7 @@ func NewManager(cfg *config.Config) Manager {
 		config:       cfg,
 		bundleServer: make(map[string]ResourceServer),
 		srv:          grpc.NewServer(),
+		stopCh:       make(chan struct{}),
 	}
 
 	return manager
@@ -100,28 +109,86 @@ func (m *managerImpl) Ready() bool {
 }
 
 // #lizard forgives
-func (m *managerImpl) Run() error {
-	if err := m.validExtraConfig(m.config.ExtraConfigPath); err != nil {
-		klog.Errorf("Can not load extra config, err %s", err)
+func (m *managerImpl) Run(ctx context.Context) error {
+	defer m.Stop()
 
-		return err
+	if m.config.ExtraConfig == nil {
+		m.config.ExtraConfig = config.NewExtraConfigStore()
+	}
+
+	var embedded *v1alpha1.GPUManagerConfiguration
+	if len(m.config.ConfigFile) > 0 {
+		fileCfg, err := v1alpha1.Load(m.config.ConfigFile)
+		if err != nil {
+			klog.Errorf("Can not load config file, err %s", err)
+			return err
+		}
+
+		embedded = fileCfg
+	}
+
+	// Command line flags take precedence over the embedded config
+	switch {
+	case len(m.config.ExtraConfigPath) > 0:
+		extraReloader := reloader.New("extra", m.config.ExtraConfigPath, m.applyExtraConfig)
+		if err := extraReloader.Load(); err != nil {
+			klog.Errorf("Can not load extra config, err %s", err)
+			return err
+		}
+
+		m.reloaders = append(m.reloaders, extraReloader)
+	case embedded != nil && len(embedded.ExtraConfig) > 0:
+		extraReloader := reloader.New("extra", m.config.ConfigFile, m.applyEmbeddedExtraConfig)
+		if err := extraReloader.Load(); err != nil {
+			klog.Errorf("Can not load extra config, err %s", err)
+			return err
+		}
+
+		m.reloaders = append(m.reloaders, extraReloader)
 	}
 
 	if m.config.Driver == "" {
 		return fmt.Errorf("you should define a driver")
 	}
 
-	if len(m.config.VolumeConfigPath) > 0 {
+	var volumeReloader *reloader.Reloader
+	switch {
+	case len(m.config.VolumeConfigPath) > 0:
 		volumeManager, err := volume.NewVolumeManager(m.config.VolumeConfigPath, m.config.EnableShare)
 		if err != nil {
 			klog.Errorf("Can not create volume managerImpl, err %s", err)
 			return err
 		}
 
-		if err := volumeManager.Run(); err != nil {
+		volumeReloader = reloader.New("volume", m.config.VolumeConfigPath, volumeManager.Reload)
+	case embedded != nil && len(embedded.Volumes) > 0:
+		volumeManager := volume.NewVolumeManagerFromConfig(embedded.Volumes, m.config.EnableShare)
+		volumeReloader = reloader.New("volume", m.config.ConfigFile, func(data []byte) error {
+			fileCfg, err := v1alpha1.Decode(data)
+			if err != nil {
+				return err
+			}
+
+			return volumeManager.Update(fileCfg.Volumes)
+		})
+	}
+
+	if volumeReloader != nil {
+		// The first load mirrors all volumes, later loads are triggered by file changes
+		if err := volumeReloader.Load(); err != nil {
 			klog.Errorf("Can not start volume managerImpl, err %s", err)
 			return err
 		}
+
+		m.reloaders = append(m.reloaders, volumeReloader)
+	}
+
+	for _, r := range m.reloaders {
+		go func(r *reloader.Reloader) {
+			if err := r.Run(ctx.Done()); err != nil {
+				klog.Errorf("Can not watch config, err %s", err)
+			}
+		}(r)
 	}
 
 	sent, err := systemd.SdNotify(true, "READY=1\n")
@@ -148,21 +215,20 @@ func (m *managerImpl) Run() error {
 		return fmt.Errorf("can not generate client from config: error(%v)", err)
 	}
 
-	containerRuntimeManager, err := containerRuntime.NewContainerRuntimeManager(
-		m.config.CgroupDriver, m.config.ContainerRuntimeEndpoint, m.config.RequestTimeout)
+	podCache, err := watchdog.NewPodCache(client, m.config.Hostname, m.stopCh)
 	if err != nil {
-		klog.Errorf("can't create container runtime manager: %v", err)
+		klog.Errorf("can't create pod cache: %v", err)
 		return err
 	}
-	klog.V(2).Infof("Container runtime manager is running")
-
-	watchdog.NewPodCache(client, m.config.Hostname)
 	klog.V(2).Infof("Watchdog is running")
 
-	labeler := watchdog.NewNodeLabeler(client.CoreV1(), m.config.Hostname, m.config.NodeLabels)
-	if err := labeler.Run(); err != nil {
+	containerRuntimeManager, err := containerRuntime.NewContainerRuntimeManager(
+		m.config.CgroupDriver, m.config.ContainerRuntimeEndpoint, m.config.RequestTimeout, podCache)
+	if err != nil {
+		klog.Errorf("can't create container runtime manager: %v", err)
 		return err
 	}
+	klog.V(2).Infof("Container runtime manager is running")
 
 	klog.V(2).Infof("Load container response data")
 	responseManager := response.NewResponseManager()
@@ -171,7 +237,7 @@ func (m *managerImpl) Run() error {
 		return err
 	}
 
-	m.virtualManager = vitrual_manager.NewVirtualManager(m.config, containerRuntimeManager, responseManager)
+	m.virtualManager = vitrual_manager.NewVirtualManager(m.config, containerRuntimeManager, responseManager, podCache)
 	m.virtualManager.Run()
 
 	treeInitFn := deviceFactory.NewFuncForName(m.config.Driver)
@@ -180,30 +246,48 @@ func (m *managerImpl) Run() error {
 	tree.Init("")
 	tree.Update()
 
+	nvTree, isNvidia := tree.(*nvtree.NvidiaTree)
+
+	labeler := watchdog.NewNodeLabeler(client.CoreV1(), m.config.Hostname, m.config.NodeLabels)
+	if isNvidia {
+		labeler.AnnotateTopology(nvTree)
+	}
+
+	if err := labeler.Run(ctx.Done()); err != nil {
+		return err
+	}
+
+	if isNvidia {
+		go labeler.PublishInventory(nvTree, ctx.Done())
+	}
+
 	initAllocator := allocFactory.NewFuncForName(m.config.Driver)
 	if initAllocator == nil {
 		return fmt.Errorf("can not find allocator for %s", m.config.Driver)
 	}
 
-	m.allocator = initAllocator(m.config, tree, client, responseManager)
-	m.displayer = display.NewDisplay(m.config, tree, containerRuntimeManager)
+	m.allocator = initAllocator(m.config, tree, client, responseManager, podCache)
+	m.displayer = display.NewDisplay(m.config, tree, containerRuntimeManager, podCache)
 
 	klog.V(2).Infof("Starting the GRPC server, driver %s, queryPort %d", m.config.Driver, m.config.QueryPort)
 	m.setupGRPCService()
-	mux, err := m.setupGRPCGatewayService()
+	mux, err := m.setupGRPCGatewayService(ctx)
 	if err != nil {
 		return err
 	}
 	m.setupMetricsService(mux)
 
+	m.httpServer = &http.Server{
+		Addr:    net.JoinHostPort(m.config.QueryAddr, strconv.Itoa(m.config.QueryPort)),
+		Handler: mux,
+	}
 	go func() {
-		displayListenHandler := net.JoinHostPort(m.config.QueryAddr, strconv.Itoa(m.config.QueryPort))
-		if err := http.ListenAndServe(displayListenHandler, mux); err != nil {
+		if err := m.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			klog.Fatalf("failed to serve connections: %v", err)
 		}
 	}()
 
-	return m.runServer()
+	return m.runServer(ctx)
 }
 
 func (m *managerImpl) setupGRPCService() {
@@ -216,15 +300,16 @@ func (m *managerImpl) setupGRPCService() {
 	displayapi.RegisterGPUDisplayServer(m.srv, m)
 }
 
-func (m *managerImpl) setupGRPCGatewayService() (*http.ServeMux, error) {
+func (m *managerImpl) setupGRPCGatewayService(ctx context.Context) (*http.ServeMux, error) {
 	mux := http.NewServeMux()
 	displayMux := runtime.NewServeMux()
 
 	mux.Handle("/", displayMux)
+	mux.Handle("/config", reloader.Handler(m.reloaders...))
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 
 	go func() {
-		if err := displayapi.RegisterGPUDisplayHandlerFromEndpoint(context.Background(), displayMux, types.ManagerSocket, utils.DefaultDialOptions); err != nil {
+		if err := displayapi.RegisterGPUDisplayHandlerFromEndpoint(ctx, displayMux, types.ManagerSocket, utils.DefaultDialOptions); err != nil {
 			klog.Fatalf("Register display service failed, error %s", err)
 		}
 	}()
@@ -236,15 +321,15 @@ func (m *managerImpl) setupMetricsService(mux *http.ServeMux) {
 	r := prometheus.NewRegistry()
 
 	r.MustRegister(m.displayer)
+	if m.virtualManager != nil {
+		r.MustRegister(m.virtualManager)
+	}
 
 	mux.Handle("/metric", promhttp.HandlerFor(r, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}))
 }
 
-func (m *managerImpl) runServer() error {
-	for name, srv := range m.bundleServer {
-		klog.V(2).Infof("Server %s is running", name)
-		go srv.Run()
-	}
+func (m *managerImpl) runServer(ctx context.Context) error {
+	m.runResourceServers()
 
 	err := syscall.Unlink(types.ManagerSocket)
 	if err != nil && !os.IsNotExist(err) {
@@ -258,35 +343,93 @@ func (m *managerImpl) runServer() error {
 
 	klog.V(2).Infof("Server is ready at %s", types.ManagerSocket)
 
-	return m.srv.Serve(l)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- m.srv.Serve(l)
+	}()
+
+	select {
+	case <-ctx.Done():
+		klog.V(2).Infof("Server is shutting down")
+		return nil
+	case err := <-errCh:
+		return err
+	}
 }
 
-func (m *managerImpl) Stop() {
+func (m *managerImpl) runResourceServers() {
+	for name, srv := range m.bundleServer {
+		klog.V(2).Infof("Server %s is running", name)
+		go func(name string, srv ResourceServer) {
+			if err := srv.Run(); err != nil {
+				klog.Errorf("Server %s exits, %v", name, err)
+			}
+		}(name, srv)
+	}
+}
+
+func (m *managerImpl) RestartResourceServers() {
 	for name, srv := range m.bundleServer {
 		klog.V(2).Infof("Server %s is stopping", name)
 		srv.Stop()
 	}
-	m.srv.Stop()
-	klog.Fatal("Stop server")
+
+	m.runResourceServers()
 }
 
-func (m *managerImpl) validExtraConfig(path string) error {
-	if path != "" {
-		if _, err := os.Stat(path); err != nil {
-			return err
+//Stop stops all servers and services, the allocation state is written
+//down to checkpoint before returning
+func (m *managerImpl) Stop() {
+	m.stopOnce.Do(func() {
+		for name, srv := range m.bundleServer {
+			klog.V(2).Infof("Server %s is stopping", name)
+			srv.Stop()
 		}
 
-		file, err := os.Open(path)
-		if err != nil {
-			return err
+		if m.httpServer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := m.httpServer.Shutdown(ctx); err != nil {
+				klog.Warningf("Failed to shutdown http server, %v", err)
+			}
 		}
 
-		cfg := make(map[string]*config.ExtraConfig)
-		if err := json.NewDecoder(file).Decode(&cfg); err != nil {
-			return err
+		m.srv.Stop()
+
+		if m.virtualManager != nil {
+			m.virtualManager.Stop()
+		}
+
+		if m.allocator != nil {
+			m.allocator.Stop()
 		}
+
+		close(m.stopCh)
+
+		klog.V(2).Infof("Server is stopped")
+	})
+}
+
+func (m *managerImpl) applyExtraConfig(data []byte) error {
+	cfg, err := config.ParseExtraConfig(data)
+	if err != nil {
+		return err
+	}
+
+	m.config.ExtraConfig.Set(cfg)
+
+	return nil
+}
+
+func (m *managerImpl) applyEmbeddedExtraConfig(data []byte) error {
+	fileCfg, err := v1alpha1.Decode(data)
+	if err != nil {
+		return err
 	}
 
+	m.config.ExtraConfig.Set(fileCfg.ExtraConfig)
+
 	return nil
 }
 
@@ -320,6 +463,10 @@ func (m *managerImpl) Version(ctx context.Context, req *google_protobuf1.Empty)
 	return m.displayer.Version(ctx, req)
 }
 
+func (m *managerImpl) Simulate(ctx context.Context, req *displayapi.SimulateRequest) (*displayapi.SimulateResponse, error) {
+	return m.displayer.Simulate(ctx, req)
+}
+
 func (m *managerImpl) RegisterToKubelet() error {
 	socketFile := filepath.Join(m.config.DevicePluginPath, types.KubeletSocket)
 	dialOptions := []grpc.DialOption{grpc.WithInsecure(), grpc.WithDialer(utils.UnixDial), grpc.WithBlock(), grpc.WithTimeout(time.Second * 5)}
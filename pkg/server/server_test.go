@@ -21,6 +21,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
@@ -159,7 +160,8 @@ func TestServer(t *testing.T) {
 	// init manager
 	srv, _ := NewManager(cfg).(*managerImpl)
 	fakeRuntimeManager := runtime.NewContainerRuntimeManagerStub()
-	srv.virtualManager = virtual_manager.NewVirtualManagerForTest(cfg, fakeRuntimeManager, response.NewFakeResponseManager())
+	srv.virtualManager = virtual_manager.NewVirtualManagerForTest(cfg, fakeRuntimeManager, response.NewFakeResponseManager(),
+		watchdog.NewFakePodLister())
 	srv.virtualManager.Run()
 	defer stopServer(srv)
 
@@ -184,9 +186,9 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 	}
 
 	k8sClient := fake.NewSimpleClientset()
-	watchdog.NewPodCacheForTest(k8sClient)
+	podLister := watchdog.NewFakePodLister()
 	initAllocator := allocFactory.NewFuncForName(cfg.Driver + "_test")
-	srv.allocator = initAllocator(cfg, tree, k8sClient, response.NewFakeResponseManager())
+	srv.allocator = initAllocator(cfg, tree, k8sClient, response.NewFakeResponseManager(), podLister)
 	srv.setupGRPCService()
 	srv.RegisterToKubelet()
 	for _, rs := range srv.bundleServer {
@@ -264,9 +266,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 			pod.Annotations[types.PredicateGPUIndexPrefix+strconv.Itoa(i)] = "0"
 		}
 		pod, _ = k8sClient.CoreV1().Pods("test-ns").Create(pod)
-
-		// wait for podLister to sync
-		time.Sleep(time.Second * 2)
+		podLister.Add(pod)
 
 		client := pluginapi.NewDevicePluginClient(conn)
 		for _, c := range pod.Spec.Containers {
@@ -287,4 +287,33 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 			}
 		}
 	}
+
+	//check if bundleServers can be served again after kubelet restarts
+	srv.RestartResourceServers()
+	for _, rs := range srv.bundleServer {
+		if err := utils.WaitForServer(rs.SocketName()); err != nil {
+			t.Fatalf("%s failed to restart: %+v", rs.SocketName(), err)
+		}
+	}
+
+	//check if ListAndWatch exits when allocator is stopped
+	newConn, err := grpc.Dial(pluginSocket, utils.DefaultDialOptions...)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %+v", err)
+	}
+	defer newConn.Close()
+
+	stream, err := pluginapi.NewDevicePluginClient(newConn).ListAndWatch(context.Background(), &pluginapi.Empty{})
+	if err != nil {
+		t.Fatalf("Failed to list and watch: %+v", err)
+	}
+
+	if resp, err := stream.Recv(); err != nil || len(resp.Devices) == 0 {
+		t.Fatalf("Failed to receive devices, resp %+v, err %+v", resp, err)
+	}
+
+	srv.allocator.Stop()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("ListAndWatch should exit after allocator stopped, got %+v", err)
+	}
 }
@@ -18,17 +18,25 @@
 package server
 
 import (
+	"context"
+	"sync"
+
 	"google.golang.org/grpc"
 )
 
 //Manager api
 type Manager interface {
 	Ready() bool
-	Run() error
+	//Run blocks until ctx is done or the server fails, all services are
+	//stopped before it returns
+	Run(ctx context.Context) error
 	RegisterToKubelet() error
+	//RestartResourceServers serves device plugin sockets again, it's used
+	//when kubelet restarts and cleans up the device plugin directory
+	RestartResourceServers()
 }
 
-//ResourceServer api for manager
+//ResourceServer api for manager, a stopped server can be run again
 type ResourceServer interface {
 	Run() error
 	Stop()
@@ -37,6 +45,8 @@ type ResourceServer interface {
 }
 
 type resourceServerImpl struct {
+	sync.Mutex
+
 	srv        *grpc.Server
 	socketFile string
 
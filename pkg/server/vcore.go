@@ -47,7 +47,6 @@ func newVcoreServer(manager *managerImpl) ResourceServer {
 
 	return &vcoreResourceServer{
 		resourceServerImpl: resourceServerImpl{
-			srv:        grpc.NewServer(),
 			socketFile: socketFile,
 			mgr:        manager,
 		},
@@ -63,11 +62,22 @@ func (vr *vcoreResourceServer) ResourceName() string {
 }
 
 func (vr *vcoreResourceServer) Stop() {
-	vr.srv.Stop()
+	vr.Lock()
+	defer vr.Unlock()
+
+	if vr.srv != nil {
+		vr.srv.Stop()
+	}
 }
 
 func (vr *vcoreResourceServer) Run() error {
-	pluginapi.RegisterDevicePluginServer(vr.srv, vr)
+	// grpc server can't be served again after stopped
+	srv := grpc.NewServer()
+	pluginapi.RegisterDevicePluginServer(srv, vr)
+
+	vr.Lock()
+	vr.srv = srv
+	vr.Unlock()
 
 	err := syscall.Unlink(vr.socketFile)
 	if err != nil && !os.IsNotExist(err) {
@@ -81,7 +91,7 @@ func (vr *vcoreResourceServer) Run() error {
 
 	klog.V(2).Infof("Server %s is ready at %s", types.VCoreAnnotation, vr.socketFile)
 
-	return vr.srv.Serve(l)
+	return srv.Serve(l)
 }
 
 /** device plugin interface */
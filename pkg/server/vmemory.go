@@ -46,7 +46,6 @@ func newVmemoryServer(manager *managerImpl) ResourceServer {
 	socketFile := filepath.Join(manager.config.DevicePluginPath, vmemorySocketName)
 	return &vmemoryResourceServer{
 		resourceServerImpl: resourceServerImpl{
-			srv:        grpc.NewServer(),
 			socketFile: socketFile,
 			mgr:        manager,
 		},
@@ -62,11 +61,22 @@ func (vr *vmemoryResourceServer) ResourceName() string {
 }
 
 func (vr *vmemoryResourceServer) Stop() {
-	vr.srv.Stop()
+	vr.Lock()
+	defer vr.Unlock()
+
+	if vr.srv != nil {
+		vr.srv.Stop()
+	}
 }
 
 func (vr *vmemoryResourceServer) Run() error {
-	pluginapi.RegisterDevicePluginServer(vr.srv, vr)
+	// grpc server can't be served again after stopped
+	srv := grpc.NewServer()
+	pluginapi.RegisterDevicePluginServer(srv, vr)
+
+	vr.Lock()
+	vr.srv = srv
+	vr.Unlock()
 
 	err := syscall.Unlink(vr.socketFile)
 	if err != nil && !os.IsNotExist(err) {
@@ -80,7 +90,7 @@ func (vr *vmemoryResourceServer) Run() error {
 
 	klog.V(2).Infof("Server %s is ready at %s", types.VMemoryAnnotation, vr.socketFile)
 
-	return vr.srv.Serve(l)
+	return srv.Serve(l)
 }
 
 /** device plugin interface */
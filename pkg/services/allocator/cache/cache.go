@@ -22,6 +22,10 @@ type Info struct {
 	Devices []string
 	Cores   int64
 	Memory  int64
+	//BestEffort is true if cores are oversubscribed by best-effort work
+	BestEffort bool `json:",omitempty"`
+	//Guaranteed is true if cores are taken by guaranteed work
+	Guaranteed bool `json:",omitempty"`
 }
 
 type containerToInfo map[string]*Info
@@ -29,6 +33,8 @@ type containerToInfo map[string]*Info
 // PodCache represents a list of pod to GPU mappings.
 type PodCache struct {
 	PodGPUMapping map[string]containerToInfo
+	//MemoryBlockSize is the size of vmemory block when the cache is written
+	MemoryBlockSize int64 `json:",omitempty"`
 }
 
 //NewAllocateCache creates new PodCache
@@ -20,11 +20,11 @@ package dummy
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"tkestack.io/gpu-manager/pkg/config"
 	"tkestack.io/gpu-manager/pkg/device"
 	"tkestack.io/gpu-manager/pkg/services/response"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
 
 	// Register test allocator controller
 	_ "tkestack.io/gpu-manager/pkg/device/dummy"
@@ -46,7 +46,7 @@ type DummyAllocator struct {
 var _ allocator.GPUTopoService = &DummyAllocator{}
 
 //NewDummyAllocator returns a new DummyAllocator
-func NewDummyAllocator(_ *config.Config, _ device.GPUTree, _ kubernetes.Interface, _ response.Manager) allocator.GPUTopoService {
+func NewDummyAllocator(_ *config.Config, _ device.GPUTree, _ kubernetes.Interface, _ response.Manager, _ watchdog.PodLister) allocator.GPUTopoService {
 	return &DummyAllocator{}
 }
 
@@ -83,18 +83,22 @@ func (ta *DummyAllocator) ListAndWatchWithResourceName(resourceName string, e *p
 		},
 	}
 
-	s.Send(&pluginapi.ListAndWatchResponse{Devices: devs})
+	if err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs}); err != nil {
+		return err
+	}
 
 	// We don't send unhealthy state
-	for {
-		time.Sleep(time.Second)
-	}
+	<-s.Context().Done()
 
 	klog.V(2).Infof("ListAndWatch %s exit", resourceName)
 
 	return nil
 }
 
+//Stop does nothing
+func (ta *DummyAllocator) Stop() {
+}
+
 //GetDevicePluginOptions returns empty DevicePluginOptions
 func (ta *DummyAllocator) GetDevicePluginOptions(ctx context.Context, e *pluginapi.Empty) (*pluginapi.DevicePluginOptions, error) {
 	return &pluginapi.DevicePluginOptions{}, nil
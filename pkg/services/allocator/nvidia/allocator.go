@@ -44,8 +44,6 @@ import (
 	"golang.org/x/net/context"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/labels"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -73,13 +71,36 @@ type NvidiaTopoAllocator struct {
 
 	config            *config.Config
 	evaluators        map[string]Evaluator
-	extraConfig       map[string]*config.ExtraConfig
 	k8sClient         kubernetes.Interface
+	podLister         watchdog.PodLister
 	unfinishedPod     *v1.Pod
 	queue             workqueue.RateLimitingInterface
+	recycleQueue      workqueue.DelayingInterface
 	stopChan          chan struct{}
+	stopOnce          sync.Once
 	checkpointManager *checkpoint.Manager
 	responseManager   response.Manager
+	//kubeletCheckpoint indexes kubelet device plugin checkpoint
+	kubeletCheckpoint *utils.CheckpointIndex
+
+	//devices are reused until cards, logical memory or block size changes,
+	//so ListAndWatch sends the same IDs
+	devicesLock       sync.Mutex
+	devicesKey        devicesKey
+	devicesGeneration int
+	devices           map[string][]*pluginapi.Device
+
+	//podsChanged is closed and replaced when a GPU pod is added or updated,
+	//it wakes up Allocate waiting for candidate pod
+	podsChangedLock sync.Mutex
+	podsChanged     chan struct{}
+}
+
+//devicesKey is what advertised devices are built from
+type devicesKey struct {
+	cards     int
+	memory    int64
+	blockSize int64
 }
 
 const (
@@ -101,11 +122,16 @@ var (
 	waitTimeout                          = 10 * time.Second
 )
 
+//devicesCheckPeriod is the interval ListAndWatch checks whether advertised
+//devices are changed
+const devicesCheckPeriod = 10 * time.Second
+
 //NewNvidiaTopoAllocator returns a new NvidiaTopoAllocator
 func NewNvidiaTopoAllocator(config *config.Config,
 	tree device.GPUTree,
 	k8sClient kubernetes.Interface,
-	responseManager response.Manager) allocator.GPUTopoService {
+	responseManager response.Manager,
+	podLister watchdog.PodLister) allocator.GPUTopoService {
 
 	_tree, _ := tree.(*nvtree.NvidiaTree)
 	cm, err := checkpoint.NewManager(config.CheckpointPath, checkpointFileName)
@@ -118,10 +144,14 @@ func NewNvidiaTopoAllocator(config *config.Config,
 		evaluators:        make(map[string]Evaluator),
 		allocatedPod:      cache.NewAllocateCache(),
 		k8sClient:         k8sClient,
+		podLister:         podLister,
 		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		recycleQueue:      workqueue.NewNamedDelayingQueue("recycle"),
 		stopChan:          make(chan struct{}),
+		podsChanged:       make(chan struct{}),
 		checkpointManager: cm,
 		responseManager:   responseManager,
+		kubeletCheckpoint: utils.NewCheckpointIndex(config.DevicePluginPath),
 	}
 
 	// Load kernel module if it's not loaded
@@ -130,15 +160,19 @@ func NewNvidiaTopoAllocator(config *config.Config,
 	// Initialize evaluator
 	alloc.initEvaluator(_tree)
 
-	// Read extra config if it's given
-	alloc.loadExtraConfig(config.ExtraConfigPath)
-
 	// Process allocation results in another goroutine
 	go wait.Until(alloc.runProcessResult, time.Second, alloc.stopChan)
 
 	// Recover
 	alloc.recoverInUsed()
 
+	// Free GPU of terminated pods after the grace period
+	alloc.podLister.AddEventHandler(watchdog.PodTerminationHandler(alloc.onPodTerminated))
+	go wait.Until(alloc.runRecycle, time.Second, alloc.stopChan)
+
+	// Wake up Allocate waiting for candidate pod
+	alloc.podLister.AddEventHandler(watchdog.PodChangeHandler(alloc.onPodChanged))
+
 	// Check allocation in another goroutine periodically
 	go alloc.checkAllocationPeriodically(alloc.stopChan)
 
@@ -150,7 +184,8 @@ func NewNvidiaTopoAllocator(config *config.Config,
 func NewNvidiaTopoAllocatorForTest(config *config.Config,
 	tree device.GPUTree,
 	k8sClient kubernetes.Interface,
-	responseManager response.Manager) allocator.GPUTopoService {
+	responseManager response.Manager,
+	podLister watchdog.PodLister) allocator.GPUTopoService {
 
 	_tree, _ := tree.(*nvtree.NvidiaTree)
 	cm, err := checkpoint.NewManager("/tmp", checkpointFileName)
@@ -163,15 +198,26 @@ func NewNvidiaTopoAllocatorForTest(config *config.Config,
 		evaluators:        make(map[string]Evaluator),
 		allocatedPod:      cache.NewAllocateCache(),
 		k8sClient:         k8sClient,
+		podLister:         podLister,
 		stopChan:          make(chan struct{}),
+		podsChanged:       make(chan struct{}),
 		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		recycleQueue:      workqueue.NewNamedDelayingQueue("recycle"),
 		checkpointManager: cm,
 		responseManager:   responseManager,
+		kubeletCheckpoint: utils.NewCheckpointIndex(config.DevicePluginPath),
 	}
 
 	// Initialize evaluator
 	alloc.initEvaluator(_tree)
 
+	// Free GPU of terminated pods after the grace period
+	alloc.podLister.AddEventHandler(watchdog.PodTerminationHandler(alloc.onPodTerminated))
+	go wait.Until(alloc.runRecycle, time.Second, alloc.stopChan)
+
+	// Wake up Allocate waiting for candidate pod
+	alloc.podLister.AddEventHandler(watchdog.PodChangeHandler(alloc.onPodChanged))
+
 	// Check allocation in another goroutine periodically
 	go alloc.checkAllocationPeriodically(alloc.stopChan)
 
@@ -197,11 +243,19 @@ func (ta *NvidiaTopoAllocator) recoverInUsed() {
 					klog.V(2).Infof("Uid: %s, Name: %s, util: %d, memory: %d", uid, cName, cache.Cores, cache.Memory)
 
 					id, _ := utils.GetGPUMinorID(dev)
-					ta.tree.MarkOccupied(&nvtree.NvidiaNode{
+					node := &nvtree.NvidiaNode{
 						Meta: nvtree.DeviceMeta{
 							MinorID: id,
 						},
-					}, cache.Cores, cache.Memory)
+					}
+					switch {
+					case cache.BestEffort:
+						ta.tree.MarkOccupiedBestEffort(node, cache.Cores, cache.Memory)
+					case cache.Guaranteed:
+						ta.tree.MarkOccupiedGuaranteed(node, cache.Cores, cache.Memory)
+					default:
+						ta.tree.MarkOccupied(node, cache.Cores, cache.Memory)
+					}
 				}
 			}
 		}
@@ -214,7 +268,7 @@ func (ta *NvidiaTopoAllocator) recoverInUsed() {
 
 func (ta *NvidiaTopoAllocator) checkAllocation() {
 	klog.V(4).Infof("Checking allocation of pods on this node")
-	pods, err := getPodsOnNode(ta.k8sClient, ta.config.Hostname, "")
+	pods, err := ta.getPodsOnNode("")
 	if err != nil {
 		klog.Infof("Failed to get pods on node due to %v", err)
 		return
@@ -270,30 +324,11 @@ func (ta *NvidiaTopoAllocator) checkAllocationPeriodically(quit chan struct{}) {
 	}
 }
 
-func (ta *NvidiaTopoAllocator) loadExtraConfig(path string) {
-	if path != "" {
-		klog.V(2).Infof("Load extra config from %s", path)
-
-		f, err := os.Open(path)
-		if err != nil {
-			klog.Fatalf("Can not load extra config at %s, err %s", path, err)
-		}
-
-		defer f.Close()
-
-		cfg := make(map[string]*config.ExtraConfig)
-		if err := json.NewDecoder(f).Decode(&cfg); err != nil {
-			klog.Fatalf("Can not unmarshal extra config, err %s", err)
-		}
-
-		ta.extraConfig = cfg
-	}
-}
-
 func (ta *NvidiaTopoAllocator) initEvaluator(tree *nvtree.NvidiaTree) {
-	ta.evaluators["link"] = nveval.NewLinkMode(tree)
-	ta.evaluators["fragment"] = nveval.NewFragmentMode(tree)
-	ta.evaluators["share"] = nveval.NewShareMode(tree)
+	ta.evaluators[nveval.LinkMode] = nveval.NewLinkMode(tree)
+	ta.evaluators[nveval.FragmentMode] = nveval.NewFragmentMode(tree)
+	ta.evaluators[nveval.ShareMode] = nveval.NewShareMode(tree)
+	ta.evaluators[nveval.BestEffortMode] = nveval.NewBestEffortMode(tree, ta.config.OversubscriptionRatio)
 }
 
 func (ta *NvidiaTopoAllocator) loadModule() {
@@ -310,15 +345,51 @@ func (ta *NvidiaTopoAllocator) loadModule() {
 	}
 }
 
+//advertisedDevices returns devices of resource and their generation.
+//Devices of all resources are rebuilt only if cards, logical memory or
+//block size changes, the generation is increased then.
+func (ta *NvidiaTopoAllocator) advertisedDevices(resourceName string) ([]*pluginapi.Device, int) {
+	ta.devicesLock.Lock()
+	defer ta.devicesLock.Unlock()
+
+	key := devicesKey{
+		cards:     len(ta.tree.Leaves()),
+		blockSize: ta.config.GetMemoryBlockSize(),
+	}
+	for _, n := range ta.tree.Leaves() {
+		key.memory += n.LogicalMemory()
+	}
+
+	if ta.devices == nil || key != ta.devicesKey {
+		klog.V(2).Infof("Build devices of %d cards, memory %d, block size %d", key.cards, key.memory, key.blockSize)
+		ta.devices = make(map[string][]*pluginapi.Device)
+		for _, dev := range ta.capacity() {
+			for _, name := range []string{types.VCoreAnnotation, types.VMemoryAnnotation} {
+				if strings.HasPrefix(dev.ID, name+"-") {
+					ta.devices[name] = append(ta.devices[name], dev)
+				}
+			}
+		}
+		ta.devicesKey = key
+		ta.devicesGeneration++
+	}
+
+	return ta.devices[resourceName], ta.devicesGeneration
+}
+
+//capacity returns one device per resource unit, because kubelet counts
+//devices as the amount of resource. The number of vmemory devices can only
+//be cut by a larger block size.
 func (ta *NvidiaTopoAllocator) capacity() (devs []*pluginapi.Device) {
 	var (
 		gpuDevices, memoryDevices []*pluginapi.Device
 		totalMemory               int64
+		blockSize                 = ta.config.GetMemoryBlockSize()
 	)
 
 	nodes := ta.tree.Leaves()
 	for i := range nodes {
-		totalMemory += int64(nodes[i].Meta.TotalMemory)
+		totalMemory += nodes[i].LogicalMemory()
 	}
 
 	totalCores := len(nodes) * nvtree.HundredCore
@@ -330,11 +401,11 @@ func (ta *NvidiaTopoAllocator) capacity() (devs []*pluginapi.Device) {
 		}
 	}
 
-	totalMemoryBlocks := totalMemory / types.MemoryBlockSize
+	totalMemoryBlocks := totalMemory / blockSize
 	memoryDevices = make([]*pluginapi.Device, totalMemoryBlocks)
 	for i := int64(0); i < totalMemoryBlocks; i++ {
 		memoryDevices[i] = &pluginapi.Device{
-			ID:     fmt.Sprintf("%s-%d-%d", types.VMemoryAnnotation, types.MemoryBlockSize, i),
+			ID:     fmt.Sprintf("%s-%d-%d", types.VMemoryAnnotation, blockSize, i),
 			Health: pluginapi.Healthy,
 		}
 	}
@@ -345,22 +416,40 @@ func (ta *NvidiaTopoAllocator) capacity() (devs []*pluginapi.Device) {
 	return
 }
 
+//noFreeNodeError explains why no card can be chosen, request memory is
+//checked against the largest card of selected models
+func (ta *NvidiaTopoAllocator) noFreeNodeError(selectors []string, filter nvtree.NodeFilter, needMemory int64, shareMode bool) error {
+	maxMemory := ta.tree.MaxMemory(filter)
+	if maxMemory == 0 && len(selectors) > 0 {
+		return fmt.Errorf("no gpu of model %s", strings.Join(selectors, ","))
+	}
+
+	if shareMode && needMemory > maxMemory {
+		return fmt.Errorf("request memory %d is larger than %d", needMemory, maxMemory)
+	}
+
+	return fmt.Errorf("no free node")
+}
+
 // #lizard forgives
 func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container, req *pluginapi.ContainerAllocateRequest) (*pluginapi.ContainerAllocateResponse, error) {
 	var (
 		nodes                       []*nvtree.NvidiaNode
 		needCores, needMemoryBlocks int64
+		needMemory                  int64
 		predicateMissed             bool
 		allocated                   bool
 	)
 
 	predicateMissed = !utils.IsGPUPredicatedPod(pod)
-	singleNodeMemory := int64(ta.tree.Leaves()[0].Meta.TotalMemory)
+	selectors := utils.GetGPUModelSelectorsOfPod(pod)
+	filter := nvtree.ModelFilter(selectors)
 	for _, v := range req.DevicesIDs {
 		if strings.HasPrefix(v, types.VCoreAnnotation) {
 			needCores++
 		} else if strings.HasPrefix(v, types.VMemoryAnnotation) {
 			needMemoryBlocks++
+			needMemory += memoryBlockOfDevice(v, ta.config.GetMemoryBlockSize())
 		}
 	}
 
@@ -369,9 +458,10 @@ func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container,
 		return nil, nil
 	}
 
-	needMemory := needMemoryBlocks * types.MemoryBlockSize
 	ta.tree.Update()
 	shareMode := false
+	bestEffort := false
+	guaranteed := false
 
 	podCache := ta.allocatedPod.GetCache(string(pod.UID))
 	containerCache := &cache.Info{}
@@ -395,43 +485,45 @@ func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container,
 	} else {
 		klog.V(2).Infof("Try allocate for %s(%s), vcore %d, vmemory %d", pod.UID, container.Name, needCores, needMemory)
 
-		switch {
-		case needCores > nvtree.HundredCore:
-			eval, ok := ta.evaluators["link"]
-			if !ok {
-				return nil, fmt.Errorf("can not find evaluator link")
-			}
-			if needCores%nvtree.HundredCore > 0 {
-				return nil, fmt.Errorf("cores are greater than %d, must be multiple of %d", nvtree.HundredCore, nvtree.HundredCore)
-			}
-			nodes = eval.Evaluate(needCores, 0)
-		case needCores == nvtree.HundredCore:
-			eval, ok := ta.evaluators["fragment"]
-			if !ok {
-				return nil, fmt.Errorf("can not find evaluator fragment")
-			}
-			nodes = eval.Evaluate(needCores, 0)
-		default:
-			if !ta.config.EnableShare {
-				return nil, fmt.Errorf("share mode is not enabled")
-			}
-			if needCores == 0 || needMemory == 0 {
-				return nil, fmt.Errorf("that cores or memory is zero is not permitted in share mode")
-			}
+		qos, err := utils.GetQoSClassOfPod(pod)
+		if err != nil {
+			return nil, err
+		}
 
-			// evaluate in share mode
-			shareMode = true
-			eval, ok := ta.evaluators["share"]
-			if !ok {
-				return nil, fmt.Errorf("can not find evaluator share")
-			}
-			nodes = eval.Evaluate(needCores, needMemory)
-			if len(nodes) == 0 {
-				if shareMode && needMemory > singleNodeMemory {
-					return nil, fmt.Errorf("request memory %d is larger than %d", needMemory, singleNodeMemory)
-				}
+		mode, err := nveval.SelectModeWithQoS(needCores, needMemory, ta.config.EnableShare, qos, ta.config.OversubscriptionRatio)
+		if err != nil {
+			return nil, err
+		}
+		guaranteed = qos == types.QoSGuaranteed
+
+		eval, ok := ta.evaluators[mode]
+		if !ok {
+			return nil, fmt.Errorf("can not find evaluator %s", mode)
+		}
+
+		shareMode = mode == nveval.ShareMode || mode == nveval.BestEffortMode
+		quotaFilter, err := ta.quotaFilter(pod, needCores, needMemory, shareMode)
+		if err != nil {
+			return nil, ta.rejectByQuota(pod, err)
+		}
+
+		// memory is only evaluated in share mode
+		var (
+			evalMemory int64
+			exceeded   bool
+		)
+		if shareMode {
+			bestEffort = mode == nveval.BestEffortMode
+			evalMemory = needMemory
+		}
+		nodes, exceeded = evaluate(eval, needCores, evalMemory, filter, quotaFilter)
+		if exceeded {
+			return nil, ta.rejectByQuota(pod, fmt.Errorf("no card is within the quota of namespace %s", pod.Namespace))
+		}
 
-				return nil, fmt.Errorf("no free node")
+		if shareMode {
+			if len(nodes) == 0 {
+				return nil, ta.noFreeNodeError(selectors, filter, needMemory, shareMode)
 			}
 
 			if !predicateMissed {
@@ -468,11 +560,7 @@ func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container,
 	}
 
 	if len(nodes) == 0 {
-		if shareMode && needMemory > singleNodeMemory {
-			return nil, fmt.Errorf("request memory %d is larger than %d", needMemory, singleNodeMemory)
-		}
-
-		return nil, fmt.Errorf("no free node")
+		return nil, ta.noFreeNodeError(selectors, filter, needMemory, shareMode)
 	}
 
 	ctntResp := &pluginapi.ContainerAllocateResponse{
@@ -499,17 +587,29 @@ func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container,
 		deviceList = append(deviceList, n.Meta.UUID)
 
 		if !allocated {
-			ta.tree.MarkOccupied(n, needCores, needMemory)
+			switch {
+			case bestEffort:
+				ta.tree.MarkOccupiedBestEffort(n, needCores, needMemory)
+			case guaranteed:
+				ta.tree.MarkOccupiedGuaranteed(n, needCores, needMemory)
+			default:
+				ta.tree.MarkOccupied(n, needCores, needMemory)
+			}
 		}
 		allocatedDevices.Insert(name)
 	}
 
 	ctntResp.Annotations[types.VDeviceAnnotation] = vDeviceAnnotationStr(nodes)
+	if ta.config.EnableUVMSwap && memoryOversubscribed(nodes) {
+		ctntResp.Annotations[types.VMemorySwapAnnotation] = "true"
+	}
 	if !allocated {
 		ta.allocatedPod.Insert(string(pod.UID), container.Name, &cache.Info{
-			Devices: allocatedDevices.UnsortedList(),
-			Cores:   needCores,
-			Memory:  needMemory,
+			Devices:    allocatedDevices.UnsortedList(),
+			Cores:      needCores,
+			Memory:     needMemory,
+			BestEffort: bestEffort,
+			Guaranteed: guaranteed,
 		})
 	}
 
@@ -547,8 +647,8 @@ func (ta *NvidiaTopoAllocator) allocateOne(pod *v1.Pod, container *v1.Container,
 		Permissions:   "rwm",
 	})
 
-	// Append default device
-	if cfg, found := ta.extraConfig["default"]; found {
+	// Append default device, the extra config may be reloaded at runtime
+	if cfg, found := ta.config.ExtraConfig.Get("default"); found {
 		for _, dev := range cfg.Devices {
 			ctntResp.Devices = append(ctntResp.Devices, &pluginapi.DeviceSpec{
 				ContainerPath: dev,
@@ -615,7 +715,7 @@ func (ta *NvidiaTopoAllocator) requestForVCuda(podUID string) error {
 }
 
 func (ta *NvidiaTopoAllocator) recycle() {
-	activePods := watchdog.GetActivePods()
+	activePods := ta.podLister.GetActivePods()
 
 	lastActivePodUids := sets.NewString()
 	activePodUids := sets.NewString()
@@ -633,6 +733,47 @@ func (ta *NvidiaTopoAllocator) recycle() {
 	ta.freeGPU(podsToBeRemoved.List())
 }
 
+//onPodTerminated schedules to free GPU of pod after the grace period, events
+//of the same pod in the period are merged
+func (ta *NvidiaTopoAllocator) onPodTerminated(pod *v1.Pod) {
+	klog.V(4).Infof("Pod %s(%s) is terminated, recycle it in %s", pod.Name, pod.UID, ta.config.RecycleGracePeriod)
+	ta.recycleQueue.AddAfter(string(pod.UID), ta.config.RecycleGracePeriod)
+}
+
+func (ta *NvidiaTopoAllocator) runRecycle() {
+	for ta.processNextRecycle() {
+	}
+}
+
+func (ta *NvidiaTopoAllocator) processNextRecycle() bool {
+	key, quit := ta.recycleQueue.Get()
+	if quit {
+		return false
+	}
+	defer ta.recycleQueue.Done(key)
+
+	podUID, ok := key.(string)
+	if !ok {
+		return true
+	}
+
+	ta.Lock()
+	defer ta.Unlock()
+
+	if ta.allocatedPod.GetCache(podUID) == nil {
+		return true
+	}
+
+	if _, ok := ta.podLister.GetActivePods()[podUID]; ok {
+		return true
+	}
+
+	klog.V(2).Infof("Recycle terminated pod %s", podUID)
+	ta.freeGPU([]string{podUID})
+
+	return true
+}
+
 func (ta *NvidiaTopoAllocator) freeGPU(podUids []string) {
 	for _, uid := range podUids {
 		for contName, info := range ta.allocatedPod.GetCache(uid) {
@@ -640,11 +781,19 @@ func (ta *NvidiaTopoAllocator) freeGPU(podUids []string) {
 
 			for _, devName := range info.Devices {
 				id, _ := utils.GetGPUMinorID(devName)
-				ta.tree.MarkFree(&nvtree.NvidiaNode{
+				node := &nvtree.NvidiaNode{
 					Meta: nvtree.DeviceMeta{
 						MinorID: id,
 					},
-				}, info.Cores, info.Memory)
+				}
+				switch {
+				case info.BestEffort:
+					ta.tree.MarkFreeBestEffort(node, info.Cores, info.Memory)
+				case info.Guaranteed:
+					ta.tree.MarkFreeGuaranteed(node, info.Cores, info.Memory)
+				default:
+					ta.tree.MarkFree(node, info.Cores, info.Memory)
+				}
 			}
 
 			ta.responseManager.DeleteResp(uid, contName)
@@ -664,12 +813,6 @@ func (ta *NvidiaTopoAllocator) Allocate(_ context.Context, reqs *pluginapi.Alloc
 	ta.Lock()
 	defer ta.Unlock()
 
-	var (
-		reqCount           uint
-		candidatePod       *v1.Pod
-		candidateContainer *v1.Container
-		found              bool
-	)
 	if len(reqs.ContainerRequests) < 1 {
 		return nil, fmt.Errorf("empty container request")
 	}
@@ -677,73 +820,18 @@ func (ta *NvidiaTopoAllocator) Allocate(_ context.Context, reqs *pluginapi.Alloc
 	// k8s send allocate request for one container at a time
 	req := reqs.ContainerRequests[0]
 	resps := &pluginapi.AllocateResponse{}
-	reqCount = uint(len(req.DevicesIDs))
+	reqCount := uint(len(req.DevicesIDs))
 
 	klog.V(4).Infof("Request GPU device: %s", strings.Join(req.DevicesIDs, ","))
 
 	ta.recycle()
 
-	if ta.unfinishedPod != nil {
-		candidatePod = ta.unfinishedPod
-		cache := ta.allocatedPod.GetCache(string(candidatePod.UID))
-		if cache == nil {
-			msg := fmt.Sprintf("failed to find pod %s in cache", candidatePod.UID)
-			klog.Infof(msg)
-			return nil, fmt.Errorf(msg)
-		}
-		for i, c := range candidatePod.Spec.Containers {
-			if _, ok := cache[c.Name]; ok {
-				continue
-			}
-
-			if !utils.IsGPURequiredContainer(&c) {
-				continue
-			}
-
-			if reqCount != utils.GetGPUResourceOfContainer(&candidatePod.Spec.Containers[i], types.VCoreAnnotation) {
-				msg := fmt.Sprintf("allocation request mismatch for pod %s, reqs %v", candidatePod.UID, reqs)
-				klog.Infof(msg)
-				return nil, fmt.Errorf(msg)
-			}
-			candidateContainer = &candidatePod.Spec.Containers[i]
-			found = true
-			break
-		}
-	} else {
-		pods, err := getCandidatePods(ta.k8sClient, ta.config.Hostname)
-		if err != nil {
-			msg := fmt.Sprintf("Failed to find candidate pods due to %v", err)
-			klog.Infof(msg)
-			return nil, fmt.Errorf(msg)
-		}
-
-		for _, pod := range pods {
-			if found {
-				break
-			}
-			for i, c := range pod.Spec.Containers {
-				if !utils.IsGPURequiredContainer(&c) {
-					continue
-				}
-				podCache := ta.allocatedPod.GetCache(string(pod.UID))
-				if podCache != nil {
-					if _, ok := podCache[c.Name]; ok {
-						klog.Infof("container %s of pod %s has been allocate, continue to next", c.Name, pod.UID)
-						continue
-					}
-				}
-				if utils.GetGPUResourceOfContainer(&pod.Spec.Containers[i], types.VCoreAnnotation) == reqCount {
-					klog.Infof("Found candidate Pod %s(%s) with device count %d", pod.UID, c.Name, reqCount)
-					candidatePod = pod
-					candidateContainer = &pod.Spec.Containers[i]
-					found = true
-					break
-				}
-			}
-		}
+	candidatePod, candidateContainer, err := ta.waitCandidate(reqs, reqCount)
+	if err != nil {
+		return nil, err
 	}
 
-	if found {
+	if candidatePod != nil {
 		// get vmemory info from container spec
 		vmemory := utils.GetGPUResourceOfContainer(candidateContainer, types.VMemoryAnnotation)
 		for i := 0; i < int(vmemory); i++ {
@@ -774,23 +862,54 @@ func (ta *NvidiaTopoAllocator) ListAndWatch(e *pluginapi.Empty, s pluginapi.Devi
 
 //ListAndWatchWithResourceName send devices for request resource back to server
 func (ta *NvidiaTopoAllocator) ListAndWatchWithResourceName(resourceName string, e *pluginapi.Empty, s pluginapi.DevicePlugin_ListAndWatchServer) error {
-	devs := make([]*pluginapi.Device, 0)
-	for _, dev := range ta.capacity() {
-		if strings.HasPrefix(dev.ID, resourceName) {
-			devs = append(devs, dev)
-		}
+	devs, generation := ta.advertisedDevices(resourceName)
+	klog.V(2).Infof("ListAndWatch %s with %d devices", resourceName, len(devs))
+
+	if err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs}); err != nil {
+		return err
 	}
 
-	s.Send(&pluginapi.ListAndWatchResponse{Devices: devs})
+	// We don't send unhealthy state, devices are sent again only if they
+	// are rebuilt, until kubelet closes the stream or the allocator is stopped
+	ticker := time.NewTicker(devicesCheckPeriod)
+	defer ticker.Stop()
 
-	// We don't send unhealthy state
 	for {
-		time.Sleep(time.Second)
+		select {
+		case <-s.Context().Done():
+			klog.V(2).Infof("ListAndWatch %s exit", resourceName)
+			return nil
+		case <-ta.stopChan:
+			klog.V(2).Infof("ListAndWatch %s exit", resourceName)
+			return nil
+		case <-ticker.C:
+			next, nextGeneration := ta.advertisedDevices(resourceName)
+			if nextGeneration == generation {
+				continue
+			}
+
+			devs, generation = next, nextGeneration
+			klog.V(2).Infof("ListAndWatch %s with %d changed devices", resourceName, len(devs))
+			if err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs}); err != nil {
+				return err
+			}
+		}
 	}
+}
 
-	klog.V(2).Infof("ListAndWatch %s exit", resourceName)
+//Stop terminates background routines and writes down the checkpoint
+func (ta *NvidiaTopoAllocator) Stop() {
+	ta.stopOnce.Do(func() {
+		close(ta.stopChan)
+		ta.queue.ShutDown()
+		ta.recycleQueue.ShutDown()
 
-	return nil
+		ta.Lock()
+		defer ta.Unlock()
+
+		ta.writeCheckpoint()
+		klog.V(2).Infof("Allocator is stopped")
+	})
 }
 
 //GetDevicePluginOptions returns empty DevicePluginOptions
@@ -809,34 +928,36 @@ func (ta *NvidiaTopoAllocator) PreStartContainer(ctx context.Context, req *plugi
 		podUID        string
 		containerName string
 		vcore         int64
-		vmemory       int64
+		memory        int64
 		//devices       []string
 	)
 
 	// try to get podUID, containerName, vcore and vmemory from kubelet deviceplugin checkpoint file
-	cp, err := utils.GetCheckpointData(ta.config.DevicePluginPath)
+	coreEntry, err := ta.kubeletCheckpoint.FindByDevices(types.VCoreAnnotation, req.DevicesIDs)
 	if err != nil {
 		msg := fmt.Sprintf("%s, failed to read from checkpoint file due to %v",
 			types.PreStartContainerCheckErrMsg, err)
 		klog.Infof(msg)
 		return nil, fmt.Errorf(msg)
 	}
-	for _, entry := range cp.PodDeviceEntries {
-		if entry.ResourceName == types.VCoreAnnotation &&
-			utils.IsStringSliceEqual(req.DevicesIDs, entry.DeviceIDs) {
-			podUID = entry.PodUID
-			containerName = entry.ContainerName
-			vcore = int64(len(entry.DeviceIDs))
-			break
+
+	if coreEntry != nil {
+		podUID = coreEntry.PodUID
+		containerName = coreEntry.ContainerName
+		vcore = int64(len(coreEntry.DeviceIDs))
+
+		memoryEntry, err := ta.kubeletCheckpoint.FindByContainer(podUID, containerName, types.VMemoryAnnotation)
+		if err != nil {
+			msg := fmt.Sprintf("%s, failed to read from checkpoint file due to %v",
+				types.PreStartContainerCheckErrMsg, err)
+			klog.Infof(msg)
+			return nil, fmt.Errorf(msg)
 		}
-	}
 
-	for _, entry := range cp.PodDeviceEntries {
-		if entry.PodUID == podUID &&
-			entry.ContainerName == containerName &&
-			entry.ResourceName == types.VMemoryAnnotation {
-			vmemory = int64(len(entry.DeviceIDs))
-			break
+		if memoryEntry != nil {
+			for _, id := range memoryEntry.DeviceIDs {
+				memory += memoryBlockOfDevice(id, ta.config.GetMemoryBlockSize())
+			}
 		}
 	}
 
@@ -846,14 +967,14 @@ func (ta *NvidiaTopoAllocator) PreStartContainer(ctx context.Context, req *plugi
 		klog.Infof(msg)
 		return nil, fmt.Errorf(msg)
 	}
-	pod, ok := watchdog.GetActivePods()[podUID]
+	pod, ok := ta.podLister.GetActivePods()[podUID]
 	if !ok {
 		msg := fmt.Sprintf("%s, failed to get pod %s in watchdog", types.PreStartContainerCheckErrMsg, podUID)
 		klog.Infof(msg)
 		return nil, fmt.Errorf(msg)
 	}
 
-	err = ta.preStartContainerCheck(podUID, containerName, vcore, vmemory)
+	err = ta.preStartContainerCheck(podUID, containerName, vcore, memory)
 	if err != nil {
 		klog.Infof(err.Error())
 		ta.queue.AddRateLimited(&allocateResult{
@@ -885,7 +1006,7 @@ func (ta *NvidiaTopoAllocator) PreStartContainer(ctx context.Context, req *plugi
 	return &pluginapi.PreStartContainerResponse{}, nil
 }
 
-func (ta *NvidiaTopoAllocator) preStartContainerCheck(podUID string, containerName string, vcore int64, vmemory int64) error {
+func (ta *NvidiaTopoAllocator) preStartContainerCheck(podUID string, containerName string, vcore int64, memory int64) error {
 	cache := ta.allocatedPod.GetCache(podUID)
 	if cache == nil {
 		msg := fmt.Sprintf("%s, failed to get pod %s from allocatedPod cache",
@@ -899,10 +1020,10 @@ func (ta *NvidiaTopoAllocator) preStartContainerCheck(podUID string, containerNa
 			types.PreStartContainerCheckErrMsg, containerName, podUID)
 		klog.Infof(msg)
 		return fmt.Errorf(msg)
-	} else if c.Memory != vmemory*types.MemoryBlockSize || c.Cores != vcore {
+	} else if c.Memory != memory || c.Cores != vcore {
 		// request and cache mismatch, evict the pod
 		msg := fmt.Sprintf("%s, pod %s container %s requset mismatch from cache. req: vcore %d vmemory %d; cache: vcore %d vmemory %d",
-			types.PreStartContainerCheckErrMsg, podUID, containerName, vcore, vmemory*types.MemoryBlockSize, c.Cores, c.Memory)
+			types.PreStartContainerCheckErrMsg, podUID, containerName, vcore, memory, c.Cores, c.Memory)
 		klog.Infof(msg)
 		return fmt.Errorf(msg)
 	} else {
@@ -1024,6 +1145,9 @@ func (ta *NvidiaTopoAllocator) getReadyAnnotations(pod *v1.Pod, assigned bool) (
 		}
 		predicateIndexStr := strings.Join(devices, ",")
 		annotationMap[types.PredicateGPUIndexPrefix+strconv.Itoa(i)] = predicateIndexStr
+		// Memory is recorded in bytes, scheduler extender and display don't
+		// depend on vmemory block size of the node to get it
+		annotationMap[types.VMemoryAllocatedPrefix+strconv.Itoa(i)] = strconv.FormatInt(containerCache.Memory, 10)
 	}
 	annotationMap[types.GPUAssigned] = strconv.FormatBool(assigned)
 
@@ -1127,6 +1251,23 @@ func patchPodWithAnnotations(client kubernetes.Interface, pod *v1.Pod, annotatio
 	return nil
 }
 
+//memoryBlockOfDevice returns the block size of vmemory device, the id is
+//in the format of tencent.com/vcuda-memory-<size>-<index>, so devices
+//advertised before the block size is changed keep their size
+func memoryBlockOfDevice(id string, defaultSize int64) int64 {
+	parts := strings.Split(strings.TrimPrefix(id, types.VMemoryAnnotation+"-"), "-")
+	if len(parts) != 2 {
+		return defaultSize
+	}
+
+	size, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || size <= 0 {
+		return defaultSize
+	}
+
+	return size
+}
+
 func vDeviceAnnotationStr(nodes []*nvtree.NvidiaNode) string {
 	str := make([]string, 0)
 	for _, node := range nodes {
@@ -1136,9 +1277,148 @@ func vDeviceAnnotationStr(nodes []*nvtree.NvidiaNode) string {
 	return strings.Join(str, ",")
 }
 
-func getCandidatePods(client kubernetes.Interface, hostname string) ([]*v1.Pod, error) {
+func memoryOversubscribed(nodes []*nvtree.NvidiaNode) bool {
+	for _, n := range nodes {
+		if n.MemoryOversubscribed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+//waitCandidate finds the candidate container of request, it must be called
+//with ta locked. Kubelet may send the request before informer sees the pod,
+//so it waits for pod changes up to CandidateWaitTimeout with ta unlocked,
+//and looks up the candidate again after ta is locked. Nil is returned if the
+//candidate is not found.
+func (ta *NvidiaTopoAllocator) waitCandidate(reqs *pluginapi.AllocateRequest, reqCount uint) (*v1.Pod, *v1.Container, error) {
+	deadline := time.Now().Add(ta.config.CandidateWaitTimeout)
+
+	for waiting := false; ; waiting = true {
+		// Take the channel before looking up, so changes in between are not missed
+		changed := ta.podsChangedChan()
+
+		if ta.unfinishedPod != nil {
+			return ta.unfinishedCandidate(reqs, reqCount)
+		}
+
+		pod, container, err := ta.findCandidate(reqCount)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to find candidate pods due to %v", err)
+			klog.Infof(msg)
+			return nil, nil, fmt.Errorf(msg)
+		}
+
+		remaining := time.Until(deadline)
+		if pod != nil || remaining <= 0 {
+			return pod, container, nil
+		}
+
+		if !waiting {
+			klog.V(2).Infof("Candidate pod of request with device count %d is not found, wait for informer", reqCount)
+		}
+
+		ta.Unlock()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ta.stopChan:
+		}
+		timer.Stop()
+		ta.Lock()
+
+		select {
+		case <-ta.stopChan:
+			return nil, nil, fmt.Errorf("allocator is stopped")
+		default:
+		}
+	}
+}
+
+//unfinishedCandidate returns the next unallocated GPU container of
+//unfinishedPod, all containers of a pod are allocated before other pods
+func (ta *NvidiaTopoAllocator) unfinishedCandidate(reqs *pluginapi.AllocateRequest, reqCount uint) (*v1.Pod, *v1.Container, error) {
+	pod := ta.unfinishedPod
+	cache := ta.allocatedPod.GetCache(string(pod.UID))
+	if cache == nil {
+		msg := fmt.Sprintf("failed to find pod %s in cache", pod.UID)
+		klog.Infof(msg)
+		return nil, nil, fmt.Errorf(msg)
+	}
+
+	for i, c := range pod.Spec.Containers {
+		if _, ok := cache[c.Name]; ok {
+			continue
+		}
+
+		if !utils.IsGPURequiredContainer(&c) {
+			continue
+		}
+
+		if reqCount != utils.GetGPUResourceOfContainer(&pod.Spec.Containers[i], types.VCoreAnnotation) {
+			msg := fmt.Sprintf("allocation request mismatch for pod %s, reqs %v", pod.UID, reqs)
+			klog.Infof(msg)
+			return nil, nil, fmt.Errorf(msg)
+		}
+
+		return pod, &pod.Spec.Containers[i], nil
+	}
+
+	return nil, nil, nil
+}
+
+//onPodChanged wakes up Allocate waiting for candidate pod
+func (ta *NvidiaTopoAllocator) onPodChanged(_ *v1.Pod) {
+	ta.podsChangedLock.Lock()
+	defer ta.podsChangedLock.Unlock()
+
+	close(ta.podsChanged)
+	ta.podsChanged = make(chan struct{})
+}
+
+//podsChangedChan returns the channel closed on next pod change
+func (ta *NvidiaTopoAllocator) podsChangedChan() <-chan struct{} {
+	ta.podsChangedLock.Lock()
+	defer ta.podsChangedLock.Unlock()
+
+	return ta.podsChanged
+}
+
+//findCandidate returns the earliest predicated pod which has an unallocated
+//container requesting reqCount cores
+func (ta *NvidiaTopoAllocator) findCandidate(reqCount uint) (*v1.Pod, *v1.Container, error) {
+	pods, err := ta.getCandidatePods()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pod := range pods {
+		podCache := ta.allocatedPod.GetCache(string(pod.UID))
+		for i, c := range pod.Spec.Containers {
+			if !utils.IsGPURequiredContainer(&c) {
+				continue
+			}
+			if podCache != nil {
+				if _, ok := podCache[c.Name]; ok {
+					klog.Infof("container %s of pod %s has been allocate, continue to next", c.Name, pod.UID)
+					continue
+				}
+			}
+			if utils.GetGPUResourceOfContainer(&pod.Spec.Containers[i], types.VCoreAnnotation) == reqCount {
+				klog.Infof("Found candidate Pod %s(%s) with device count %d", pod.UID, c.Name, reqCount)
+				return pod, &pod.Spec.Containers[i], nil
+			}
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func (ta *NvidiaTopoAllocator) getCandidatePods() ([]*v1.Pod, error) {
 	candidatePods := []*v1.Pod{}
-	allPods, err := getPodsOnNode(client, hostname, string(v1.PodPending))
+	allPods, err := ta.getPodsOnNode(v1.PodPending)
 	if err != nil {
 		return candidatePods, err
 	}
@@ -1161,42 +1441,18 @@ func getCandidatePods(client kubernetes.Interface, hostname string) ([]*v1.Pod,
 	return OrderPodsdByPredicateTime(candidatePods), nil
 }
 
-func getPodsOnNode(client kubernetes.Interface, hostname string, phase string) ([]v1.Pod, error) {
-	if len(hostname) == 0 {
-		hostname, _ = os.Hostname()
-	}
-	var (
-		selector fields.Selector
-		pods     []v1.Pod
-	)
-
-	if phase != "" {
-		selector = fields.SelectorFromSet(fields.Set{"spec.nodeName": hostname, "status.phase": phase})
-	} else {
-		selector = fields.SelectorFromSet(fields.Set{"spec.nodeName": hostname})
-	}
-	var (
-		podList *v1.PodList
-		err     error
-	)
-
-	err = wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
-		podList, err = client.CoreV1().Pods(v1.NamespaceAll).List(metav1.ListOptions{
-			FieldSelector: selector.String(),
-			LabelSelector: labels.Everything().String(),
-		})
-		if err != nil {
-			return false, err
-		}
-		return true, nil
-	})
+//getPodsOnNode returns copies of pods in podLister, so callers can
+//modify them without touching the informer
+func (ta *NvidiaTopoAllocator) getPodsOnNode(phase v1.PodPhase) ([]v1.Pod, error) {
+	cachedPods, err := ta.podLister.ListPods(phase)
 	if err != nil {
-		return pods, fmt.Errorf("failed to get Pods on node %s because: %v", hostname, err)
+		return nil, fmt.Errorf("failed to get Pods on node because: %v", err)
 	}
 
-	klog.V(9).Infof("all pods on this node: %v", podList.Items)
-	for _, pod := range podList.Items {
-		pods = append(pods, pod)
+	klog.V(9).Infof("all pods on this node: %v", cachedPods)
+	pods := make([]v1.Pod, 0, len(cachedPods))
+	for _, pod := range cachedPods {
+		pods = append(pods, *pod.DeepCopy())
 	}
 
 	return pods, nil
@@ -1236,9 +1492,47 @@ func (ta *NvidiaTopoAllocator) readCheckpoint() {
 	if err != nil {
 		klog.Warningf("Failed to unmarshal data from checkpoint due to %s", err.Error())
 	}
+
+	blockSize := ta.config.GetMemoryBlockSize()
+	if old := ta.allocatedPod.MemoryBlockSize; old > 0 && old != blockSize {
+		klog.Infof("Memory block size is changed from %d to %d, reconcile allocated memory of %d pods",
+			old, blockSize, len(ta.allocatedPod.PodGPUMapping))
+		ta.reconcileMemory(old)
+	}
+}
+
+//reconcileMemory recomputes allocated memory in cache from vmemory devices
+//kubelet assigned to containers, devices advertised before block size is
+//changed have their size in ID, others have oldBlockSize
+func (ta *NvidiaTopoAllocator) reconcileMemory(oldBlockSize int64) {
+	for podUID, containers := range ta.allocatedPod.PodGPUMapping {
+		for name, info := range containers {
+			entry, err := ta.kubeletCheckpoint.FindByContainer(podUID, name, types.VMemoryAnnotation)
+			if err != nil {
+				klog.Warningf("Can't read device plugin checkpoint, allocated memory is not reconciled, %v", err)
+				return
+			}
+
+			if entry == nil {
+				continue
+			}
+
+			var memory int64
+			for _, id := range entry.DeviceIDs {
+				memory += memoryBlockOfDevice(id, oldBlockSize)
+			}
+
+			if memory != info.Memory {
+				klog.Warningf("Allocated memory of container %s of pod %s is changed from %d to %d",
+					name, podUID, info.Memory, memory)
+				info.Memory = memory
+			}
+		}
+	}
 }
 
 func (ta *NvidiaTopoAllocator) writeCheckpoint() {
+	ta.allocatedPod.MemoryBlockSize = ta.config.GetMemoryBlockSize()
 	data, err := json.Marshal(ta.allocatedPod)
 	if err != nil {
 		klog.Warningf("Failed to marshal allocatedPod due to %s", err.Error())
@@ -21,7 +21,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,10 +40,12 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 )
 
@@ -86,7 +92,8 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 
 	//init allocator
 	k8sClient := fake.NewSimpleClientset()
-	alloc := initAllocator(tree, k8sClient)
+	podLister := watchdog.NewFakePodLister()
+	alloc := initAllocator(tree, k8sClient, podLister)
 	alloc.initEvaluator(tree)
 
 	testCase := []struct {
@@ -175,6 +182,7 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 		}
 		if podCache.GetCache(string(pod.UID)) == nil {
 			k8sClient.CoreV1().Pods("test-ns").Create(pod)
+			podLister.Add(pod)
 			podCache.Insert(string(pod.UID), testCase.PodName, &cache.Info{
 				Devices: []string{testCase.Device},
 				Cores:   100,
@@ -183,7 +191,6 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 		}
 	}
 
-	watchdog.NewPodCacheForTest(k8sClient)
 	data, err := json.Marshal(podCache)
 	if err != nil {
 		t.Errorf("Failed to marshal allocatedPod due to %v", err)
@@ -245,8 +252,8 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 
 	//init allocator k8sclient and watchdog
 	k8sClient := fake.NewSimpleClientset()
-	watchdog.NewPodCacheForTest(k8sClient)
-	alloc := initAllocator(tree, k8sClient)
+	podLister := newPodLister(k8sClient)
+	alloc := initAllocator(tree, k8sClient, podLister)
 	alloc.initEvaluator(tree)
 
 	//create and allocate pod1
@@ -456,8 +463,8 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 
 	//init allocator k8sclient and watchdog
 	k8sClient := fake.NewSimpleClientset()
-	watchdog.NewPodCacheForTest(k8sClient)
-	alloc := initAllocator(tree, k8sClient)
+	podLister := newPodLister(k8sClient)
+	alloc := initAllocator(tree, k8sClient, podLister)
 	alloc.initEvaluator(tree)
 
 	//create and allocate pod1
@@ -520,8 +527,8 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 
 	//init allocator k8sclient and watchdog
 	k8sClient := fake.NewSimpleClientset()
-	watchdog.NewPodCacheForTest(k8sClient)
-	alloc := initAllocator(tree, k8sClient)
+	podLister := newPodLister(k8sClient)
+	alloc := initAllocator(tree, k8sClient, podLister)
 	alloc.initEvaluator(tree)
 
 	//create and allocate pod1
@@ -648,8 +655,8 @@ GPU5     SOC     SOC     SOC     SOC     PIX      X
 
 	//init allocator k8sclient and watchdog
 	k8sClient := fake.NewSimpleClientset()
-	watchdog.NewPodCacheForTest(k8sClient)
-	alloc := initAllocator(tree, k8sClient)
+	podLister := newPodLister(k8sClient)
+	alloc := initAllocator(tree, k8sClient, podLister)
 	alloc.initEvaluator(tree)
 
 	//create and allocate pod1
@@ -847,7 +854,36 @@ func prepareContainerAllocateRequest(cores int, memory int) (req pluginapi.Conta
 	return req
 }
 
-func initAllocator(tree *nvidia.NvidiaTree, client kubernetes.Interface) *NvidiaTopoAllocator {
+//newPodLister returns a FakePodLister which sees pods written to client
+//without the delay of an informer
+func newPodLister(client *fake.Clientset) *watchdog.FakePodLister {
+	podLister := watchdog.NewFakePodLister()
+	react := k8stesting.ObjectReaction(client.Tracker())
+	client.PrependReactor("*", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		handled, obj, err := react(action)
+		if err != nil {
+			return handled, obj, err
+		}
+
+		switch action.GetVerb() {
+		case "create", "update", "patch":
+			if pod, ok := obj.(*v1.Pod); ok {
+				podLister.Add(pod.DeepCopy())
+			}
+		case "delete":
+			podLister.Delete(&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: action.GetNamespace(),
+				Name:      action.(k8stesting.DeleteAction).GetName(),
+			}})
+		}
+
+		return handled, obj, err
+	})
+
+	return podLister
+}
+
+func initAllocator(tree *nvidia.NvidiaTree, client kubernetes.Interface, podLister watchdog.PodLister) *NvidiaTopoAllocator {
 	cfg := &config.Config{
 		EnableShare:           true,
 		VCudaRequestsQueue:    make(chan *types.VCudaRequest, 10),
@@ -859,6 +895,333 @@ func initAllocator(tree *nvidia.NvidiaTree, client kubernetes.Interface) *Nvidia
 		}
 	}(cfg)
 
-	alloc := NewNvidiaTopoAllocatorForTest(cfg, tree, client, response.NewFakeResponseManager())
+	alloc := NewNvidiaTopoAllocatorForTest(cfg, tree, client, response.NewFakeResponseManager(), podLister)
 	return alloc.(*NvidiaTopoAllocator)
 }
+
+func TestAllocateOneHeterogeneous(t *testing.T) {
+	flag.Parse()
+	//init tree with a T4 and a V100
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+	models := []string{"Tesla T4", "Tesla V100-PCIE-16GB"}
+	for i, n := range tree.Leaves() {
+		n.Meta.Model = models[i]
+		n.Meta.TotalMemory = uint64(1024 * 1024 * 1024 * (1 + 3*i))
+		n.AllocatableMeta.Cores = nvidia.HundredCore
+		n.AllocatableMeta.Memory = int64(n.Meta.TotalMemory)
+	}
+
+	k8sClient := fake.NewSimpleClientset()
+	alloc := initAllocator(tree, k8sClient, watchdog.NewFakePodLister())
+	alloc.initEvaluator(tree)
+
+	allocate := func(uid, model string, memory int, idx string) (*pluginapi.ContainerAllocateResponse, error) {
+		pod := createPod(k8sClient, podRawInfo{
+			Name:       "pod-" + uid,
+			UID:        uid,
+			Containers: []containerRawInfo{{Name: "container-0", Cores: 10, Memory: memory, PredicateIndexes: idx}},
+		})
+		if len(model) > 0 {
+			pod.Annotations[types.GPUModelAnnotation] = model
+		}
+
+		req := prepareContainerAllocateRequest(10, memory)
+		return alloc.allocateOne(pod, &pod.Spec.Containers[0], &req)
+	}
+
+	// 2GiB only fits in V100
+	resp, err := allocate("uid-1", "", 8, "1")
+	if err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+	if resp.Devices[0].HostPath != "/dev/nvidia1" {
+		t.Fatalf("expect /dev/nvidia1, got %s", resp.Devices[0].HostPath)
+	}
+
+	resp, err = allocate("uid-2", "T4", 2, "0")
+	if err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+	if resp.Devices[0].HostPath != "/dev/nvidia0" {
+		t.Fatalf("expect /dev/nvidia0, got %s", resp.Devices[0].HostPath)
+	}
+
+	// memory is checked against T4, not the first card
+	if _, err = allocate("uid-3", "t4", 8, "0"); err == nil || !strings.Contains(err.Error(), "larger than 1073741824") {
+		t.Fatalf("expect memory error, got %v", err)
+	}
+
+	if _, err = allocate("uid-4", "a100", 1, "0"); err == nil || !strings.Contains(err.Error(), "no gpu of model a100") {
+		t.Fatalf("expect model error, got %v", err)
+	}
+}
+
+func TestMemoryBlockSize(t *testing.T) {
+	flag.Parse()
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = 4 << 30
+	}
+
+	blockSize := int64(1 << 30)
+	alloc := &NvidiaTopoAllocator{
+		tree:   tree,
+		config: &config.Config{MemoryBlockSize: blockSize},
+	}
+
+	memoryBlocks := 0
+	for _, dev := range alloc.capacity() {
+		if strings.HasPrefix(dev.ID, types.VMemoryAnnotation) {
+			memoryBlocks++
+			if memoryBlockOfDevice(dev.ID, types.MemoryBlockSize) != blockSize {
+				t.Fatalf("device %s should have block size %d", dev.ID, blockSize)
+			}
+		}
+	}
+
+	if memoryBlocks != 8 {
+		t.Fatalf("expect 8 memory blocks, got %d", memoryBlocks)
+	}
+
+	vcores, _ := alloc.advertisedDevices(types.VCoreAnnotation)
+	vmemory, generation := alloc.advertisedDevices(types.VMemoryAnnotation)
+	if len(vcores) != 2*nvidia.HundredCore || len(vmemory) != 8 {
+		t.Fatalf("expect %d vcore and 8 vmemory devices, got %d and %d", 2*nvidia.HundredCore, len(vcores), len(vmemory))
+	}
+
+	if again, g := alloc.advertisedDevices(types.VMemoryAnnotation); &again[0] != &vmemory[0] || g != generation {
+		t.Fatalf("advertised devices should be reused")
+	}
+
+	// devices are rebuilt after logical memory or block size changes
+	tree.Leaves()[0].MemoryRatio = 2
+	if again, g := alloc.advertisedDevices(types.VMemoryAnnotation); len(again) != 12 || g == generation {
+		t.Fatalf("expect 12 vmemory devices of a new generation, got %d of %d", len(again), g)
+	}
+
+	alloc.config.MemoryBlockSize = 2 << 30
+	if again, _ := alloc.advertisedDevices(types.VMemoryAnnotation); len(again) != 6 {
+		t.Fatalf("expect 6 vmemory devices, got %d", len(again))
+	}
+	alloc.config.MemoryBlockSize = blockSize
+	tree.Leaves()[0].MemoryRatio = 0
+
+	// devices advertised with the old block size keep their size
+	oldID := fmt.Sprintf("%s-%d-%d", types.VMemoryAnnotation, types.MemoryBlockSize, 3)
+	if size := memoryBlockOfDevice(oldID, blockSize); size != types.MemoryBlockSize {
+		t.Fatalf("expect block size %d of %s, got %d", types.MemoryBlockSize, oldID, size)
+	}
+
+	if size := memoryBlockOfDevice(types.VMemoryAnnotation, blockSize); size != blockSize {
+		t.Fatalf("expect default block size %d, got %d", blockSize, size)
+	}
+}
+
+func TestReconcileMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// devices of old block size are assigned to the pod by kubelet
+	oldBlockSize := int64(types.MemoryBlockSize)
+	data, _ := json.Marshal(&types.CheckpointData{
+		Data: &types.Checkpoint{PodDeviceEntries: []types.PodDevicesEntry{{
+			PodUID:        "pod1",
+			ContainerName: "c",
+			ResourceName:  types.VMemoryAnnotation,
+			DeviceIDs: []string{
+				fmt.Sprintf("%s-%d-%d", types.VMemoryAnnotation, oldBlockSize, 0),
+				fmt.Sprintf("%s-%d-%d", types.VMemoryAnnotation, oldBlockSize, 1),
+			},
+		}}},
+	})
+	if err := ioutil.WriteFile(filepath.Join(dir, types.CheckPointFileName), data, 0644); err != nil {
+		t.Fatalf("can't write checkpoint: %v", err)
+	}
+
+	allocatedPod := cache.NewAllocateCache()
+	allocatedPod.MemoryBlockSize = oldBlockSize
+	allocatedPod.Insert("pod1", "c", &cache.Info{Cores: 50, Memory: 2 << 30})
+	allocatedPod.Insert("pod2", "c", &cache.Info{Cores: 50, Memory: 1 << 30})
+
+	alloc := &NvidiaTopoAllocator{
+		config:            &config.Config{MemoryBlockSize: 1 << 30},
+		allocatedPod:      allocatedPod,
+		kubeletCheckpoint: utils.NewCheckpointIndex(dir),
+	}
+	alloc.reconcileMemory(oldBlockSize)
+
+	if memory := allocatedPod.GetCache("pod1")["c"].Memory; memory != 2*oldBlockSize {
+		t.Fatalf("memory should be reconciled to %d, got %d", 2*oldBlockSize, memory)
+	}
+
+	if memory := allocatedPod.GetCache("pod2")["c"].Memory; memory != 1<<30 {
+		t.Fatalf("memory of container not in checkpoint should be kept, got %d", memory)
+	}
+}
+
+func TestRecycleOnPodTermination(t *testing.T) {
+	flag.Parse()
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.AllocatableMeta.Cores = nvidia.HundredCore
+		n.AllocatableMeta.Memory = 1024 * 1024 * 1024
+		n.Meta.TotalMemory = 1024 * 1024 * 1024
+	}
+
+	k8sClient := fake.NewSimpleClientset()
+	podLister := newPodLister(k8sClient)
+	alloc := initAllocator(tree, k8sClient, podLister)
+	alloc.initEvaluator(tree)
+	alloc.config.RecycleGracePeriod = 500 * time.Millisecond
+
+	raws := []podRawInfo{
+		{
+			Name:       "pod-1",
+			UID:        "uid-1",
+			Containers: []containerRawInfo{{Name: "container-0", Cores: 100, Memory: 1, PredicateIndexes: "0"}},
+		},
+		{
+			Name:       "pod-2",
+			UID:        "uid-2",
+			Containers: []containerRawInfo{{Name: "container-0", Cores: 100, Memory: 1, PredicateIndexes: "1"}},
+		},
+	}
+	for _, raw := range raws {
+		if _, err := createAndAllocate(alloc, k8sClient, raw); err != nil {
+			t.Fatalf("failed to allocate for pod %s: %v", raw.Name, err)
+		}
+	}
+	if tree.Available() != 0 {
+		t.Fatalf("expect no available card, got %d", tree.Available())
+	}
+
+	// candidates come from the informer cache
+	candidates, err := alloc.getCandidatePods()
+	if err != nil || len(candidates) != 0 {
+		t.Fatalf("expect no candidate pod, got %v, %v", candidates, err)
+	}
+
+	isRecycled := func(uid string) bool {
+		alloc.Lock()
+		defer alloc.Unlock()
+		return alloc.allocatedPod.GetCache(uid) == nil
+	}
+
+	// no Allocate or recycle call is needed to free the cards
+	pod, _ := k8sClient.CoreV1().Pods("test-ns").Get(raws[0].Name, metav1.GetOptions{})
+	pod.Status.Phase = v1.PodSucceeded
+	k8sClient.CoreV1().Pods("test-ns").UpdateStatus(pod)
+	k8sClient.CoreV1().Pods("test-ns").Delete(raws[1].Name, &metav1.DeleteOptions{})
+
+	time.Sleep(200 * time.Millisecond)
+	if isRecycled(raws[0].UID) || isRecycled(raws[1].UID) {
+		t.Fatalf("pods are recycled in grace period")
+	}
+
+	for _, raw := range raws {
+		err = wait.PollImmediate(100*time.Millisecond, 5*time.Second, func() (bool, error) {
+			return isRecycled(raw.UID), nil
+		})
+		if err != nil {
+			t.Fatalf("pod %s is not recycled after termination", raw.Name)
+		}
+		if alloc.responseManager.GetResp(raw.UID, raw.Containers[0].Name) != nil {
+			t.Fatalf("response of pod %s is not deleted", raw.Name)
+		}
+	}
+
+	if tree.Available() != 2 {
+		t.Fatalf("expect 2 available cards, got %d", tree.Available())
+	}
+}
+
+func TestAllocateWaitForCandidate(t *testing.T) {
+	flag.Parse()
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.AllocatableMeta.Cores = nvidia.HundredCore
+		n.AllocatableMeta.Memory = 1024 * 1024 * 1024
+		n.Meta.TotalMemory = 1024 * 1024 * 1024
+	}
+
+	k8sClient := fake.NewSimpleClientset()
+	podLister := watchdog.NewFakePodLister()
+	alloc := initAllocator(tree, k8sClient, podLister)
+	alloc.initEvaluator(tree)
+	alloc.config.CandidateWaitTimeout = 5 * time.Second
+
+	raw := podRawInfo{
+		Name: "late",
+		UID:  "late-uid",
+		Containers: []containerRawInfo{
+			{Name: "container-0", Cores: 50, Memory: 1, PredicateIndexes: "0"},
+		},
+	}
+	pod := createPod(k8sClient, raw)
+
+	// informer sees the pod after kubelet sends the request, the allocator
+	// is not locked while waiting
+	locked := make(chan bool, 1)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		acquired := make(chan struct{})
+		go func() {
+			alloc.Lock()
+			alloc.Unlock()
+			close(acquired)
+		}()
+		select {
+		case <-acquired:
+			locked <- false
+		case <-time.After(time.Second):
+			locked <- true
+		}
+		podLister.Add(pod)
+	}()
+
+	req := prepareContainerAllocateRequest(50, 0)
+	start := time.Now()
+	resp, err := alloc.Allocate(nil, &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{&req},
+	})
+	if err != nil || len(resp.ContainerResponses) != 1 {
+		t.Fatalf("expect allocation after the pod appears, got %+v, %v", resp, err)
+	}
+	if <-locked {
+		t.Fatalf("allocator should be unlocked while waiting for candidate")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("wait should end once the pod appears, took %v", elapsed)
+	}
+
+	if cache := alloc.allocatedPod.GetCache(raw.UID); cache == nil || cache["container-0"] == nil {
+		t.Fatalf("pod %s should be allocated", raw.UID)
+	}
+
+	// the wait is bounded by its own timeout instead of request timeout
+	alloc.config.RequestTimeout = 5 * time.Second
+	alloc.config.CandidateWaitTimeout = 300 * time.Millisecond
+	req = prepareContainerAllocateRequest(30, 0)
+	start = time.Now()
+	if _, err := alloc.Allocate(nil, &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{&req},
+	}); err == nil {
+		t.Fatalf("expect error without candidate pod")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("wait should be bounded by candidate wait timeout, took %v", elapsed)
+	}
+}
@@ -23,5 +23,5 @@ import (
 
 //Evaluator api for schedule algorithm
 type Evaluator interface {
-	Evaluate(cores int64, memory int64) []*node.NvidiaNode
+	Evaluate(cores int64, memory int64, filter node.NodeFilter) []*node.NvidiaNode
 }
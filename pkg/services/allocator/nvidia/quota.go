@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"fmt"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+//namespaceUsage is GPU allocated to pods of a namespace on this node
+type namespaceUsage struct {
+	cores  int64
+	memory int64
+	//cardCores is vcore on each card by device name
+	cardCores map[string]int64
+}
+
+//quotaOf returns GPU quota of namespace in the extra config, the quota may
+//be changed when the extra config is reloaded
+func (ta *NvidiaTopoAllocator) quotaOf(namespace string) *config.GPUQuota {
+	cfg, _ := ta.config.ExtraConfig.Get("default")
+	return cfg.QuotaOf(namespace)
+}
+
+func (ta *NvidiaTopoAllocator) usageOfNamespace(namespace string) *namespaceUsage {
+	usage := &namespaceUsage{
+		cardCores: make(map[string]int64),
+	}
+
+	activePods := ta.podLister.GetActivePods()
+	for uid, containers := range ta.allocatedPod.PodGPUMapping {
+		pod, ok := activePods[uid]
+		if !ok || pod.Namespace != namespace {
+			continue
+		}
+
+		for _, info := range containers {
+			usage.cores += info.Cores
+			usage.memory += info.Memory
+
+			// cards of a multi-card container are used exclusively
+			cores := info.Cores
+			if len(info.Devices) > 1 {
+				cores = nvtree.HundredCore
+			}
+			for _, dev := range info.Devices {
+				usage.cardCores[dev] += cores
+			}
+		}
+	}
+
+	return usage
+}
+
+//quotaFilter checks the request of pod against quota of its namespace, and
+//returns a NodeFilter which accepts cards within the quota. The filter is
+//nil if the quota doesn't limit cards.
+// #lizard forgives
+func (ta *NvidiaTopoAllocator) quotaFilter(pod *v1.Pod, needCores, needMemory int64, shareMode bool) (nvtree.NodeFilter, error) {
+	quota := ta.quotaOf(pod.Namespace)
+	if quota == nil {
+		return nil, nil
+	}
+
+	usage := ta.usageOfNamespace(pod.Namespace)
+	klog.V(4).Infof("GPU usage of namespace %s: vcore %d, memory %d, cards %v",
+		pod.Namespace, usage.cores, usage.memory, usage.cardCores)
+
+	cardCores, newCards := needCores, 1
+	if !shareMode {
+		cardCores, newCards = nvtree.HundredCore, int(needCores/nvtree.HundredCore)
+	}
+
+	if quota.CoresPerCard > 0 && cardCores > quota.CoresPerCard {
+		return nil, fmt.Errorf("requesting %d vcore of a card exceeds the limit %d of namespace %s",
+			cardCores, quota.CoresPerCard, pod.Namespace)
+	}
+
+	if quota.Cores > 0 && usage.cores+needCores > quota.Cores {
+		return nil, fmt.Errorf("namespace %s uses %d vcore, requesting %d exceeds the limit %d",
+			pod.Namespace, usage.cores, needCores, quota.Cores)
+	}
+
+	if limit := quota.MemoryLimit(); limit > 0 && usage.memory+needMemory > limit {
+		return nil, fmt.Errorf("namespace %s uses %d bytes of GPU memory, requesting %d exceeds the limit %d",
+			pod.Namespace, usage.memory, needMemory, limit)
+	}
+
+	// cards of exclusive mode are always free, so they are new to namespace
+	if quota.Cards > 0 && !shareMode && len(usage.cardCores)+newCards > quota.Cards {
+		return nil, fmt.Errorf("namespace %s uses %d cards, requesting %d exceeds the limit %d",
+			pod.Namespace, len(usage.cardCores), newCards, quota.Cards)
+	}
+
+	usedCardsOnly := quota.Cards > 0 && len(usage.cardCores) >= quota.Cards
+	if !usedCardsOnly && quota.CoresPerCard == 0 {
+		return nil, nil
+	}
+
+	return func(n *nvtree.NvidiaNode) bool {
+		used, ok := usage.cardCores[n.MinorName()]
+		if usedCardsOnly && !ok {
+			return false
+		}
+
+		return quota.CoresPerCard == 0 || used+cardCores <= quota.CoresPerCard
+	}, nil
+}
+
+//evaluate runs eval with both filter and quotaFilter, exceeded is true if no
+//card is chosen only because of quotaFilter
+func evaluate(eval Evaluator, cores, memory int64, filter, quotaFilter nvtree.NodeFilter) (nodes []*nvtree.NvidiaNode, exceeded bool) {
+	nodes = eval.Evaluate(cores, memory, nvtree.AllFilters(filter, quotaFilter))
+	if len(nodes) == 0 && quotaFilter != nil {
+		exceeded = len(eval.Evaluate(cores, memory, filter)) > 0
+	}
+
+	return nodes, exceeded
+}
+
+//rejectByQuota fails pod through the ALLOCATE_FAIL path, so the reason is
+//shown in status of pod
+func (ta *NvidiaTopoAllocator) rejectByQuota(pod *v1.Pod, err error) error {
+	msg := fmt.Sprintf("%s, %v", types.GPUQuotaExceededErrMsg, err)
+	klog.Infof("Reject pod %s(%s), %s", pod.Name, pod.UID, msg)
+	ta.queue.AddRateLimited(&allocateResult{
+		pod:     pod,
+		result:  ALLOCATE_FAIL,
+		message: msg,
+		reason:  types.GPUQuotaExceededErrType,
+	})
+
+	return fmt.Errorf(msg)
+}
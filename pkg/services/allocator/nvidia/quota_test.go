@@ -0,0 +1,129 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nvidia
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceQuota(t *testing.T) {
+	obj := nvidia.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvidia.NvidiaTree)
+	tree.Init("\tGPU0\tGPU1\tGPU2\tGPU3\nGPU0\tX\tPIX\tPIX\tPIX\nGPU1\tPIX\tX\tPIX\tPIX\nGPU2\tPIX\tPIX\tX\tPIX\nGPU3\tPIX\tPIX\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.AllocatableMeta.Cores = nvidia.HundredCore
+		n.AllocatableMeta.Memory = 1024 * 1024 * 1024
+		n.Meta.TotalMemory = 1024 * 1024 * 1024
+	}
+
+	podLister := watchdog.NewFakePodLister()
+	alloc := initAllocator(tree, fake.NewSimpleClientset(), podLister)
+	alloc.initEvaluator(tree)
+
+	memory := resource.MustParse("1Gi")
+	alloc.config.ExtraConfig = config.NewExtraConfigStore()
+	alloc.config.ExtraConfig.Set(map[string]*config.ExtraConfig{
+		"default": {
+			Quotas: map[string]*config.GPUQuota{
+				"team-x":             {Cards: 1, Cores: 60, CoresPerCard: 50, Memory: &memory},
+				config.AllNamespaces: {Cards: 1},
+			},
+		},
+	})
+
+	allocate := func(namespace, name string, cores, memory int) error {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				UID:       k8stypes.UID(namespace + "-" + name),
+				Annotations: map[string]string{
+					types.PredicateTimeAnnotation:       "1",
+					types.GPUAssigned:                   "false",
+					types.PredicateGPUIndexPrefix + "0": "0",
+				},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Name: "container-0",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						types.VCoreAnnotation:   resource.MustParse(fmt.Sprintf("%d", cores)),
+						types.VMemoryAnnotation: resource.MustParse(fmt.Sprintf("%d", memory)),
+					},
+				},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodPending},
+		}
+		podLister.Add(pod)
+
+		req := prepareContainerAllocateRequest(cores, memory)
+		_, err := alloc.allocateOne(pod, &pod.Spec.Containers[0], &req)
+		return err
+	}
+
+	expectRejected := func(err error, reason string) {
+		if err == nil || !strings.Contains(err.Error(), types.GPUQuotaExceededErrMsg) || !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expect quota error with %q, got %v", reason, err)
+		}
+
+		// rejected pod is failed through ALLOCATE_FAIL
+		item, _ := alloc.queue.Get()
+		alloc.queue.Done(item)
+		alloc.queue.Forget(item)
+		if ar, ok := item.(*allocateResult); !ok || ar.result != ALLOCATE_FAIL || ar.reason != types.GPUQuotaExceededErrType {
+			t.Fatalf("expect ALLOCATE_FAIL result, got %+v", item)
+		}
+	}
+
+	if err := allocate("team-x", "a", 30, 1); err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+
+	// the only card of team-x has 20 vcore left
+	expectRejected(allocate("team-x", "b", 30, 1), "no card is within the quota")
+	expectRejected(allocate("team-x", "c", 60, 1), "exceeds the limit 50")
+	expectRejected(allocate("team-x", "d", 20, 8), "bytes of GPU memory")
+
+	if err := allocate("team-x", "e", 20, 1); err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+	expectRejected(allocate("team-x", "f", 20, 1), "exceeds the limit 60")
+
+	// other namespaces have their own usage
+	expectRejected(allocate("team-y", "a", 200, 1), "requesting 2 exceeds the limit 1")
+	if err := allocate("team-y", "b", 100, 1); err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+
+	if tree.Available() != 2 {
+		t.Fatalf("expect 2 available cards, got %d", tree.Available())
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"tkestack.io/gpu-manager/pkg/config"
 	"tkestack.io/gpu-manager/pkg/device"
 	"tkestack.io/gpu-manager/pkg/services/response"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
@@ -31,13 +32,16 @@ import (
 type GPUTopoService interface {
 	pluginapi.DevicePluginServer
 	ListAndWatchWithResourceName(string, *pluginapi.Empty, pluginapi.DevicePlugin_ListAndWatchServer) error
+	//Stop terminates background routines and flushes the allocation state
+	Stop()
 }
 
 //NewFunc represents function for creating new GPUTopoService
 type NewFunc func(cfg *config.Config,
 	tree device.GPUTree,
 	k8sClient kubernetes.Interface,
-	responseManager response.Manager) GPUTopoService
+	responseManager response.Manager,
+	podLister watchdog.PodLister) GPUTopoService
 
 var (
 	factory = make(map[string]NewFunc)
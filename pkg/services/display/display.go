@@ -21,7 +21,6 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
@@ -49,18 +48,21 @@ type Display struct {
 	config                  *config.Config
 	tree                    *nvtree.NvidiaTree
 	containerRuntimeManager runtime.ContainerRuntimeInterface
+	podLister               watchdog.PodLister
 }
 
 var _ displayapi.GPUDisplayServer = &Display{}
 var _ prometheus.Collector = &Display{}
 
 //NewDisplay returns a new Display
-func NewDisplay(config *config.Config, tree device.GPUTree, runtimeManager runtime.ContainerRuntimeInterface) *Display {
+func NewDisplay(config *config.Config, tree device.GPUTree, runtimeManager runtime.ContainerRuntimeInterface,
+	podLister watchdog.PodLister) *Display {
 	_tree, _ := tree.(*nvtree.NvidiaTree)
 	return &Display{
 		tree:                    _tree,
 		config:                  config,
 		containerRuntimeManager: runtimeManager,
+		podLister:               podLister,
 	}
 }
 
@@ -78,7 +80,7 @@ func (disp *Display) PrintUsages(context.Context, *google_protobuf1.Empty) (*dis
 	disp.Lock()
 	defer disp.Unlock()
 
-	activePods := watchdog.GetActivePods()
+	activePods := disp.podLister.GetActivePods()
 	displayResp := &displayapi.UsageResponse{
 		Usage: make(map[string]*displayapi.ContainerStat),
 	}
@@ -108,10 +110,9 @@ func (disp *Display) PrintUsages(context.Context, *google_protobuf1.Empty) (*dis
 func (disp *Display) getPodSpec(pod *v1.Pod, devicesInfo map[string]*displayapi.Devices) map[string]*displayapi.Spec {
 	podSpec := make(map[string]*displayapi.Spec)
 
-	for _, ctnt := range pod.Spec.Containers {
+	for i, ctnt := range pod.Spec.Containers {
 		vcore := ctnt.Resources.Requests[types.VCoreAnnotation]
-		vmemory := ctnt.Resources.Requests[types.VMemoryAnnotation]
-		memBytes := vmemory.Value() * types.MemoryBlockSize
+		memBytes := utils.GetGPUMemoryOfContainer(pod, i, disp.config.GetMemoryBlockSize())
 
 		spec := &displayapi.Spec{
 			Gpu: float32(vcore.Value()) / 100,
@@ -139,7 +140,7 @@ func (disp *Display) getPodUsage(pod *v1.Pod) map[string]*displayapi.Devices {
 
 	for _, stat := range pod.Status.ContainerStatuses {
 		contName := stat.Name
-		contID := strings.TrimPrefix(stat.ContainerID, fmt.Sprintf("%s://", disp.containerRuntimeManager.RuntimeName()))
+		contID := runtime.TrimContainerIDPrefix(stat.ContainerID)
 		if len(contID) == 0 {
 			continue
 		}
@@ -273,6 +274,11 @@ var (
 	utilSpecDescBuilder   = gpuUtilSpecDesc{}
 	memoryDescBuilder     = gpuMemoryDesc{}
 	memorySpecDescBuilder = gpuMemorySpecDesc{}
+
+	//memory of card in MiB, kind is logical or physical
+	cardMemoryLabels        = []string{"node", "gpu", "kind"}
+	cardMemoryDesc          = prometheus.NewDesc("gpu_memory_capacity", "gpu memory can be committed in MiB", cardMemoryLabels, nil)
+	cardCommittedMemoryDesc = prometheus.NewDesc("gpu_memory_committed", "committed gpu memory in MiB", cardMemoryLabels, nil)
 )
 
 const (
@@ -320,11 +326,15 @@ func (disp *Display) Describe(ch chan<- *prometheus.Desc) {
 	ch <- utilSpecDescBuilder.getDescribeDesc()
 	ch <- memoryDescBuilder.getDescribeDesc()
 	ch <- memorySpecDescBuilder.getDescribeDesc()
+	ch <- cardMemoryDesc
+	ch <- cardCommittedMemoryDesc
 }
 
 // Collect implements prometheus Collector interface
 func (disp *Display) Collect(ch chan<- prometheus.Metric) {
-	for _, pod := range watchdog.GetActivePods() {
+	disp.collectCardMemory(ch)
+
+	for _, pod := range disp.podLister.GetActivePods() {
 		valueLabels := make([]string, len(defaultMetricLabels))
 		valueLabels[metricPodName] = pod.Name
 		valueLabels[metricNamespace] = pod.Namespace
@@ -374,3 +384,29 @@ func (disp *Display) Collect(ch chan<- prometheus.Metric) {
 		}
 	}
 }
+
+//collectCardMemory reports logical and physical memory commitments of
+//each card, they differ if memory of the card is oversubscribed
+func (disp *Display) collectCardMemory(ch chan<- prometheus.Metric) {
+	disp.tree.Lock()
+	defer disp.tree.Unlock()
+
+	for _, n := range disp.tree.Leaves() {
+		gpuID := fmt.Sprintf("gpu%d", n.Meta.ID)
+		metrics := []struct {
+			desc  *prometheus.Desc
+			kind  string
+			value int64
+		}{
+			{cardMemoryDesc, "logical", n.LogicalMemory()},
+			{cardMemoryDesc, "physical", int64(n.Meta.TotalMemory)},
+			{cardCommittedMemoryDesc, "logical", n.CommittedMemory()},
+			{cardCommittedMemoryDesc, "physical", n.CommittedPhysicalMemory()},
+		}
+
+		for _, m := range metrics {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue,
+				float64(m.value>>20), disp.config.Hostname, gpuID, m.kind)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package display
+
+import (
+	"context"
+	"fmt"
+
+	nveval "tkestack.io/gpu-manager/pkg/algorithm/nvidia"
+	displayapi "tkestack.io/gpu-manager/pkg/api/runtime/display"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/klog"
+)
+
+type evaluator interface {
+	Evaluate(cores int64, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode
+}
+
+//Simulate evaluates the request on a snapshot of the tree with the same
+//evaluator as allocator, and returns the chosen cards and fragmentation
+//before and after the allocation. The tree is not changed.
+func (disp *Display) Simulate(_ context.Context, req *displayapi.SimulateRequest) (*displayapi.SimulateResponse, error) {
+	qos := types.QoSGuaranteed
+	if req.QosClass != "" {
+		qos = types.QoSClass(req.QosClass)
+		switch qos {
+		case types.QoSGuaranteed, types.QoSBurstable, types.QoSBestEffort:
+		default:
+			return nil, fmt.Errorf("invalid qos class %s", req.QosClass)
+		}
+	}
+
+	mode, err := nveval.SelectModeWithQoS(req.Cores, req.Memory, disp.config.EnableShare, qos, disp.config.OversubscriptionRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := disp.tree.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("can't snapshot tree, %v", err)
+	}
+
+	evaluators := map[string]evaluator{
+		nveval.LinkMode:       nveval.NewLinkMode(snapshot),
+		nveval.FragmentMode:   nveval.NewFragmentMode(snapshot),
+		nveval.ShareMode:      nveval.NewShareMode(snapshot),
+		nveval.BestEffortMode: nveval.NewBestEffortMode(snapshot, disp.config.OversubscriptionRatio),
+	}
+
+	// allocator commits memory in whole blocks
+	blockSize := snapshot.MemoryBlockSize()
+	memory := (req.Memory + blockSize - 1) / blockSize * blockSize
+	shareMode := mode == nveval.ShareMode || mode == nveval.BestEffortMode
+
+	resp := &displayapi.SimulateResponse{
+		Mode:   mode,
+		Before: fragmentationOf(snapshot),
+	}
+
+	var nodes []*nvtree.NvidiaNode
+	if shareMode {
+		nodes = evaluators[mode].Evaluate(req.Cores, memory, nvtree.ModelFilter(req.ModelSelectors))
+	} else {
+		nodes = evaluators[mode].Evaluate(req.Cores, 0, nvtree.ModelFilter(req.ModelSelectors))
+	}
+
+	for _, n := range nodes {
+		switch {
+		case mode == nveval.BestEffortMode:
+			snapshot.MarkOccupiedBestEffort(n, req.Cores, memory)
+		case qos == types.QoSGuaranteed:
+			snapshot.MarkOccupiedGuaranteed(n, req.Cores, memory)
+		default:
+			snapshot.MarkOccupied(n, req.Cores, memory)
+		}
+		resp.Devices = append(resp.Devices, n.MinorName())
+	}
+
+	klog.V(4).Infof("Simulate vcore %d vmemory %d in %s mode, devices %v", req.Cores, memory, mode, resp.Devices)
+	resp.After = fragmentationOf(snapshot)
+
+	return resp, nil
+}
+
+func fragmentationOf(tree *nvtree.NvidiaTree) *displayapi.Fragmentation {
+	frag := tree.Fragmentation()
+
+	return &displayapi.Fragmentation{
+		FreeCards:  int32(frag.FreeCards),
+		FreeCores:  frag.FreeCores,
+		FreeMemory: frag.FreeMemory,
+		Ratio:      float32(frag.Ratio),
+	}
+}
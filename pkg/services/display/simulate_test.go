@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package display
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"testing"
+
+	displayapi "tkestack.io/gpu-manager/pkg/api/runtime/display"
+	"tkestack.io/gpu-manager/pkg/config"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func init() {
+	flag.Set("v", "4")
+	flag.Set("logtostderr", "true")
+}
+
+func TestSimulate(t *testing.T) {
+	testCase :=
+		`    GPU0    GPU1    GPU2    GPU3
+GPU0      X      PIX     PHB     PHB
+GPU1     PIX      X      PHB     PHB
+GPU2     PHB     PHB      X      PIX
+GPU3     PHB     PHB     PIX      X
+`
+	tree, _ := nvtree.NewNvidiaTree(nil).(*nvtree.NvidiaTree)
+	tree.Init(testCase)
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = 4 * types.MemoryBlockSize
+		n.AllocatableMeta.Cores = nvtree.HundredCore
+		n.AllocatableMeta.Memory = 4 * types.MemoryBlockSize
+	}
+	tree.MarkOccupied(tree.Leaves()[0], 50, types.MemoryBlockSize)
+
+	disp := NewDisplay(&config.Config{EnableShare: true}, tree, nil, watchdog.NewFakePodLister())
+	expectBefore := &displayapi.Fragmentation{
+		FreeCards:  3,
+		FreeCores:  350,
+		FreeMemory: 15 * types.MemoryBlockSize,
+		Ratio:      float32(50) / 350,
+	}
+
+	testCases := []struct {
+		cores, memory int64
+		mode          string
+		devices       []string
+		after         *displayapi.Fragmentation
+	}{
+		{
+			cores:   30,
+			memory:  types.MemoryBlockSize + 1,
+			mode:    "share",
+			devices: []string{"/dev/nvidia0"},
+			after: &displayapi.Fragmentation{
+				FreeCards:  3,
+				FreeCores:  320,
+				FreeMemory: 13 * types.MemoryBlockSize,
+				Ratio:      float32(20) / 320,
+			},
+		},
+		{
+			cores:   200,
+			mode:    "link",
+			devices: []string{"/dev/nvidia2", "/dev/nvidia3"},
+			after: &displayapi.Fragmentation{
+				FreeCards:  1,
+				FreeCores:  150,
+				FreeMemory: 7 * types.MemoryBlockSize,
+				Ratio:      float32(50) / 150,
+			},
+		},
+		{
+			cores: 400,
+			mode:  "link",
+			after: expectBefore,
+		},
+	}
+
+	for _, tc := range testCases {
+		resp, err := disp.Simulate(context.Background(), &displayapi.SimulateRequest{Cores: tc.cores, Memory: tc.memory})
+		if err != nil {
+			t.Fatalf("can't simulate vcore %d: %v", tc.cores, err)
+		}
+
+		if resp.Mode != tc.mode || !reflect.DeepEqual(resp.Devices, tc.devices) {
+			t.Errorf("vcore %d: expect %s mode on %v, got %s mode on %v", tc.cores, tc.mode, tc.devices, resp.Mode, resp.Devices)
+		}
+
+		if !reflect.DeepEqual(resp.Before, expectBefore) || !reflect.DeepEqual(resp.After, tc.after) {
+			t.Errorf("vcore %d: unexpected fragmentation %+v -> %+v", tc.cores, resp.Before, resp.After)
+		}
+	}
+
+	if tree.Available() != 3 || tree.Leaves()[0].AllocatableMeta.Cores != 50 {
+		t.Fatalf("tree should not be changed by simulation")
+	}
+
+	if _, err := disp.Simulate(context.Background(), &displayapi.SimulateRequest{Cores: 150}); err == nil {
+		t.Fatalf("invalid request should be rejected")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package reloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tkestack.io/gpu-manager/pkg/utils"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog"
+)
+
+const (
+	// configMapDataDir is the symlink kubelet swaps atomically when a
+	// projected ConfigMap is updated
+	configMapDataDir = "..data"
+	debouncePeriod   = 500 * time.Millisecond
+)
+
+//ApplyFunc validates the content of a config file and makes it effective.
+//The previous config must stay in effect if an error is returned.
+type ApplyFunc func(data []byte) error
+
+//Revision describes the config file content which is currently in effect
+type Revision struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Revision  int64     `json:"revision"`
+	Checksum  string    `json:"checksum,omitempty"`
+	LoadedAt  time.Time `json:"loadedAt,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+//Reloader watches a config file and applies the new content on change
+type Reloader struct {
+	sync.Mutex
+
+	name  string
+	path  string
+	apply ApplyFunc
+	rev   Revision
+}
+
+//New returns a Reloader for config file at path
+func New(name, path string, apply ApplyFunc) *Reloader {
+	return &Reloader{
+		name:  name,
+		path:  path,
+		apply: apply,
+		rev: Revision{
+			Name: name,
+			Path: path,
+		},
+	}
+}
+
+//Load reads the config file and applies it if the content has changed.
+//On error the last good config is kept and the error is recorded.
+func (r *Reloader) Load() error {
+	r.Lock()
+	defer r.Unlock()
+
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return r.recordError(err)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if r.rev.Revision > 0 && checksum == r.rev.Checksum {
+		klog.V(4).Infof("Config %s is not changed, skip", r.path)
+		return nil
+	}
+
+	if err := r.apply(data); err != nil {
+		return r.recordError(err)
+	}
+
+	r.rev.Revision++
+	r.rev.Checksum = checksum
+	r.rev.LoadedAt = time.Now()
+	r.rev.LastError = ""
+	klog.V(2).Infof("Config %s is loaded, revision %d, checksum %s", r.path, r.rev.Revision, checksum)
+
+	return nil
+}
+
+func (r *Reloader) recordError(err error) error {
+	err = fmt.Errorf("can't load %s config %s, %v", r.name, r.path, err)
+	r.rev.LastError = err.Error()
+
+	return err
+}
+
+//Revision returns the revision currently in effect
+func (r *Reloader) Revision() Revision {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.rev
+}
+
+//Run watches the directory of config file and reloads the config until
+//stop is closed. The directory is watched instead of the file itself, so
+//editors replacing the file and ConfigMap symlink swaps are both noticed.
+func (r *Reloader) Run(stop <-chan struct{}) error {
+	watcher, err := utils.NewFSWatcher(filepath.Dir(r.path))
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	klog.V(2).Infof("Watching %s config %s", r.name, r.path)
+
+	var (
+		timer   = time.NewTimer(debouncePeriod)
+		pending bool
+	)
+	timer.Stop()
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if !r.isConfigEvent(event) {
+				continue
+			}
+
+			klog.V(4).Infof("Config %s event %s", r.path, event)
+			pending = true
+			timer.Reset(debouncePeriod)
+		case <-timer.C:
+			if !pending {
+				continue
+			}
+			pending = false
+
+			if err := r.Load(); err != nil {
+				klog.Errorf("%v, keep revision %d", err, r.Revision().Revision)
+			}
+		case err := <-watcher.Errors:
+			klog.Errorf("inotify: %s", err)
+		case <-stop:
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+func (r *Reloader) isConfigEvent(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	name := filepath.Base(event.Name)
+	return name == filepath.Base(r.path) || name == configMapDataDir
+}
+
+//Handler returns a http handler shows revisions of given reloaders
+func Handler(reloaders ...*Reloader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		revisions := make([]Revision, 0, len(reloaders))
+		for _, r := range reloaders {
+			revisions = append(revisions, r.Revision())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(revisions); err != nil {
+			klog.Errorf("can't encode config revisions, %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package reloader
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func init() {
+	flag.Set("v", "4")
+	flag.Set("logtostderr", "true")
+}
+
+func TestReloader(t *testing.T) {
+	flag.Parse()
+	tempDir, _ := ioutil.TempDir("", "reloader")
+	defer os.RemoveAll(tempDir)
+
+	cfgFile := filepath.Join(tempDir, "test.conf")
+	if err := ioutil.WriteFile(cfgFile, []byte("good-1"), 0644); err != nil {
+		t.Fatalf("can't write config, %v", err)
+	}
+
+	active := ""
+	r := New("test", cfgFile, func(data []byte) error {
+		if string(data) == "bad" {
+			return fmt.Errorf("invalid content")
+		}
+		active = string(data)
+		return nil
+	})
+
+	if err := r.Load(); err != nil {
+		t.Fatalf("failed to load config, %v", err)
+	}
+	if rev := r.Revision(); rev.Revision != 1 || active != "good-1" {
+		t.Fatalf("expect revision 1 with good-1, got %d with %s", rev.Revision, active)
+	}
+
+	// reload with same content should not bump revision
+	if err := r.Load(); err != nil || r.Revision().Revision != 1 {
+		t.Fatalf("expect revision 1 unchanged, got %d, %v", r.Revision().Revision, err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Run(stop)
+	time.Sleep(100 * time.Millisecond)
+
+	// bad content must keep the last good one
+	ioutil.WriteFile(cfgFile, []byte("bad"), 0644)
+	err := wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(r.Revision().LastError) > 0, nil
+	})
+	if err != nil {
+		t.Fatalf("bad config is not detected")
+	}
+	if rev := r.Revision(); rev.Revision != 1 || active != "good-1" {
+		t.Fatalf("expect revision 1 with good-1 kept, got %d with %s", rev.Revision, active)
+	}
+
+	ioutil.WriteFile(cfgFile, []byte("good-2"), 0644)
+	err = wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return r.Revision().Revision == 2, nil
+	})
+	if err != nil || active != "good-2" || len(r.Revision().LastError) > 0 {
+		t.Fatalf("expect revision 2 with good-2, got %+v with %s", r.Revision(), active)
+	}
+
+	w := httptest.NewRecorder()
+	Handler(r).ServeHTTP(w, httptest.NewRequest("GET", "/config", nil))
+	revisions := make([]Revision, 0)
+	if err := json.Unmarshal(w.Body.Bytes(), &revisions); err != nil {
+		t.Fatalf("can't decode /config response, %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Revision != 2 || revisions[0].Path != cfgFile {
+		t.Fatalf("unexpected /config response %s", w.Body.String())
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vitrual_manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"tkestack.io/gpu-manager/pkg/utils/cgroup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"k8s.io/klog"
+)
+
+const (
+	rejectReasonNoCredential = "no_credential"
+	rejectReasonNoContainer  = "no_container"
+	rejectReasonPodMismatch  = "pod_mismatch"
+	rejectReasonContMismatch = "container_mismatch"
+)
+
+//peerAuthInfo carries the credentials of the process on the other side of
+//the unix socket
+type peerAuthInfo struct {
+	ucred *syscall.Ucred
+}
+
+func (peerAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+//peerCredentials is a grpc transport credential which reads SO_PEERCRED of
+//unix socket connections, the connection itself is left untouched
+type peerCredentials struct{}
+
+var _ credentials.TransportCredentials = peerCredentials{}
+
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, nil, nil
+}
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected connection type %T", conn)
+	}
+
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		ucred   *syscall.Ucred
+		credErr error
+	)
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, nil, err
+	}
+	if credErr != nil {
+		return nil, nil, credErr
+	}
+
+	return conn, peerAuthInfo{ucred: ucred}, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (peerCredentials) Clone() credentials.TransportCredentials {
+	return peerCredentials{}
+}
+
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+func newRejectedCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vcuda_register_rejected_total",
+		Help: "Number of vcuda requests rejected by peer authentication",
+	}, []string{"reason"})
+}
+
+//authenticate verifies the caller really runs in the pod and container it
+//claims, the container ID of caller is returned
+func (vm *VirtualManager) authenticate(ctx context.Context, podUID, contName, contID string) (string, error) {
+	pid := 0
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(peerAuthInfo); ok && info.ucred != nil {
+			pid = int(info.ucred.Pid)
+		}
+	}
+	if pid == 0 {
+		return "", vm.reject(rejectReasonNoCredential, podUID, contName+contID, "no peer credential")
+	}
+
+	callerPodUID, callerID, err := cgroup.GetPodContainerOfProcess(pid)
+	if err != nil {
+		return "", vm.reject(rejectReasonNoContainer, podUID, contName+contID, "pid %d: %v", pid, err)
+	}
+
+	if callerPodUID != podUID {
+		return "", vm.reject(rejectReasonPodMismatch, podUID, contName+contID, "pid %d belongs to pod %s", pid, callerPodUID)
+	}
+
+	if len(contID) > 0 && callerID != contID {
+		return "", vm.reject(rejectReasonContMismatch, podUID, contName+contID, "pid %d belongs to container %s", pid, callerID)
+	}
+
+	return callerID, nil
+}
+
+func (vm *VirtualManager) reject(reason string, podUID, cont string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	klog.Warningf("Reject vcuda request of %s/%s, %s", podUID, cont, msg)
+	vm.rejected.WithLabelValues(reason).Inc()
+
+	return fmt.Errorf("request rejected, %s", msg)
+}
+
+//Describe implements prometheus.Collector
+func (vm *VirtualManager) Describe(ch chan<- *prometheus.Desc) {
+	vm.rejected.Describe(ch)
+	ch <- processMemoryDesc
+	ch <- processSMTimeDesc
+}
+
+//Collect implements prometheus.Collector
+func (vm *VirtualManager) Collect(ch chan<- prometheus.Metric) {
+	vm.rejected.Collect(ch)
+	vm.collectUsage(ch)
+}
@@ -28,115 +28,33 @@ import (
 	"sync"
 	"syscall"
 	"time"
-	"unsafe"
 
 	vcudaapi "tkestack.io/gpu-manager/pkg/api/runtime/vcuda"
 	"tkestack.io/gpu-manager/pkg/config"
 	"tkestack.io/gpu-manager/pkg/device/nvidia"
 	"tkestack.io/gpu-manager/pkg/runtime"
 	"tkestack.io/gpu-manager/pkg/services/response"
+	"tkestack.io/gpu-manager/pkg/services/virtual-manager/vcudaconfig"
 	"tkestack.io/gpu-manager/pkg/services/watchdog"
 	"tkestack.io/gpu-manager/pkg/types"
 	"tkestack.io/gpu-manager/pkg/utils"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
 
-//#include <stdint.h>
-//#include <sys/types.h>
-//#include <sys/stat.h>
-//#include <fcntl.h>
-//#include <string.h>
-//#include <sys/file.h>
-//#include <time.h>
-//#include <stdlib.h>
-//#include <unistd.h>
-//
-//#ifndef NVML_DEVICE_PCI_BUS_ID_BUFFER_SIZE
-//#define NVML_DEVICE_PCI_BUS_ID_BUFFER_SIZE 16
-//#endif
-//
-//#ifndef FILENAME_MAX
-//#define FILENAME_MAX 4096
-//#endif
-//
-//struct version_t {
-//  int major;
-//  int minor;
-//} __attribute__((packed, aligned(8)));
-//
-//struct resource_data_t {
-//  char pod_uid[48];
-//  int limit;
-//  char occupied[4044];
-//  char container_name[FILENAME_MAX];
-//  char bus_id[NVML_DEVICE_PCI_BUS_ID_BUFFER_SIZE];
-//  uint64_t gpu_memory;
-//  int utilization;
-//  int hard_limit;
-//  struct version_t driver_version;
-//  int enable;
-//} __attribute__((packed, aligned(8)));
-//
-//int setting_to_disk(const char* filename, struct resource_data_t* data) {
-//  int fd = 0;
-//  int wsize = 0;
-//  int ret = 0;
-//
-//  fd = open(filename, O_CREAT | O_TRUNC | O_WRONLY, 00777);
-//  if (fd == -1) {
-//    return 1;
-//  }
-//
-//  wsize = (int)write(fd, (void*)data, sizeof(struct resource_data_t));
-//  if (wsize != sizeof(struct resource_data_t)) {
-//    ret = 2;
-//	goto DONE;
-//  }
-//
-//DONE:
-//  close(fd);
-//
-//  return ret;
-//}
-//
-//int pids_to_disk(const char* filename, int* data, int size) {
-//  int fd = 0;
-//  int wsize = 0;
-//  struct timespec wait = {
-//	.tv_sec = 0, .tv_nsec = 100 * 1000 * 1000,
-//  };
-//  int ret = 0;
-//
-//  fd = open(filename, O_CREAT | O_TRUNC | O_WRONLY, 00777);
-//  if (fd == -1) {
-//    return 1;
-//  }
-//
-//  while (flock(fd, LOCK_EX)) {
-//    nanosleep(&wait, NULL);
-//  }
-//
-//  wsize = (int)write(fd, (void*)data, sizeof(int) * size);
-//  if (wsize != sizeof(int) * size) {
-//	ret = 2;
-//    goto DONE;
-//  }
-//
-//DONE:
-//  flock(fd, LOCK_UN);
-//  close(fd);
-//
-//  return ret;
-//}
-import "C"
-
 const (
 	PIDS_CONFIG_NAME       = "pids.config"
 	CONTROLLER_CONFIG_NAME = "vcuda.config"
 	DEFAULT_DIR_MODE       = 0777
+
+	// Versioned files are written along with legacy ones, see vcudaconfig
+	VERSIONED_PIDS_CONFIG_NAME       = "pids.vconfig"
+	VERSIONED_CONTROLLER_CONFIG_NAME = "vcuda.vconfig"
 )
 
 //VirtualManager manages vGPUs
@@ -147,19 +65,38 @@ type VirtualManager struct {
 	containerRuntimeManager runtime.ContainerRuntimeInterface
 	vDeviceServers          map[string]*grpc.Server
 	responseManager         response.Manager
+	stopCh                  chan struct{}
+	stopOnce                sync.Once
+	rejected                *prometheus.CounterVec
+	podLister               watchdog.PodLister
+	recycleQueue            workqueue.DelayingInterface
+
+	containersLock sync.Mutex
+	containers     map[string]*containerState
+
+	pidFilesLock sync.Mutex
+	pidFiles     map[string]*pidFile
 }
 
 var _ vcudaapi.VCUDAServiceServer = &VirtualManager{}
+var _ prometheus.Collector = &VirtualManager{}
 
 //NewVirtualManager returns a new VirtualManager.
 func NewVirtualManager(config *config.Config,
 	runtimeManager runtime.ContainerRuntimeInterface,
-	responseManager response.Manager) *VirtualManager {
+	responseManager response.Manager,
+	podLister watchdog.PodLister) *VirtualManager {
 	manager := &VirtualManager{
 		cfg:                     config,
 		containerRuntimeManager: runtimeManager,
 		vDeviceServers:          make(map[string]*grpc.Server),
 		responseManager:         responseManager,
+		stopCh:                  make(chan struct{}),
+		rejected:                newRejectedCounter(),
+		podLister:               podLister,
+		recycleQueue:            workqueue.NewNamedDelayingQueue("vcuda-recycle"),
+		containers:              make(map[string]*containerState),
+		pidFiles:                make(map[string]*pidFile),
 	}
 
 	return manager
@@ -169,12 +106,19 @@ func NewVirtualManager(config *config.Config,
 //client for testing.
 func NewVirtualManagerForTest(config *config.Config,
 	runtimeManager runtime.ContainerRuntimeInterface,
-	responseManager response.Manager) *VirtualManager {
+	responseManager response.Manager,
+	podLister watchdog.PodLister) *VirtualManager {
 	manager := &VirtualManager{
 		cfg:                     config,
 		vDeviceServers:          make(map[string]*grpc.Server),
 		containerRuntimeManager: runtimeManager,
 		responseManager:         responseManager,
+		stopCh:                  make(chan struct{}),
+		rejected:                newRejectedCounter(),
+		podLister:               podLister,
+		recycleQueue:            workqueue.NewNamedDelayingQueue("vcuda-recycle"),
+		containers:              make(map[string]*containerState),
+		pidFiles:                make(map[string]*pidFile),
 	}
 
 	return manager
@@ -195,14 +139,36 @@ func (vm *VirtualManager) Run() {
 	<-registered
 
 	go vm.garbageCollector()
+	vm.podLister.AddEventHandler(watchdog.PodTerminationHandler(vm.onPodTerminated))
+	go wait.Until(vm.runRecycle, time.Second, vm.stopCh)
+	go vm.pidsRefresher()
 	go vm.process()
 	klog.V(2).Infof("Virtual manager is running")
 }
 
+//Stop terminates background routines and closes all vDevice servers
+func (vm *VirtualManager) Stop() {
+	vm.stopOnce.Do(func() {
+		close(vm.stopCh)
+		vm.recycleQueue.ShutDown()
+
+		vm.Lock()
+		defer vm.Unlock()
+
+		for dir, srv := range vm.vDeviceServers {
+			klog.V(2).Infof("Close vDevice server %s", dir)
+			srv.Stop()
+			delete(vm.vDeviceServers, dir)
+		}
+
+		klog.V(2).Infof("Virtual manager is stopped")
+	})
+}
+
 func (vm *VirtualManager) vDeviceWatcher(registered chan struct{}) {
 	klog.V(2).Infof("Start vDevice watcher")
 
-	activePods := watchdog.GetActivePods()
+	activePods := vm.podLister.GetActivePods()
 	possibleActiveVm := vm.responseManager.ListAll()
 
 	for uid, containerMapping := range possibleActiveVm {
@@ -236,22 +202,12 @@ func (vm *VirtualManager) vDeviceWatcher(registered chan struct{}) {
 			} else {
 				klog.Warningf("Ignore directory %s", dirName)
 			}
-
-			srv := runVDeviceServer(dirName, vm)
-			if srv == nil {
-				klog.Fatalf("Can't recover vDevice server for %s", dirName)
-			}
-
-			klog.V(2).Infof("Recover vDevice server for %s", dirName)
-			vm.Lock()
-			vm.vDeviceServers[dirName] = srv
-			vm.Unlock()
 		}
 	}
 
 	close(registered)
 
-	wait.Forever(func() {
+	wait.Until(func() {
 		vm.Lock()
 		defer vm.Unlock()
 
@@ -263,15 +219,15 @@ func (vm *VirtualManager) vDeviceWatcher(registered chan struct{}) {
 				delete(vm.vDeviceServers, dir)
 			}
 		}
-	}, time.Minute)
+	}, time.Minute, vm.stopCh)
 }
 
 func (vm *VirtualManager) garbageCollector() {
 	klog.V(2).Infof("Starting garbage directory collector")
-	wait.Forever(func() {
+	wait.Until(func() {
 		needDeleted := make([]string, 0)
 
-		activePods := watchdog.GetActivePods()
+		activePods := vm.podLister.GetActivePods()
 		possibleActiveVm := vm.responseManager.ListAll()
 
 		for uid, containerMapping := range possibleActiveVm {
@@ -290,7 +246,56 @@ func (vm *VirtualManager) garbageCollector() {
 			klog.V(2).Infof("Remove directory %s", dir)
 			os.RemoveAll(filepath.Clean(dir))
 		}
-	}, time.Minute)
+
+		vm.pruneContainers(activePods)
+	}, time.Minute, vm.stopCh)
+}
+
+//onPodTerminated schedules to remove the directory of pod after the grace
+//period, so it doesn't wait for garbageCollector
+func (vm *VirtualManager) onPodTerminated(pod *v1.Pod) {
+	vm.recycleQueue.AddAfter(string(pod.UID), vm.cfg.RecycleGracePeriod)
+}
+
+func (vm *VirtualManager) runRecycle() {
+	for vm.processNextRecycle() {
+	}
+}
+
+func (vm *VirtualManager) processNextRecycle() bool {
+	key, quit := vm.recycleQueue.Get()
+	if quit {
+		return false
+	}
+	defer vm.recycleQueue.Done(key)
+
+	podUID, ok := key.(string)
+	if !ok {
+		return true
+	}
+
+	activePods := vm.podLister.GetActivePods()
+	if _, ok := activePods[podUID]; ok {
+		return true
+	}
+
+	dirName := filepath.Clean(filepath.Join(vm.cfg.VirtualManagerPath, podUID))
+	vm.Lock()
+	if srv, ok := vm.vDeviceServers[dirName]; ok {
+		klog.V(2).Infof("Close vDevice server %s", dirName)
+		srv.Stop()
+		delete(vm.vDeviceServers, dirName)
+	}
+	vm.Unlock()
+
+	if _, err := os.Stat(dirName); err == nil {
+		klog.V(2).Infof("Remove directory %s", dirName)
+		os.RemoveAll(dirName)
+	}
+
+	vm.pruneContainers(activePods)
+
+	return true
 }
 
 //                Host                     |                Container
@@ -352,10 +357,16 @@ func (vm *VirtualManager) process() {
 	}
 
 	klog.V(2).Infof("Starting process vm events")
-	for evt := range vm.cfg.VCudaRequestsQueue {
-		podUID := evt.PodUID
-		klog.V(2).Infof("process %s", podUID)
-		evt.Done <- vcudaConfigFunc(podUID)
+	for {
+		select {
+		case evt := <-vm.cfg.VCudaRequestsQueue:
+			podUID := evt.PodUID
+			klog.V(2).Infof("process %s", podUID)
+			evt.Done <- vcudaConfigFunc(podUID)
+		case <-vm.stopCh:
+			klog.V(2).Infof("Stop processing vm events")
+			return
+		}
 	}
 }
 
@@ -377,20 +388,19 @@ func (vm *VirtualManager) registerVDeviceWithContainerId(podUID, contID string)
 		return nil, fmt.Errorf("unable to find virtual manager controller path")
 	}
 
-	pidFilename := filepath.Join(baseDir, contID, PIDS_CONFIG_NAME)
-	configFilename := filepath.Join(baseDir, contID, CONTROLLER_CONFIG_NAME)
+	configDir := filepath.Join(baseDir, contID)
 
-	if err := os.MkdirAll(filepath.Dir(configFilename), DEFAULT_DIR_MODE); err != nil && !os.IsExist(err) {
+	if err := os.MkdirAll(configDir, DEFAULT_DIR_MODE); err != nil && !os.IsExist(err) {
 		return nil, err
 	}
 
 	// write down pid file
-	err = vm.writePidFile(pidFilename, contID)
+	err = vm.writePidFile(podUID, configDir, contID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := vm.writeConfigFile(configFilename, podUID, containerInfo.Metadata.Name); err != nil {
+	if err := vm.writeConfigFile(configDir, podUID, containerInfo.Metadata.Name); err != nil {
 		return nil, err
 	}
 
@@ -398,7 +408,7 @@ func (vm *VirtualManager) registerVDeviceWithContainerId(podUID, contID string)
 }
 
 // Deprecated
-func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName string) (*vcudaapi.VDeviceResponse, error) {
+func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName, callerID string) (*vcudaapi.VDeviceResponse, error) {
 	klog.V(2).Infof("UID: %s, contName: %s want to registration", podUID, contName)
 
 	resp := vm.responseManager.GetResp(podUID, contName)
@@ -411,16 +421,15 @@ func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName stri
 		return nil, fmt.Errorf("unable to find virtual manager controller path")
 	}
 
-	pidFilename := filepath.Join(baseDir, contName, PIDS_CONFIG_NAME)
-	configFilename := filepath.Join(baseDir, contName, CONTROLLER_CONFIG_NAME)
+	configDir := filepath.Join(baseDir, contName)
 
-	if err := os.MkdirAll(filepath.Dir(configFilename), DEFAULT_DIR_MODE); err != nil && !os.IsExist(err) {
+	if err := os.MkdirAll(configDir, DEFAULT_DIR_MODE); err != nil && !os.IsExist(err) {
 		return nil, err
 	}
 
 	containerID := ""
 	err := wait.Poll(time.Second, time.Minute, func() (done bool, err error) {
-		activePods := watchdog.GetActivePods()
+		activePods := vm.podLister.GetActivePods()
 		pod, ok := activePods[podUID]
 		if !ok {
 			return false, fmt.Errorf("can't locate %s", podUID)
@@ -433,7 +442,7 @@ func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName stri
 			}
 		}
 
-		containerID = strings.TrimPrefix(containerID, "docker://")
+		containerID = runtime.TrimContainerIDPrefix(containerID)
 
 		if len(containerID) == 0 {
 			klog.Errorf("can't locate %s(%s)", podUID, contName)
@@ -447,11 +456,15 @@ func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName stri
 		return nil, err
 	}
 
-	if err := vm.writePidFile(pidFilename, containerID); err != nil {
+	if containerID != callerID {
+		return nil, vm.reject(rejectReasonContMismatch, podUID, contName, "caller belongs to container %s", callerID)
+	}
+
+	if err := vm.writePidFile(podUID, configDir, containerID); err != nil {
 		return nil, err
 	}
 
-	if err := vm.writeConfigFile(configFilename, podUID, contName); err != nil {
+	if err := vm.writeConfigFile(configDir, podUID, contName); err != nil {
 		return nil, err
 	}
 
@@ -459,129 +472,181 @@ func (vm *VirtualManager) registerVDeviceWithContainerName(podUID, contName stri
 }
 
 //RegisterVDevice handles RPC calls from vcuda client
-func (vm *VirtualManager) RegisterVDevice(_ context.Context, req *vcudaapi.VDeviceRequest) (*vcudaapi.VDeviceResponse, error) {
+func (vm *VirtualManager) RegisterVDevice(ctx context.Context, req *vcudaapi.VDeviceRequest) (*vcudaapi.VDeviceResponse, error) {
 	podUID := req.PodUid
 	contName := req.ContainerName
 	contID := req.ContainerId
 
+	callerID, err := vm.authenticate(ctx, podUID, contName, contID)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(contName) > 0 {
-		return vm.registerVDeviceWithContainerName(podUID, contName)
+		return vm.registerVDeviceWithContainerName(podUID, contName, callerID)
 	}
 
 	return vm.registerVDeviceWithContainerId(podUID, contID)
 }
 
-func (vm *VirtualManager) writePidFile(filename string, contID string) error {
-	klog.V(2).Infof("Write %s", filename)
-	cFileName := C.CString(filename)
-	defer C.free(unsafe.Pointer(cFileName))
-
-	pidsInContainer, err := vm.containerRuntimeManager.GetPidsInContainers(contID)
-	if err != nil {
-		return err
-	}
-	if len(pidsInContainer) == 0 {
-		return fmt.Errorf("empty pids")
+//writeConfigFile writes config of container to both legacy and versioned
+//config files in dir, existing files are kept
+func (vm *VirtualManager) writeConfigFile(dir string, podUID, name string) error {
+	files := map[string]func(*vcudaconfig.Config) ([]byte, error){
+		CONTROLLER_CONFIG_NAME:           vcudaconfig.EncodeLegacyConfig,
+		VERSIONED_CONTROLLER_CONFIG_NAME: vcudaconfig.EncodeConfig,
 	}
-	pids := make([]C.int, len(pidsInContainer))
 
-	for i := range pidsInContainer {
-		pids[i] = C.int(pidsInContainer[i])
-	}
+	var config *vcudaconfig.Config
+	for file, encode := range files {
+		filename := filepath.Join(dir, file)
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		if config == nil {
+			containerConfig, err := vm.containerConfig(podUID, name)
+			if err != nil {
+				return err
+			}
+			config = fileConfig(containerConfig)
+		}
+
+		data, err := encode(config)
+		if err != nil {
+			return err
+		}
 
-	if C.pids_to_disk(cFileName, &pids[0], (C.int)(len(pids))) != 0 {
-		return fmt.Errorf("can't sink pids file")
+		if err := writeFileAtomic(filename, data); err != nil {
+			return fmt.Errorf("can't sink config %s, %v", filename, err)
+		}
 	}
 
 	return nil
 }
 
-func (vm *VirtualManager) writeConfigFile(filename string, podUID, name string) error {
-	if _, err := os.Stat(filename); err != nil {
-		if !os.IsNotExist(err) {
-			return err
-		}
+//containerConfig returns the effective limits of container name in pod
+//podUID, pids and generation are left empty
+func (vm *VirtualManager) containerConfig(podUID, name string) (*vcudaapi.ContainerConfig, error) {
+	activePods := vm.podLister.GetActivePods()
+	pod, ok := activePods[podUID]
+	if !ok {
+		return nil, fmt.Errorf("can't locate %s", podUID)
+	}
 
-		activePods := watchdog.GetActivePods()
-		pod, ok := activePods[podUID]
-		if !ok {
-			return fmt.Errorf("can't locate %s", podUID)
-		}
+	hasLimitCore := false
+	limitCores := 100
 
-		hasLimitCore := false
-		limitCores := 100
+	if pod.Annotations != nil {
+		limitData, ok := pod.Annotations[types.VCoreLimitAnnotation]
+		if ok {
+			hasLimitCore = true
+			limit, err := strconv.Atoi(limitData)
+			if err != nil {
+				return nil, err
+			}
 
-		if pod.Annotations != nil {
-			limitData, ok := pod.Annotations[types.VCoreLimitAnnotation]
-			if ok {
-				hasLimitCore = true
-				limit, err := strconv.Atoi(limitData)
-				if err != nil {
-					return err
-				}
+			if limit < limitCores {
+				limitCores = limit
+			}
+		}
+	}
 
-				if limit < limitCores {
-					limitCores = limit
-				}
+	qos, err := utils.GetQoSClassOfPod(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	uvmSwap := false
+	allocatedMemory := int64(-1)
+	if resp := vm.responseManager.GetResp(podUID, name); resp != nil {
+		uvmSwap = resp.Annotations[types.VMemorySwapAnnotation] == "true"
+		// Allocated memory is in bytes, it doesn't depend on block size
+		if data, ok := resp.Annotations[types.VMemoryAnnotation]; ok {
+			if allocatedMemory, err = strconv.ParseInt(data, 10, 64); err != nil {
+				return nil, err
 			}
 		}
+	}
 
-		found := false
-		for _, cont := range pod.Spec.Containers {
-			if cont.Name == name || strings.HasPrefix(name, utils.MakeContainerNamePrefix(cont.Name)) {
-				found = true
-				coresLimit := cont.Resources.Limits[types.VCoreAnnotation]
-				cores := (&coresLimit).Value()
-				memoryLimit := cont.Resources.Limits[types.VMemoryAnnotation]
-				memory := (&memoryLimit).Value() * types.MemoryBlockSize
-
-				if err := func() error {
-					var vcudaConfig C.struct_resource_data_t
-
-					cPodUID := C.CString(podUID)
-					cContName := C.CString(name)
-					cFileName := C.CString(filename)
-
-					defer C.free(unsafe.Pointer(cPodUID))
-					defer C.free(unsafe.Pointer(cContName))
-					defer C.free(unsafe.Pointer(cFileName))
-
-					C.strcpy(&vcudaConfig.pod_uid[0], (*C.char)(unsafe.Pointer(cPodUID)))
-					C.strcpy(&vcudaConfig.container_name[0], (*C.char)(unsafe.Pointer(cContName)))
-					vcudaConfig.gpu_memory = C.uint64_t(memory)
-					vcudaConfig.utilization = C.int(cores)
-					vcudaConfig.hard_limit = 1
-					vcudaConfig.driver_version.major = C.int(types.DriverVersionMajor)
-					vcudaConfig.driver_version.minor = C.int(types.DriverVersionMinor)
-
-					if cores >= nvidia.HundredCore {
-						vcudaConfig.enable = 0
-					} else {
-						vcudaConfig.enable = 1
-					}
+	for _, cont := range pod.Spec.Containers {
+		if cont.Name != name && !strings.HasPrefix(name, utils.MakeContainerNamePrefix(cont.Name)) {
+			continue
+		}
 
-					if hasLimitCore {
-						vcudaConfig.hard_limit = 0
-						vcudaConfig.limit = C.int(limitCores)
-					}
+		coresLimit := cont.Resources.Limits[types.VCoreAnnotation]
+		cores := (&coresLimit).Value()
+		memoryLimit := cont.Resources.Limits[types.VMemoryAnnotation]
+		memory := (&memoryLimit).Value() * vm.cfg.GetMemoryBlockSize()
+		if allocatedMemory >= 0 {
+			memory = allocatedMemory
+		}
 
-					if C.setting_to_disk(cFileName, &vcudaConfig) != 0 {
-						return fmt.Errorf("can't sink config %s", filename)
-					}
+		config := &vcudaapi.ContainerConfig{
+			PodUid:        podUID,
+			ContainerName: name,
+			GpuMemory:     uint64(memory),
+			Utilization:   int32(cores),
+			HardLimit:     true,
+			Enable:        cores < nvidia.HundredCore,
+			QosClass:      string(qos),
+			// Memory beyond physical memory of oversubscribed cards
+			// is backed by unified memory
+			UvmSwap: uvmSwap,
+		}
 
-					return nil
-				}(); err != nil {
-					return err
-				}
-			}
+		if hasLimitCore {
+			config.HardLimit = false
+			config.Limit = int32(limitCores)
 		}
 
-		if !found {
-			return fmt.Errorf("can't locate %s(%s)", podUID, name)
+		// Best-effort work only runs in idle time slices
+		switch {
+		case qos == types.QoSBestEffort:
+			config.MinUtilization = 0
+		case cores >= nvidia.HundredCore:
+			config.MinUtilization = nvidia.HundredCore
+		default:
+			config.MinUtilization = int32(cores)
 		}
+
+		return config, nil
 	}
 
-	return nil
+	return nil, fmt.Errorf("can't locate %s(%s)", podUID, name)
+}
+
+//fileConfig converts config to the one written to files
+func fileConfig(config *vcudaapi.ContainerConfig) *vcudaconfig.Config {
+	return &vcudaconfig.Config{
+		PodUID:         config.PodUid,
+		ContainerName:  config.ContainerName,
+		GPUMemory:      config.GpuMemory,
+		Utilization:    config.Utilization,
+		Limit:          config.Limit,
+		MinUtilization: config.MinUtilization,
+		QoSClass:       qosClassValue(types.QoSClass(config.QosClass)),
+		DriverMajor:    int32(types.DriverVersionMajor),
+		DriverMinor:    int32(types.DriverVersionMinor),
+		HardLimit:      config.HardLimit,
+		Enable:         config.Enable,
+		UVMSwap:        config.UvmSwap,
+	}
+}
+
+func qosClassValue(qos types.QoSClass) int32 {
+	switch qos {
+	case types.QoSGuaranteed:
+		return vcudaconfig.QoSGuaranteed
+	case types.QoSBurstable:
+		return vcudaconfig.QoSBurstable
+	case types.QoSBestEffort:
+		return vcudaconfig.QoSBestEffort
+	}
+
+	return vcudaconfig.QoSUnspecified
 }
 
 func runVDeviceServer(dir string, handler vcudaapi.VCUDAServiceServer) *grpc.Server {
@@ -598,12 +663,14 @@ func runVDeviceServer(dir string, handler vcudaapi.VCUDAServiceServer) *grpc.Ser
 		return nil
 	}
 
+	// Any user of container can connect, caller is authenticated by its
+	// peer credentials
 	if err := os.Chmod(socketFile, DEFAULT_DIR_MODE); err != nil {
 		klog.Errorf("chmod %s failed, %v", socketFile, err)
 		return nil
 	}
 
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.Creds(peerCredentials{}))
 	vcudaapi.RegisterVCUDAServiceServer(srv, handler)
 
 	ch := make(chan error, 1)
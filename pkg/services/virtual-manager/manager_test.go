@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package vitrual_manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/runtime"
+	"tkestack.io/gpu-manager/pkg/services/response"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestRecycleDirectory(t *testing.T) {
+	root, err := ioutil.TempDir("", "vm")
+	if err != nil {
+		t.Fatalf("can't create dir, %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	newPod := func(name string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", UID: k8stypes.UID(name + "-uid")},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Name: "test-container",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						types.VCoreAnnotation:   resource.MustParse("10"),
+						types.VMemoryAnnotation: resource.MustParse("1"),
+					},
+				},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	running, finished := newPod("running"), newPod("finished")
+	for _, pod := range []*v1.Pod{running, finished} {
+		if err := os.MkdirAll(filepath.Join(root, string(pod.UID)), DEFAULT_DIR_MODE); err != nil {
+			t.Fatalf("can't create dir, %v", err)
+		}
+	}
+
+	podLister := watchdog.NewFakePodLister(running, finished)
+	cfg := &config.Config{VirtualManagerPath: root, RecycleGracePeriod: 100 * time.Millisecond}
+	vm := NewVirtualManagerForTest(cfg, runtime.NewContainerRuntimeManagerStub(), response.NewFakeResponseManager(), podLister)
+	podLister.AddEventHandler(watchdog.PodTerminationHandler(vm.onPodTerminated))
+	go wait.Until(vm.runRecycle, time.Second, vm.stopCh)
+	defer vm.Stop()
+
+	done := finished.DeepCopy()
+	done.Status.Phase = v1.PodSucceeded
+	podLister.Update(done)
+
+	err = wait.PollImmediate(50*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := os.Stat(filepath.Join(root, string(finished.UID)))
+		return os.IsNotExist(err), nil
+	})
+	if err != nil {
+		t.Fatalf("directory of terminated pod is not removed")
+	}
+
+	if _, err := os.Stat(filepath.Join(root, string(running.UID))); err != nil {
+		t.Fatalf("directory of running pod should be kept, %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vitrual_manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"tkestack.io/gpu-manager/pkg/services/virtual-manager/vcudaconfig"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	pidsRefreshPeriod = 5 * time.Second
+)
+
+//pidFile is the pid files of a registered container
+type pidFile struct {
+	podUID string
+	dir    string
+	pids   []int32
+}
+
+//writePidFile writes pids of container to both legacy and versioned pid
+//files in dir, the files are refreshed afterwards until the pod is gone
+func (vm *VirtualManager) writePidFile(podUID, dir, contID string) error {
+	klog.V(2).Infof("Write pids of %s to %s", contID, dir)
+
+	pids, err := vm.pidsOfContainer(contID)
+	if err != nil {
+		return err
+	}
+	if len(pids) == 0 {
+		return fmt.Errorf("empty pids")
+	}
+
+	if err := writePids(dir, pids); err != nil {
+		return err
+	}
+
+	vm.pidFilesLock.Lock()
+	vm.pidFiles[contID] = &pidFile{podUID: podUID, dir: dir, pids: pids}
+	vm.pidFilesLock.Unlock()
+
+	return nil
+}
+
+//pidsRefresher rewrites pid files of registered containers when processes
+//are forked or exit
+func (vm *VirtualManager) pidsRefresher() {
+	klog.V(2).Infof("Starting pids refresher")
+	wait.Until(vm.refreshPidFiles, pidsRefreshPeriod, vm.stopCh)
+}
+
+func (vm *VirtualManager) refreshPidFiles() {
+	activePods := vm.podLister.GetActivePods()
+
+	vm.pidFilesLock.Lock()
+	files := make(map[string]pidFile, len(vm.pidFiles))
+	for contID, file := range vm.pidFiles {
+		if _, err := os.Stat(file.dir); err != nil || activePods[file.podUID] == nil {
+			klog.V(2).Infof("Stop refreshing pids of %s/%s", file.podUID, contID)
+			delete(vm.pidFiles, contID)
+			continue
+		}
+		files[contID] = *file
+	}
+	vm.pidFilesLock.Unlock()
+
+	for contID, file := range files {
+		pids, err := vm.pidsOfContainer(contID)
+		if err != nil || len(pids) == 0 || reflect.DeepEqual(pids, file.pids) {
+			continue
+		}
+
+		klog.V(4).Infof("Refresh pids of %s/%s, %v", file.podUID, contID, pids)
+		if err := writePids(file.dir, pids); err != nil {
+			klog.Errorf("can't refresh pids of %s/%s, %v", file.podUID, contID, err)
+			continue
+		}
+
+		vm.pidFilesLock.Lock()
+		if current, ok := vm.pidFiles[contID]; ok {
+			current.pids = pids
+		}
+		vm.pidFilesLock.Unlock()
+	}
+}
+
+func (vm *VirtualManager) pidsOfContainer(contID string) ([]int32, error) {
+	pidsInContainer, err := vm.containerRuntimeManager.GetPidsInContainers(contID)
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make([]int32, len(pidsInContainer))
+	for i := range pidsInContainer {
+		pids[i] = int32(pidsInContainer[i])
+	}
+
+	return pids, nil
+}
+
+func writePids(dir string, pids []int32) error {
+	if err := writeFileAtomic(filepath.Join(dir, PIDS_CONFIG_NAME), vcudaconfig.EncodeLegacyPids(pids)); err != nil {
+		return fmt.Errorf("can't sink pids file, %v", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, VERSIONED_PIDS_CONFIG_NAME), vcudaconfig.EncodePids(pids)); err != nil {
+		return fmt.Errorf("can't sink versioned pids file, %v", err)
+	}
+
+	return nil
+}
+
+//writeFileAtomic writes data to a temporary file and renames it to filename,
+//so readers never see a partial file
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, DEFAULT_DIR_MODE); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, filename)
+}
@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vitrual_manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"tkestack.io/gpu-manager/pkg/config"
+	"tkestack.io/gpu-manager/pkg/services/response"
+	"tkestack.io/gpu-manager/pkg/services/virtual-manager/vcudaconfig"
+	"tkestack.io/gpu-manager/pkg/services/watchdog"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+type fakeRuntime struct {
+	sync.Mutex
+	pids map[string][]int
+}
+
+func (r *fakeRuntime) GetPidsInContainers(containerID string) ([]int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.pids[containerID], nil
+}
+
+func (r *fakeRuntime) InspectContainer(containerID string) (*criapi.ContainerStatus, error) {
+	return nil, nil
+}
+
+func (r *fakeRuntime) RuntimeName() string { return "fake" }
+
+func (r *fakeRuntime) setPids(containerID string, pids ...int) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pids[containerID] = pids
+}
+
+func readPidFiles(t *testing.T, dir string) ([]int32, []int32) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, PIDS_CONFIG_NAME))
+	if err != nil {
+		t.Fatalf("can't read pids file, %v", err)
+	}
+	legacy, err := vcudaconfig.DecodeLegacyPids(data)
+	if err != nil {
+		t.Fatalf("can't decode pids file, %v", err)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, VERSIONED_PIDS_CONFIG_NAME))
+	if err != nil {
+		t.Fatalf("can't read versioned pids file, %v", err)
+	}
+	versioned, err := vcudaconfig.DecodePids(data)
+	if err != nil {
+		t.Fatalf("can't decode versioned pids file, %v", err)
+	}
+
+	return legacy, versioned
+}
+
+func TestRefreshPidFiles(t *testing.T) {
+	podUID := "testuid"
+	contID := "testcontainer"
+
+	podLister := watchdog.NewFakePodLister(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "testpod", Namespace: "test-ns", UID: k8stypes.UID(podUID)},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name: "test-container",
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					types.VCoreAnnotation:   resource.MustParse("1"),
+					types.VMemoryAnnotation: resource.MustParse("1"),
+				},
+			},
+		}}},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	})
+
+	dir, err := ioutil.TempDir("", "vm")
+	if err != nil {
+		t.Fatalf("can't create dir, %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runtime := &fakeRuntime{pids: make(map[string][]int)}
+	vm := NewVirtualManagerForTest(&config.Config{}, runtime, response.NewFakeResponseManager(), podLister)
+
+	runtime.setPids(contID, 10)
+	if err := vm.writePidFile(podUID, dir, contID); err != nil {
+		t.Fatalf("write pids failed, %v", err)
+	}
+
+	// a process is forked after registration
+	runtime.setPids(contID, 10, 11)
+	vm.refreshPidFiles()
+
+	expected := []int32{10, 11}
+	legacy, versioned := readPidFiles(t, dir)
+	if !reflect.DeepEqual(legacy, expected) || !reflect.DeepEqual(versioned, expected) {
+		t.Fatalf("expect %v, got %v and %v", expected, legacy, versioned)
+	}
+
+	// no temporary file is left
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Fatalf("expect 2 files, got %d", len(files))
+	}
+
+	// stop refreshing once the directory is removed
+	os.RemoveAll(dir)
+	vm.refreshPidFiles()
+	if len(vm.pidFiles) != 0 {
+		t.Fatalf("expect no pid file, got %d", len(vm.pidFiles))
+	}
+}
@@ -0,0 +1,219 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vitrual_manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	vcudaapi "tkestack.io/gpu-manager/pkg/api/runtime/vcuda"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	watchConfigPeriod = 5 * time.Second
+	containerStateTTL = 10 * time.Minute
+)
+
+var (
+	processMemoryDesc = prometheus.NewDesc("vcuda_process_memory_used_bytes",
+		"GPU memory used by process reported by vcuda library",
+		[]string{"pod_uid", "container_id", "pid", "bus_id"}, nil)
+	processSMTimeDesc = prometheus.NewDesc("vcuda_process_sm_time_seconds_total",
+		"Accumulated SM time of process reported by vcuda library",
+		[]string{"pod_uid", "container_id", "pid", "bus_id"}, nil)
+)
+
+//containerState is the latest config sent to and usage reported by a
+//container
+type containerState struct {
+	podUID   string
+	config   *vcudaapi.ContainerConfig
+	usage    []*vcudaapi.ProcessUsage
+	lastSeen time.Time
+}
+
+//GetConfig returns the effective limits of caller
+func (vm *VirtualManager) GetConfig(ctx context.Context, req *vcudaapi.ConfigRequest) (*vcudaapi.ContainerConfig, error) {
+	contID, name, err := vm.resolveContainer(ctx, req.PodUid, req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return vm.refreshConfig(req.PodUid, contID, name)
+}
+
+//WatchConfig sends the effective limits of caller every time they change
+func (vm *VirtualManager) WatchConfig(req *vcudaapi.ConfigRequest, stream vcudaapi.VCUDAService_WatchConfigServer) error {
+	ctx := stream.Context()
+	contID, name, err := vm.resolveContainer(ctx, req.PodUid, req.ContainerId)
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("UID: %s, cont: %s start watching config", req.PodUid, contID)
+	ticker := time.NewTicker(watchConfigPeriod)
+	defer ticker.Stop()
+
+	sent := int64(0)
+	for {
+		config, err := vm.refreshConfig(req.PodUid, contID, name)
+		if err != nil {
+			return err
+		}
+
+		if config.Generation != sent {
+			if err := stream.Send(config); err != nil {
+				return err
+			}
+			sent = config.Generation
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		case <-vm.stopCh:
+			return nil
+		}
+	}
+}
+
+//ReportUsage records the actual usage of processes in caller
+func (vm *VirtualManager) ReportUsage(ctx context.Context, req *vcudaapi.UsageRequest) (*vcudaapi.UsageResponse, error) {
+	contID, err := vm.authenticate(ctx, req.PodUid, "", req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.containersLock.Lock()
+	defer vm.containersLock.Unlock()
+
+	state := vm.containerStateOf(req.PodUid, contID)
+	state.usage = req.Processes
+
+	return &vcudaapi.UsageResponse{}, nil
+}
+
+//Heartbeat returns the generation of config of caller, so caller can reload
+//config only if it's changed
+func (vm *VirtualManager) Heartbeat(ctx context.Context, req *vcudaapi.HeartbeatRequest) (*vcudaapi.HeartbeatResponse, error) {
+	contID, name, err := vm.resolveContainer(ctx, req.PodUid, req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := vm.refreshConfig(req.PodUid, contID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vcudaapi.HeartbeatResponse{Generation: config.Generation}, nil
+}
+
+//resolveContainer authenticates caller and returns its container ID and name
+func (vm *VirtualManager) resolveContainer(ctx context.Context, podUID, contID string) (string, string, error) {
+	callerID, err := vm.authenticate(ctx, podUID, "", contID)
+	if err != nil {
+		return "", "", err
+	}
+
+	containerInfo, err := vm.containerRuntimeManager.InspectContainer(callerID)
+	if err != nil {
+		return "", "", fmt.Errorf("can't find %s from runtime", callerID)
+	}
+
+	return callerID, containerInfo.Metadata.Name, nil
+}
+
+//refreshConfig computes the config of container and increases its generation
+//if it's different from the last one
+func (vm *VirtualManager) refreshConfig(podUID, contID, name string) (*vcudaapi.ContainerConfig, error) {
+	config, err := vm.containerConfig(podUID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pids, err := vm.pidsOfContainer(contID)
+	if err != nil {
+		return nil, err
+	}
+
+	config.ContainerId = contID
+	config.Pids = pids
+
+	vm.containersLock.Lock()
+	defer vm.containersLock.Unlock()
+
+	state := vm.containerStateOf(podUID, contID)
+	if state.config != nil {
+		config.Generation = state.config.Generation
+	}
+	if state.config == nil || !proto.Equal(config, state.config) {
+		config.Generation++
+		state.config = config
+	}
+
+	return proto.Clone(config).(*vcudaapi.ContainerConfig), nil
+}
+
+//containerStateOf returns the state of container, containersLock must be held
+func (vm *VirtualManager) containerStateOf(podUID, contID string) *containerState {
+	state, ok := vm.containers[contID]
+	if !ok {
+		state = &containerState{podUID: podUID}
+		vm.containers[contID] = state
+	}
+	state.lastSeen = time.Now()
+
+	return state
+}
+
+//pruneContainers drops states of containers whose pod is not active or which
+//have not called for a while
+func (vm *VirtualManager) pruneContainers(activePods map[string]*v1.Pod) {
+	vm.containersLock.Lock()
+	defer vm.containersLock.Unlock()
+
+	for contID, state := range vm.containers {
+		if _, ok := activePods[state.podUID]; !ok || time.Since(state.lastSeen) > containerStateTTL {
+			klog.V(2).Infof("Remove state of %s/%s", state.podUID, contID)
+			delete(vm.containers, contID)
+		}
+	}
+}
+
+func (vm *VirtualManager) collectUsage(ch chan<- prometheus.Metric) {
+	vm.containersLock.Lock()
+	defer vm.containersLock.Unlock()
+
+	for contID, state := range vm.containers {
+		for _, usage := range state.usage {
+			labels := []string{state.podUID, contID, strconv.Itoa(int(usage.Pid)), usage.BusId}
+			ch <- prometheus.MustNewConstMetric(processMemoryDesc, prometheus.GaugeValue,
+				float64(usage.UsedMemory), labels...)
+			ch <- prometheus.MustNewConstMetric(processSMTimeDesc, prometheus.CounterValue,
+				float64(usage.SmTime)/float64(time.Second/time.Microsecond), labels...)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+// Package vcudaconfig encodes the config files read by vcuda library.
+//
+// Two formats are written side by side. The legacy one is the packed
+// resource_data_t struct and raw int array of old vcuda library. The
+// versioned one starts with a 16 bytes header, all integers are little
+// endian:
+//
+//	offset 0   magic, "VCFG" for config and "VPID" for pids
+//	offset 4   uint16 version
+//	offset 6   uint16 header size
+//	offset 8   uint32 payload size
+//	offset 12  uint32 CRC-32 (IEEE) of payload
+//
+// Payload of config version 1:
+//
+//	uint64 gpu memory in bytes
+//	int32  utilization, 100 per card
+//	int32  soft limit of utilization
+//	int32  min utilization
+//	int32  qos class
+//	int32  driver version major
+//	int32  driver version minor
+//	uint32 flags, bit 0 hard limit, bit 1 enable, bit 2 uvm swap
+//	string pod uid, uint16 length followed by bytes
+//	string container name
+//
+// Payload of pids version 1 is an uint32 count followed by int32 pids.
+//
+// Later versions only append fields to payload, readers use the fields they
+// know and skip the rest by payload size.
+package vcudaconfig
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	ConfigMagic = "VCFG"
+	PidsMagic   = "VPID"
+	Version     = 1
+	HeaderSize  = 16
+)
+
+//QoS class values shared with vcuda library
+const (
+	QoSUnspecified int32 = iota
+	QoSGuaranteed
+	QoSBurstable
+	QoSBestEffort
+)
+
+const (
+	flagHardLimit = 1 << iota
+	flagEnable
+	flagUVMSwap
+)
+
+//configV1Size is the payload size of config version 1 without strings
+const configV1Size = 8 + 4*6 + 4
+
+//Config is the settings of a container read by vcuda library
+type Config struct {
+	PodUID         string
+	ContainerName  string
+	GPUMemory      uint64
+	Utilization    int32
+	Limit          int32
+	MinUtilization int32
+	QoSClass       int32
+	DriverMajor    int32
+	DriverMinor    int32
+	HardLimit      bool
+	Enable         bool
+	UVMSwap        bool
+}
+
+//EncodeConfig returns the versioned encoding of c
+func EncodeConfig(c *Config) ([]byte, error) {
+	payload := &bytes.Buffer{}
+
+	flags := uint32(0)
+	if c.HardLimit {
+		flags |= flagHardLimit
+	}
+	if c.Enable {
+		flags |= flagEnable
+	}
+	if c.UVMSwap {
+		flags |= flagUVMSwap
+	}
+
+	for _, v := range []interface{}{c.GPUMemory, c.Utilization, c.Limit, c.MinUtilization,
+		c.QoSClass, c.DriverMajor, c.DriverMinor, flags} {
+		binary.Write(payload, binary.LittleEndian, v)
+	}
+
+	for _, s := range []string{c.PodUID, c.ContainerName} {
+		if len(s) > 0xffff {
+			return nil, fmt.Errorf("string %s is too long", s)
+		}
+		binary.Write(payload, binary.LittleEndian, uint16(len(s)))
+		payload.WriteString(s)
+	}
+
+	return encode(ConfigMagic, payload.Bytes()), nil
+}
+
+//DecodeConfig decodes the versioned encoding of config
+func DecodeConfig(data []byte) (*Config, error) {
+	payload, err := decode(ConfigMagic, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < configV1Size {
+		return nil, fmt.Errorf("config payload is too short, %d bytes", len(payload))
+	}
+
+	c := &Config{}
+	r := bytes.NewReader(payload)
+	flags := uint32(0)
+	for _, v := range []interface{}{&c.GPUMemory, &c.Utilization, &c.Limit, &c.MinUtilization,
+		&c.QoSClass, &c.DriverMajor, &c.DriverMinor, &flags} {
+		binary.Read(r, binary.LittleEndian, v)
+	}
+	c.HardLimit = flags&flagHardLimit != 0
+	c.Enable = flags&flagEnable != 0
+	c.UVMSwap = flags&flagUVMSwap != 0
+
+	for _, s := range []*string{&c.PodUID, &c.ContainerName} {
+		n := uint16(0)
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("config payload is truncated")
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("config payload is truncated")
+		}
+		*s = string(buf)
+	}
+
+	return c, nil
+}
+
+//EncodePids returns the versioned encoding of pids
+func EncodePids(pids []int32) []byte {
+	payload := &bytes.Buffer{}
+	binary.Write(payload, binary.LittleEndian, uint32(len(pids)))
+	binary.Write(payload, binary.LittleEndian, pids)
+
+	return encode(PidsMagic, payload.Bytes())
+}
+
+//DecodePids decodes the versioned encoding of pids
+func DecodePids(data []byte) ([]int32, error) {
+	payload, err := decode(PidsMagic, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("pids payload is too short, %d bytes", len(payload))
+	}
+
+	n := binary.LittleEndian.Uint32(payload)
+	if uint64(len(payload)-4) < uint64(n)*4 {
+		return nil, fmt.Errorf("pids payload is truncated, expect %d pids", n)
+	}
+
+	pids := make([]int32, n)
+	for i := range pids {
+		pids[i] = int32(binary.LittleEndian.Uint32(payload[4+4*i:]))
+	}
+
+	return pids, nil
+}
+
+func encode(magic string, payload []byte) []byte {
+	data := make([]byte, HeaderSize, HeaderSize+len(payload))
+	copy(data, magic)
+	binary.LittleEndian.PutUint16(data[4:], Version)
+	binary.LittleEndian.PutUint16(data[6:], HeaderSize)
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[12:], crc32.ChecksumIEEE(payload))
+
+	return append(data, payload...)
+}
+
+//decode verifies header and returns payload
+func decode(magic string, data []byte) ([]byte, error) {
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("data is too short, %d bytes", len(data))
+	}
+
+	if string(data[:4]) != magic {
+		return nil, fmt.Errorf("unexpected magic %q, expect %q", data[:4], magic)
+	}
+
+	version := binary.LittleEndian.Uint16(data[4:])
+	if version < Version {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+
+	headerSize := int(binary.LittleEndian.Uint16(data[6:]))
+	payloadSize := int(binary.LittleEndian.Uint32(data[8:]))
+	if headerSize < HeaderSize || len(data) < headerSize+payloadSize {
+		return nil, fmt.Errorf("data is truncated, expect %d bytes, got %d", headerSize+payloadSize, len(data))
+	}
+
+	payload := data[headerSize : headerSize+payloadSize]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[12:]) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+
+	return payload, nil
+}
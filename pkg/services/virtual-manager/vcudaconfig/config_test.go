@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vcudaconfig
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files of versioned format")
+
+//testdata/legacy-*.config are written by setting_to_disk and pids_to_disk of
+//vcuda library with the same values
+var (
+	goldenConfig = &Config{
+		PodUID:         "0d1e5a3f-2c4b-4e8a-9f10-1234567890ab",
+		ContainerName:  "k8s_cuda_vcuda-test_default_0d1e5a3f-2c4b-4e8a-9f10-1234567890ab_0",
+		GPUMemory:      3 << 30,
+		Utilization:    50,
+		Limit:          30,
+		MinUtilization: 50,
+		QoSClass:       QoSBurstable,
+		DriverMajor:    418,
+		DriverMinor:    67,
+		Enable:         true,
+		UVMSwap:        true,
+	}
+	goldenPids = []int32{1, 42, 65535, 4194304}
+)
+
+func checkGolden(t *testing.T, name string, data []byte, updatable bool) {
+	filename := filepath.Join("testdata", name)
+	if updatable && *update {
+		if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+			t.Fatalf("can't update %s, %v", filename, err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("can't read %s, %v", filename, err)
+	}
+
+	if !bytes.Equal(expected, data) {
+		t.Fatalf("%s mismatch, expect %d bytes, got %d bytes", name, len(expected), len(data))
+	}
+}
+
+func TestLegacyFormat(t *testing.T) {
+	data, err := EncodeLegacyConfig(goldenConfig)
+	if err != nil {
+		t.Fatalf("encode failed, %v", err)
+	}
+	checkGolden(t, "legacy-vcuda.config", data, false)
+
+	// fields appended later are only in the versioned format
+	legacyConfig := *goldenConfig
+	legacyConfig.QoSClass, legacyConfig.MinUtilization, legacyConfig.UVMSwap = 0, 0, false
+
+	config, err := DecodeLegacyConfig(data)
+	if err != nil || !reflect.DeepEqual(config, &legacyConfig) {
+		t.Fatalf("expect %+v, got %+v, %v", legacyConfig, config, err)
+	}
+
+	data = EncodeLegacyPids(goldenPids)
+	checkGolden(t, "legacy-pids.config", data, false)
+
+	pids, err := DecodeLegacyPids(data)
+	if err != nil || !reflect.DeepEqual(pids, goldenPids) {
+		t.Fatalf("expect %v, got %v, %v", goldenPids, pids, err)
+	}
+
+	if _, err := EncodeLegacyConfig(&Config{PodUID: string(make([]byte, legacyPodUIDSize))}); err == nil {
+		t.Fatalf("expect error for too long pod uid")
+	}
+}
+
+func TestLegacyLayout(t *testing.T) {
+	// fields of resource_data_t in the released vcuda library
+	fields := []struct {
+		name   string
+		size   int
+		offset int
+	}{
+		{"pod_uid", 48, legacyPodUIDOffset},
+		{"limit", 4, legacyLimitOffset},
+		{"occupied", 4044, -1},
+		{"container_name", 4096, legacyContainerNameOffset},
+		{"bus_id", 16, -1},
+		{"gpu_memory", 8, legacyGPUMemoryOffset},
+		{"utilization", 4, legacyUtilizationOffset},
+		{"hard_limit", 4, legacyHardLimitOffset},
+		{"driver_version.major", 4, legacyDriverMajorOffset},
+		{"driver_version.minor", 4, legacyDriverMinorOffset},
+		{"enable", 4, legacyEnableOffset},
+	}
+
+	size := 0
+	for _, f := range fields {
+		if f.offset >= 0 && f.offset != size {
+			t.Errorf("offset of %s should be %d, got %d", f.name, size, f.offset)
+		}
+		size += f.size
+	}
+
+	// aligned(8) pads the packed struct
+	size = (size + 7) / 8 * 8
+	if LegacyConfigSize != size {
+		t.Fatalf("legacy config size should be %d, got %d", size, LegacyConfigSize)
+	}
+}
+
+func TestVersionedFormat(t *testing.T) {
+	data, err := EncodeConfig(goldenConfig)
+	if err != nil {
+		t.Fatalf("encode failed, %v", err)
+	}
+	checkGolden(t, "vcuda.v1.config", data, true)
+
+	config, err := DecodeConfig(data)
+	if err != nil || !reflect.DeepEqual(config, goldenConfig) {
+		t.Fatalf("expect %+v, got %+v, %v", goldenConfig, config, err)
+	}
+
+	data = EncodePids(goldenPids)
+	checkGolden(t, "pids.v1.config", data, true)
+
+	pids, err := DecodePids(data)
+	if err != nil || !reflect.DeepEqual(pids, goldenPids) {
+		t.Fatalf("expect %v, got %v, %v", goldenPids, pids, err)
+	}
+}
+
+func TestVersionedFormatCompatibility(t *testing.T) {
+	data, _ := EncodeConfig(goldenConfig)
+
+	// a later version appends fields to payload
+	payload := append(append([]byte{}, data[HeaderSize:]...), 0xde, 0xad, 0xbe, 0xef)
+	newer := encode(ConfigMagic, payload)
+	binary.LittleEndian.PutUint16(newer[4:], Version+1)
+	config, err := DecodeConfig(newer)
+	if err != nil || !reflect.DeepEqual(config, goldenConfig) {
+		t.Fatalf("expect %+v, got %+v, %v", goldenConfig, config, err)
+	}
+
+	corrupted := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte{}, data...))
+	}
+	for name, b := range map[string][]byte{
+		"magic":     corrupted(func(b []byte) []byte { b[0] = 'X'; return b }),
+		"version":   corrupted(func(b []byte) []byte { binary.LittleEndian.PutUint16(b[4:], 0); return b }),
+		"checksum":  corrupted(func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }),
+		"truncated": corrupted(func(b []byte) []byte { return b[:len(b)-1] }),
+		"header":    corrupted(func(b []byte) []byte { return b[:HeaderSize-1] }),
+		"pids":      EncodePids(goldenPids),
+	} {
+		if _, err := DecodeConfig(b); err == nil {
+			t.Fatalf("expect error for %s", name)
+		}
+	}
+}
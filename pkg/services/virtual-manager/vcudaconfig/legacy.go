@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package vcudaconfig
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//Layout of packed resource_data_t of the released vcuda library, int is 4
+//bytes and in little endian on all supported platforms, FILENAME_MAX is 4096
+//and NVML_DEVICE_PCI_BUS_ID_BUFFER_SIZE is 16. The struct ends at enable and
+//is padded to 8240 bytes by aligned(8).
+//
+//	struct resource_data_t {
+//	  char pod_uid[48];
+//	  int limit;
+//	  char occupied[4044];
+//	  char container_name[FILENAME_MAX];
+//	  char bus_id[NVML_DEVICE_PCI_BUS_ID_BUFFER_SIZE];
+//	  uint64_t gpu_memory;
+//	  int utilization;
+//	  int hard_limit;
+//	  struct version_t driver_version;
+//	  int enable;
+//	} __attribute__((packed, aligned(8)));
+//
+//QoS class, min utilization and UVM swap are only in the versioned format,
+//appending them would change the layout old library reads.
+const (
+	legacyPodUIDOffset        = 0
+	legacyPodUIDSize          = 48
+	legacyLimitOffset         = 48
+	legacyContainerNameOffset = 4096
+	legacyContainerNameSize   = 4096
+	legacyGPUMemoryOffset     = 8208
+	legacyUtilizationOffset   = 8216
+	legacyHardLimitOffset     = 8220
+	legacyDriverMajorOffset   = 8224
+	legacyDriverMinorOffset   = 8228
+	legacyEnableOffset        = 8232
+
+	//LegacyConfigSize is sizeof(struct resource_data_t)
+	LegacyConfigSize = 8240
+)
+
+//EncodeLegacyConfig returns c as packed resource_data_t
+func EncodeLegacyConfig(c *Config) ([]byte, error) {
+	// strings are terminated by NUL
+	if len(c.PodUID) >= legacyPodUIDSize {
+		return nil, fmt.Errorf("pod uid %s is too long", c.PodUID)
+	}
+	if len(c.ContainerName) >= legacyContainerNameSize {
+		return nil, fmt.Errorf("container name %s is too long", c.ContainerName)
+	}
+
+	data := make([]byte, LegacyConfigSize)
+	copy(data[legacyPodUIDOffset:], c.PodUID)
+	copy(data[legacyContainerNameOffset:], c.ContainerName)
+	binary.LittleEndian.PutUint64(data[legacyGPUMemoryOffset:], c.GPUMemory)
+
+	for offset, v := range map[int]int32{
+		legacyLimitOffset:       c.Limit,
+		legacyUtilizationOffset: c.Utilization,
+		legacyHardLimitOffset:   boolValue(c.HardLimit),
+		legacyDriverMajorOffset: c.DriverMajor,
+		legacyDriverMinorOffset: c.DriverMinor,
+		legacyEnableOffset:      boolValue(c.Enable),
+	} {
+		binary.LittleEndian.PutUint32(data[offset:], uint32(v))
+	}
+
+	return data, nil
+}
+
+//DecodeLegacyConfig decodes packed resource_data_t
+func DecodeLegacyConfig(data []byte) (*Config, error) {
+	if len(data) != LegacyConfigSize {
+		return nil, fmt.Errorf("unexpected size %d, expect %d", len(data), LegacyConfigSize)
+	}
+
+	int32At := func(offset int) int32 {
+		return int32(binary.LittleEndian.Uint32(data[offset:]))
+	}
+
+	return &Config{
+		PodUID:        cString(data[legacyPodUIDOffset : legacyPodUIDOffset+legacyPodUIDSize]),
+		ContainerName: cString(data[legacyContainerNameOffset : legacyContainerNameOffset+legacyContainerNameSize]),
+		GPUMemory:     binary.LittleEndian.Uint64(data[legacyGPUMemoryOffset:]),
+		Utilization:   int32At(legacyUtilizationOffset),
+		Limit:         int32At(legacyLimitOffset),
+		DriverMajor:   int32At(legacyDriverMajorOffset),
+		DriverMinor:   int32At(legacyDriverMinorOffset),
+		HardLimit:     int32At(legacyHardLimitOffset) != 0,
+		Enable:        int32At(legacyEnableOffset) != 0,
+	}, nil
+}
+
+//EncodeLegacyPids returns pids as raw int array
+func EncodeLegacyPids(pids []int32) []byte {
+	data := make([]byte, 4*len(pids))
+	for i, pid := range pids {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(pid))
+	}
+
+	return data
+}
+
+//DecodeLegacyPids decodes raw int array
+func DecodeLegacyPids(data []byte) ([]int32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("unexpected size %d", len(data))
+	}
+
+	pids := make([]int32, len(data)/4)
+	for i := range pids {
+		pids[i] = int32(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+
+	return pids, nil
+}
+
+func boolValue(b bool) int32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func cString(data []byte) string {
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i])
+		}
+	}
+
+	return string(data)
+}
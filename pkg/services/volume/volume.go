@@ -21,11 +21,14 @@ import (
 	"debug/elf"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"tkestack.io/gpu-manager/pkg/services/volume/ldcache"
 	"tkestack.io/gpu-manager/pkg/types"
@@ -35,12 +38,16 @@ import (
 
 //VolumeManager manages volumes used by containers running GPU application
 type VolumeManager struct {
+	sync.Mutex
+
 	Config  []Config `json:"volume,omitempty"`
 	cfgPath string
 
 	cudaControlFile string
 	cudaSoname      map[string]string
 	mlSoName        map[string]string
+	driverMajor     int
+	driverMinor     int
 	share           bool
 }
 
@@ -76,29 +83,113 @@ type VolumeMap map[string]*Volume
 
 //NewVolumeManager returns a new VolumeManager
 func NewVolumeManager(config string, share bool) (*VolumeManager, error) {
-	f, err := os.Open(config)
+	data, err := ioutil.ReadFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs, err := ParseConfig(data)
 	if err != nil {
 		return nil, err
 	}
 
-	defer f.Close()
+	volumeManager := NewVolumeManagerFromConfig(cfgs, share)
+	volumeManager.cfgPath = filepath.Dir(config)
 
-	volumeManager := &VolumeManager{
-		cfgPath:    filepath.Dir(config),
+	return volumeManager, nil
+}
+
+//NewVolumeManagerFromConfig returns a new VolumeManager with validated config
+func NewVolumeManagerFromConfig(cfgs []Config, share bool) *VolumeManager {
+	return &VolumeManager{
+		Config:     cfgs,
 		cudaSoname: make(map[string]string),
 		mlSoName:   make(map[string]string),
 		share:      share,
 	}
+}
+
+//ParseConfig decodes and validates the content of volume config file
+func ParseConfig(data []byte) ([]Config, error) {
+	vm := &VolumeManager{}
+	if err := json.Unmarshal(data, vm); err != nil {
+		return nil, err
+	}
 
-	if err := json.NewDecoder(f).Decode(volumeManager); err != nil {
+	if err := ValidateConfig(vm.Config); err != nil {
 		return nil, err
 	}
 
-	return volumeManager, nil
+	return vm.Config, nil
 }
 
-//Run starts a VolumeManager
-func (vm *VolumeManager) Run() (err error) {
+//ValidateConfig checks the definition of volumes
+func ValidateConfig(cfgs []Config) error {
+	if len(cfgs) == 0 {
+		return fmt.Errorf("no volume is defined")
+	}
+
+	names := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if len(cfg.Name) == 0 {
+			return fmt.Errorf("volume name is empty")
+		}
+
+		if names[cfg.Name] {
+			return fmt.Errorf("volume %s is duplicated", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		if !filepath.IsAbs(cfg.BasePath) {
+			return fmt.Errorf("base path %q of volume %s must be absolute", cfg.BasePath, cfg.Name)
+		}
+
+		for t := range cfg.Components {
+			if t != "binaries" && t != "libraries" {
+				return fmt.Errorf("unknown component %s of volume %s", t, cfg.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+//Reload validates the new volume config and mirrors the volumes again,
+//the previous volumes are kept if anything goes wrong.
+func (vm *VolumeManager) Reload(data []byte) error {
+	cfgs, err := ParseConfig(data)
+	if err != nil {
+		return err
+	}
+
+	return vm.Update(cfgs)
+}
+
+//Update mirrors the volumes with the given config, the previous volumes
+//are kept if anything goes wrong.
+func (vm *VolumeManager) Update(cfgs []Config) error {
+	if err := ValidateConfig(cfgs); err != nil {
+		return err
+	}
+
+	vm.Lock()
+	defer vm.Unlock()
+
+	if err := vm.apply(cfgs); err != nil {
+		return err
+	}
+
+	vm.Config = cfgs
+	klog.V(2).Infof("Volume manager is reloaded")
+
+	return nil
+}
+
+//apply mirrors volumes into new version directories beside them, and
+//points the volume symlinks to them only if every volume is mirrored.
+//Containers bind mount the version directory the symlink points to, so the
+//previous versions are removed only if no container mounts them anymore.
+func (vm *VolumeManager) apply(cfgs []Config) (err error) {
 	cache, err := ldcache.Open()
 	if err != nil {
 		return err
@@ -110,16 +201,24 @@ func (vm *VolumeManager) Run() (err error) {
 		}
 	}()
 
-	vols := make(VolumeMap)
-	for _, cfg := range vm.Config {
+	var (
+		version    = strconv.FormatInt(time.Now().UnixNano(), 10)
+		vols       = make(VolumeMap)
+		targets    = make(map[string]string)
+		driverPath string
+		originPath string
+	)
+
+	for _, cfg := range cfgs {
+		target := path.Join(cfg.BasePath, cfg.Name)
 		vol := &Volume{
-			Path: path.Join(cfg.BasePath, cfg.Name),
+			Path: versionPath(target, version),
 		}
 
 		if cfg.Name == "nvidia" {
-			types.DriverLibraryPath = filepath.Join(cfg.BasePath, cfg.Name)
+			driverPath = target
 		} else {
-			types.DriverOriginLibraryPath = filepath.Join(cfg.BasePath, cfg.Name)
+			originPath = target
 		}
 
 		for t, c := range cfg.Components {
@@ -142,18 +241,235 @@ func (vm *VolumeManager) Run() (err error) {
 			}
 
 			vols[cfg.Name] = vol
+			targets[vol.Path] = target
+		}
+	}
+
+	swapped := false
+	defer func() {
+		if !swapped {
+			for dir := range targets {
+				os.RemoveAll(dir)
+			}
 		}
+	}()
+
+	// Mirror with a staged manager, the current one is kept if it fails
+	staged := NewVolumeManagerFromConfig(cfgs, vm.share)
+	if err := staged.mirror(vols); err != nil {
+		return err
 	}
 
-	if err := vm.mirror(vols); err != nil {
+	if err := swapVolumes(targets); err != nil {
 		return err
 	}
+	swapped = true
+
+	vm.cudaControlFile = staged.cudaControlFile
+	vm.cudaSoname = staged.cudaSoname
+	vm.mlSoName = staged.mlSoName
+	if staged.driverMajor > 0 {
+		types.DriverVersionMajor = staged.driverMajor
+		types.DriverVersionMinor = staged.driverMinor
+		klog.V(2).Infof("Driver version: %d.%d", types.DriverVersionMajor, types.DriverVersionMinor)
+	}
+
+	if len(driverPath) > 0 {
+		types.DriverLibraryPath = driverPath
+	}
+	if len(originPath) > 0 {
+		types.DriverOriginLibraryPath = originPath
+	}
+
+	inUse, err := mountedDirs(procRoot)
+	if err != nil {
+		klog.Warningf("Can't find mounted volumes, previous versions are kept, %v", err)
+		return nil
+	}
+
+	for _, target := range targets {
+		removeVersions(target, inUse)
+	}
+
+	return nil
+}
+
+const (
+	procRoot         = "/proc"
+	versionSeparator = ".v"
+	linkSuffix       = ".link"
+	//legacyVersion is given to a volume directory created by releases
+	//without versioned volumes
+	legacyVersion = "0"
+)
+
+//versionPath returns the directory of the version of volume target
+func versionPath(target, version string) string {
+	return path.Join(path.Dir(target), "."+path.Base(target)+versionSeparator+version)
+}
+
+//swapVolumes points every volume symlink to its new version directory,
+//the previous versions are restored if any of them fails. The key of
+//targets is the version directory and the value is the volume symlink.
+func swapVolumes(targets map[string]string) error {
+	previous := make(map[string]string)
+
+	rollback := func() {
+		for target, old := range previous {
+			var err error
+			if len(old) == 0 {
+				err = os.Remove(target)
+			} else {
+				err = relink(target, old)
+			}
+
+			if err != nil {
+				klog.Errorf("Can't restore %s to %q, %v", target, old, err)
+			}
+		}
+	}
 
-	klog.V(2).Infof("Volume manager is running")
+	for dir, target := range targets {
+		if _, err := os.Stat(dir); err != nil {
+			rollback()
+			return err
+		}
+
+		old, err := currentVersion(target)
+		if err != nil {
+			rollback()
+			return err
+		}
+		previous[target] = old
+
+		if err := relink(target, path.Base(dir)); err != nil {
+			rollback()
+			return err
+		}
+	}
 
 	return nil
 }
 
+//currentVersion returns the version directory volume symlink target
+//points to, empty if it doesn't exist. A directory created by releases
+//without versioned volumes is moved to a version directory, containers
+//mounted it keep using it.
+func currentVersion(target string) (string, error) {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		dest, err := os.Readlink(target)
+		if err != nil {
+			return "", err
+		}
+
+		return path.Base(dest), nil
+	case fi.IsDir():
+		legacy := versionPath(target, legacyVersion)
+		if err := os.Rename(target, legacy); err != nil {
+			return "", err
+		}
+
+		return path.Base(legacy), nil
+	}
+
+	return "", fmt.Errorf("%s is neither a directory nor a symlink", target)
+}
+
+//relink atomically replaces volume symlink target with one pointing to
+//version directory name
+func relink(target, name string) error {
+	link := path.Join(path.Dir(target), "."+path.Base(target)+linkSuffix)
+	if err := removeFile(link); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(name, link); err != nil {
+		return err
+	}
+
+	return os.Rename(link, target)
+}
+
+//removeVersions removes version directories of volume target except the
+//current one and the ones in use
+func removeVersions(target string, inUse map[string]bool) {
+	current, err := os.Readlink(target)
+	if err != nil {
+		klog.Warningf("Can't read volume %s, %v", target, err)
+		return
+	}
+
+	entries, err := ioutil.ReadDir(path.Dir(target))
+	if err != nil {
+		klog.Warningf("Can't list versions of volume %s, %v", target, err)
+		return
+	}
+
+	prefix := "." + path.Base(target) + versionSeparator
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !strings.HasPrefix(name, prefix) || name == path.Base(current) || inUse[name] {
+			continue
+		}
+
+		klog.V(2).Infof("Remove unused volume %s", name)
+		if err := os.RemoveAll(path.Join(path.Dir(target), name)); err != nil {
+			klog.Warningf("Can't remove %s, %v", name, err)
+		}
+	}
+}
+
+//mountedDirs returns the names of directories bind mounted by processes,
+//which requires the host pid namespace. Every mount namespace is read once.
+func mountedDirs(proc string) (map[string]bool, error) {
+	entries, err := ioutil.ReadDir(proc)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]bool)
+	namespaces := make(map[string]bool)
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+
+		if ns, err := os.Readlink(path.Join(proc, e.Name(), "ns", "mnt")); err == nil {
+			if namespaces[ns] {
+				continue
+			}
+			namespaces[ns] = true
+		}
+
+		data, err := ioutil.ReadFile(path.Join(proc, e.Name(), "mountinfo"))
+		if err != nil {
+			// The process has exited
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			// The 4th field is the root of the mount in its filesystem
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+
+			dirs[path.Base(fields[3])] = true
+		}
+	}
+
+	return dirs, nil
+}
+
 // #lizard forgives
 func (vm *VolumeManager) mirror(vols VolumeMap) error {
 	for driver, vol := range vols {
@@ -180,9 +496,8 @@ func (vm *VolumeManager) mirror(vols VolumeMap) error {
 
 				if strings.HasPrefix(path.Base(f), "libcuda.so") {
 					driverStr := strings.SplitN(strings.TrimPrefix(path.Base(f), "libcuda.so."), ".", 2)
-					types.DriverVersionMajor, _ = strconv.Atoi(driverStr[0])
-					types.DriverVersionMinor, _ = strconv.Atoi(driverStr[1])
-					klog.V(2).Infof("Driver version: %d.%d", types.DriverVersionMajor, types.DriverVersionMinor)
+					vm.driverMajor, _ = strconv.Atoi(driverStr[0])
+					vm.driverMinor, _ = strconv.Atoi(driverStr[1])
 				}
 
 				if strings.HasPrefix(path.Base(f), "libcuda-control.so") {
@@ -0,0 +1,130 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package volume
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwapVolumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "volume")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("can't create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("can't write %s: %v", path, err)
+		}
+	}
+
+	read := func(path string) string {
+		data, _ := ioutil.ReadFile(path)
+		return string(data)
+	}
+
+	nvidia, origin := filepath.Join(dir, "nvidia"), filepath.Join(dir, "origin")
+	// volume directory created by releases without versions
+	write(filepath.Join(nvidia, "lib64", "lib"), "legacy")
+
+	v1 := map[string]string{
+		versionPath(nvidia, "1"): nvidia,
+		versionPath(origin, "1"): origin,
+	}
+	write(filepath.Join(versionPath(nvidia, "1"), "lib64", "lib"), "v1")
+
+	// version of origin is missing, nvidia must be restored
+	if err := swapVolumes(v1); err == nil {
+		t.Fatalf("swap should fail without version directory")
+	}
+
+	if got := read(filepath.Join(nvidia, "lib64", "lib")); got != "legacy" {
+		t.Fatalf("nvidia volume should be restored, got %q", got)
+	}
+
+	if _, err := os.Lstat(origin); !os.IsNotExist(err) {
+		t.Fatalf("origin volume should not exist, %v", err)
+	}
+
+	write(filepath.Join(versionPath(origin, "1"), "bin", "smi"), "v1")
+	if err := swapVolumes(v1); err != nil {
+		t.Fatalf("can't swap volumes: %v", err)
+	}
+
+	if read(filepath.Join(nvidia, "lib64", "lib")) != "v1" || read(filepath.Join(origin, "bin", "smi")) != "v1" {
+		t.Fatalf("volumes are not swapped")
+	}
+
+	write(filepath.Join(versionPath(nvidia, "2"), "lib64", "lib"), "v2")
+	if err := swapVolumes(map[string]string{versionPath(nvidia, "2"): nvidia}); err != nil {
+		t.Fatalf("can't swap volumes: %v", err)
+	}
+
+	// the legacy directory is still mounted by a container
+	removeVersions(nvidia, map[string]bool{filepath.Base(versionPath(nvidia, legacyVersion)): true})
+
+	if read(filepath.Join(nvidia, "lib64", "lib")) != "v2" {
+		t.Fatalf("current version should be kept")
+	}
+
+	if read(filepath.Join(versionPath(nvidia, legacyVersion), "lib64", "lib")) != "legacy" {
+		t.Fatalf("version in use should be kept")
+	}
+
+	if _, err := os.Stat(versionPath(nvidia, "1")); !os.IsNotExist(err) {
+		t.Fatalf("unused version should be removed, %v", err)
+	}
+
+	if read(filepath.Join(origin, "bin", "smi")) != "v1" {
+		t.Fatalf("versions of other volumes should be kept")
+	}
+}
+
+func TestMountedDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mountinfo := `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+1285 1280 8:1 /etc/gpu-manager/vdriver/.nvidia.v1 /usr/local/nvidia ro,relatime - ext4 /dev/sda1 rw
+`
+	if err := os.MkdirAll(filepath.Join(dir, "100"), 0755); err != nil {
+		t.Fatalf("can't create dir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "100", "mountinfo"), []byte(mountinfo), 0644); err != nil {
+		t.Fatalf("can't write mountinfo: %v", err)
+	}
+
+	dirs, err := mountedDirs(dir)
+	if err != nil {
+		t.Fatalf("can't read mounts: %v", err)
+	}
+
+	if !dirs[".nvidia.v1"] || dirs[".nvidia.v2"] {
+		t.Fatalf("unexpected mounted directories %v", dirs)
+	}
+}
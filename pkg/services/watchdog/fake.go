@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package watchdog
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+//FakePodLister is a PodLister keeping pods in memory for testing, event
+//handlers are called synchronously
+type FakePodLister struct {
+	sync.Mutex
+
+	pods     map[string]*v1.Pod
+	handlers []cache.ResourceEventHandler
+}
+
+var _ PodLister = &FakePodLister{}
+
+//NewFakePodLister returns a FakePodLister containing pods
+func NewFakePodLister(pods ...*v1.Pod) *FakePodLister {
+	l := &FakePodLister{
+		pods: make(map[string]*v1.Pod),
+	}
+	for _, pod := range pods {
+		l.pods[keyOfPod(pod)] = pod
+	}
+
+	return l
+}
+
+func keyOfPod(pod *v1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+//Add adds pod, or updates it if it exists
+func (l *FakePodLister) Add(pod *v1.Pod) {
+	l.Lock()
+	old, ok := l.pods[keyOfPod(pod)]
+	l.pods[keyOfPod(pod)] = pod
+	handlers := l.handlers
+	l.Unlock()
+
+	for _, h := range handlers {
+		if ok {
+			h.OnUpdate(old, pod)
+		} else {
+			h.OnAdd(pod)
+		}
+	}
+}
+
+//Update is the same as Add
+func (l *FakePodLister) Update(pod *v1.Pod) {
+	l.Add(pod)
+}
+
+//Delete removes pod
+func (l *FakePodLister) Delete(pod *v1.Pod) {
+	l.Lock()
+	old, ok := l.pods[keyOfPod(pod)]
+	delete(l.pods, keyOfPod(pod))
+	handlers := l.handlers
+	l.Unlock()
+
+	if !ok {
+		return
+	}
+	for _, h := range handlers {
+		h.OnDelete(old)
+	}
+}
+
+//AddEventHandler registers handler and delivers existing pods to it
+func (l *FakePodLister) AddEventHandler(handler cache.ResourceEventHandler) {
+	l.Lock()
+	l.handlers = append(l.handlers, handler)
+	pods := l.list()
+	l.Unlock()
+
+	for _, pod := range pods {
+		handler.OnAdd(pod)
+	}
+}
+
+//GetActivePods returns active pods of l
+func (l *FakePodLister) GetActivePods() map[string]*v1.Pod {
+	l.Lock()
+	defer l.Unlock()
+
+	return activePodsOf(l.list())
+}
+
+//GetPod returns the active pod of l
+func (l *FakePodLister) GetPod(namespace, name string) (*v1.Pod, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	pod, ok := l.pods[namespace+"/"+name]
+	if !ok {
+		return nil, errors.NewNotFound(v1.Resource("pods"), name)
+	}
+
+	if err := checkActivePod(pod); err != nil {
+		return nil, err
+	}
+
+	return pod, nil
+}
+
+//ListPods returns pods of phase of l
+func (l *FakePodLister) ListPods(phase v1.PodPhase) ([]*v1.Pod, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	return podsOfPhase(l.list(), phase), nil
+}
+
+func (l *FakePodLister) list() []*v1.Pod {
+	pods := make([]*v1.Pod, 0, len(l.pods))
+	for _, pod := range l.pods {
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return keyOfPod(pods[i]) < keyOfPod(pods[j])
+	})
+
+	return pods
+}
@@ -0,0 +1,240 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package watchdog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/klog"
+	"tkestack.io/nvml"
+)
+
+const (
+	gpuModelFullLabel         = "gaia.tencent.com/gpu-model-full"
+	gpuMemoryLabel            = "gaia.tencent.com/gpu-memory"
+	gpuCountLabel             = "gaia.tencent.com/gpu-count"
+	gpuDriverVersionLabel     = "gaia.tencent.com/gpu-driver-version"
+	gpuCUDAVersionLabel       = "gaia.tencent.com/gpu-cuda-version"
+	gpuComputeCapabilityLabel = "gaia.tencent.com/gpu-compute-capability"
+	gpuNVLinkLabel            = "gaia.tencent.com/gpu-nvlink"
+	gpuMIGLabel               = "gaia.tencent.com/gpu-mig"
+	gpuHeterogeneousLabel     = "gaia.tencent.com/gpu-heterogeneous"
+
+	gib = 1 << 30
+)
+
+//GPUInfo contains information of GPU cards on this node, computed labels
+//are generated from it.
+type GPUInfo struct {
+	DriverVersion string
+	//CUDAVersion is the latest CUDA version supported by the driver, empty
+	//if unknown
+	CUDAVersion string
+	Devices     []GPUDeviceInfo
+}
+
+//GPUDeviceInfo contains information of a GPU card
+type GPUDeviceInfo struct {
+	Model       string
+	TotalMemory uint64
+	//ComputeCapability is empty if unknown
+	ComputeCapability string
+	//NVLinks is the number of active NVLinks, negative if unknown
+	NVLinks int
+	//MIG tells whether the card supports MIG, nil if unknown
+	MIG *bool
+}
+
+//LabelFunc computes the value of a node label from GPU information,
+//empty value means the label doesn't apply and should be removed.
+type LabelFunc func(info *GPUInfo) string
+
+var (
+	computedLabelsLock sync.Mutex
+	computedLabels     = map[string]LabelFunc{
+		gpuModelLabel:             uniformLabel(func(dev GPUDeviceInfo) string { return getTypeName(dev.Model) }),
+		gpuModelFullLabel:         uniformLabel(func(dev GPUDeviceInfo) string { return dev.Model }),
+		gpuMemoryLabel:            uniformLabel(memoryClass),
+		gpuCountLabel:             gpuCount,
+		gpuDriverVersionLabel:     func(info *GPUInfo) string { return info.DriverVersion },
+		gpuCUDAVersionLabel:       func(info *GPUInfo) string { return info.CUDAVersion },
+		gpuComputeCapabilityLabel: uniformLabel(computeCapability),
+		gpuNVLinkLabel:            uniformLabel(nvlink),
+		gpuMIGLabel:               uniformLabel(migCapable),
+		gpuHeterogeneousLabel:     heterogeneous,
+	}
+)
+
+//RegisterLabel adds a computed node label, labeler refreshes it with
+//other computed labels.
+func RegisterLabel(key string, fn LabelFunc) {
+	computedLabelsLock.Lock()
+	defer computedLabelsLock.Unlock()
+
+	computedLabels[key] = fn
+}
+
+func getComputedLabels() map[string]LabelFunc {
+	computedLabelsLock.Lock()
+	defer computedLabelsLock.Unlock()
+
+	labels := make(map[string]LabelFunc, len(computedLabels))
+	for k, fn := range computedLabels {
+		labels[k] = fn
+	}
+
+	return labels
+}
+
+func getGPUInfo() (*GPUInfo, error) {
+	if err := nvml.Init(); err != nil {
+		return nil, err
+	}
+
+	defer nvml.Shutdown()
+
+	info := &GPUInfo{}
+
+	driverVersion, err := nvml.SystemGetDriverVersion()
+	if err != nil {
+		return nil, err
+	}
+	info.DriverVersion = driverVersion
+
+	if info.CUDAVersion, err = queryCUDAVersion(); err != nil {
+		klog.Warningf("Can't query CUDA version, %v", err)
+	}
+
+	num, err := nvml.DeviceGetCount()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint(0); i < num; i++ {
+		dev, err := nvml.DeviceGetHandleByIndex(i)
+		if err != nil {
+			return nil, fmt.Errorf("can't get device %d, %v", i, err)
+		}
+
+		name, err := dev.DeviceGetName()
+		if err != nil {
+			return nil, fmt.Errorf("can't get name of device %d, %v", i, err)
+		}
+
+		_, _, totalMem, err := dev.DeviceGetMemoryInfo()
+		if err != nil {
+			return nil, fmt.Errorf("can't get memory of device %d, %v", i, err)
+		}
+
+		devInfo := GPUDeviceInfo{
+			Model:       name,
+			TotalMemory: totalMem,
+		}
+		if err := queryDeviceFeatures(i, &devInfo); err != nil {
+			klog.Warningf("Can't query features of device %d, %v", i, err)
+		}
+
+		klog.V(4).Infof("GPU %d name: %s, memory: %d, compute capability: %s, nvlinks: %d",
+			i, name, totalMem, devInfo.ComputeCapability, devInfo.NVLinks)
+		info.Devices = append(info.Devices, devInfo)
+	}
+
+	return info, nil
+}
+
+//uniformLabel returns the value only if all cards have the same value
+func uniformLabel(fn func(dev GPUDeviceInfo) string) LabelFunc {
+	return func(info *GPUInfo) string {
+		value := ""
+		for i, dev := range info.Devices {
+			v := fn(dev)
+			if i > 0 && v != value {
+				return ""
+			}
+			value = v
+		}
+
+		return value
+	}
+}
+
+func gpuCount(info *GPUInfo) string {
+	return strconv.Itoa(len(info.Devices))
+}
+
+func heterogeneous(info *GPUInfo) string {
+	if len(info.Devices) == 0 {
+		return ""
+	}
+
+	for _, dev := range info.Devices[1:] {
+		if dev.Model != info.Devices[0].Model || dev.TotalMemory != info.Devices[0].TotalMemory {
+			return "true"
+		}
+	}
+
+	return "false"
+}
+
+func memoryClass(dev GPUDeviceInfo) string {
+	if dev.TotalMemory == 0 {
+		return ""
+	}
+
+	// Reserved memory makes total memory a little smaller than the nominal one
+	return fmt.Sprintf("%dGi", (dev.TotalMemory+gib-1)/gib)
+}
+
+//computeCapability is reported by NVML, it's unset if the driver can't
+//report it
+func computeCapability(dev GPUDeviceInfo) string {
+	return dev.ComputeCapability
+}
+
+func migCapable(dev GPUDeviceInfo) string {
+	if dev.MIG == nil {
+		return ""
+	}
+
+	return strconv.FormatBool(*dev.MIG)
+}
+
+//nvlink is true if the card has active NVLinks to other cards
+func nvlink(dev GPUDeviceInfo) string {
+	if dev.NVLinks < 0 {
+		return ""
+	}
+
+	return strconv.FormatBool(dev.NVLinks > 0)
+}
+
+var invalidLabelChars = regexp.MustCompile("[^A-Za-z0-9_.-]+")
+
+//sanitizeLabelValue converts value to a valid label value
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+
+	return strings.Trim(value, "-_.")
+}
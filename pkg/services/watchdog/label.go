@@ -18,20 +18,36 @@
 package watchdog
 
 import (
+	"encoding/json"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog"
-	"tkestack.io/nvml"
 )
 
 const (
 	gpuModelLabel = "gaia.tencent.com/gpu-model"
+
+	//managedLabelsAnnotation records labels set by labeler, labels which
+	//are not set anymore will be removed from node
+	managedLabelsAnnotation = "tencent.com/gpu-managed-labels"
+
+	labelRefreshPeriod    = 5 * time.Minute
+	inventoryResyncPeriod = time.Minute
+	inventoryDebounce     = time.Second
 )
 
 type labelFunc interface {
@@ -39,44 +55,35 @@ type labelFunc interface {
 }
 
 type nodeLabeler struct {
-	hostName    string
-	client      v1core.CoreV1Interface
-	labelMapper map[string]labelFunc
+	hostName         string
+	client           v1core.CoreV1Interface
+	staticLabels     map[string]string
+	computedLabels   map[string]LabelFunc
+	annotationMapper map[string]labelFunc
+	gpuInfoFn        func() (*GPUInfo, error)
+	refreshPeriod    time.Duration
 }
 
-type modelFunc struct{}
-type stringFunc string
-
-var modelFn = modelFunc{}
-
-func (m modelFunc) GetLabel() (model string) {
-	if err := nvml.Init(); err != nil {
-		klog.Warningf("Can't initialize nvml library, %v", err)
-		return
-	}
-
-	defer nvml.Shutdown()
-
-	// Assume all devices on this node are the same model
-	dev, err := nvml.DeviceGetHandleByIndex(0)
-	if err != nil {
-		klog.Warningf("Can't get device 0 information, %v", err)
-		return
-	}
+type topologyFunc struct {
+	tree *nvtree.NvidiaTree
+}
 
-	rawName, err := dev.DeviceGetName()
+func (f topologyFunc) GetLabel() string {
+	data, err := json.Marshal(f.tree.Topology())
 	if err != nil {
-		klog.Warningf("Can't get device name, %v", err)
-		return
+		klog.Warningf("Can't marshal topology, %v", err)
+		return ""
 	}
 
-	klog.V(4).Infof("GPU name: %s", rawName)
+	return string(data)
+}
 
-	return getTypeName(rawName)
+type memoryBlockFunc struct {
+	tree *nvtree.NvidiaTree
 }
 
-func (s stringFunc) GetLabel() string {
-	return string(s)
+func (f memoryBlockFunc) GetLabel() string {
+	return strconv.FormatInt(f.tree.MemoryBlockSize(), 10)
 }
 
 var modelNameSplitPattern = regexp.MustCompile("\\s+")
@@ -101,38 +108,133 @@ func NewNodeLabeler(client v1core.CoreV1Interface, hostname string, labels map[s
 
 	klog.V(2).Infof("Labeler for hostname %s", hostname)
 
-	labelMapper := make(map[string]labelFunc)
+	computedLabels := getComputedLabels()
+	staticLabels := make(map[string]string)
 	for k, v := range labels {
-		if k == gpuModelLabel {
-			labelMapper[k] = modelFn
-		} else {
-			labelMapper[k] = stringFunc(v)
+		// Computed labels in configuration are ignored, labeler refreshes them
+		if _, ok := computedLabels[k]; !ok {
+			staticLabels[k] = v
 		}
 	}
 
 	return &nodeLabeler{
-		hostName:    hostname,
-		client:      client,
-		labelMapper: labelMapper,
+		hostName:         hostname,
+		client:           client,
+		staticLabels:     staticLabels,
+		computedLabels:   computedLabels,
+		annotationMapper: make(map[string]labelFunc),
+		gpuInfoFn:        getGPUInfo,
+		refreshPeriod:    labelRefreshPeriod,
+	}
+}
+
+//AnnotateTopology publishes GPU topology and vmemory block size of the
+//tree in node annotation, scheduler extender rebuilds the tree from them.
+func (nl *nodeLabeler) AnnotateTopology(tree *nvtree.NvidiaTree) {
+	nl.annotationMapper[types.GPUTopologyAnnotation] = topologyFunc{tree}
+	nl.annotationMapper[types.VMemoryBlockAnnotation] = memoryBlockFunc{tree}
+}
+
+//PublishInventory keeps GPU inventory and free capacity of the tree in
+//node annotation until stopCh is closed. The annotation is patched after
+//allocation or recycle changes the tree, and resynced periodically.
+func (nl *nodeLabeler) PublishInventory(tree *nvtree.NvidiaTree, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(inventoryResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		if err := nl.patchInventory(tree); err != nil {
+			klog.Warningf("Can't publish GPU inventory of %s, %v", nl.hostName, err)
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		case <-tree.Changed():
+			// An allocation may change several cards, wait for them
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(inventoryDebounce):
+			}
+
+			select {
+			case <-tree.Changed():
+			default:
+			}
+		}
 	}
 }
 
-func (nl *nodeLabeler) Run() error {
-	err := wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+func (nl *nodeLabeler) patchInventory(tree *nvtree.NvidiaTree) error {
+	data, err := json.Marshal(tree.Inventory())
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				types.GPUInventoryAnnotation: string(data),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(4).Infof("Annotate %s %s=%s", nl.hostName, types.GPUInventoryAnnotation, string(data))
+	_, err = nl.client.Nodes().Patch(nl.hostName, k8stypes.StrategicMergePatchType, patch)
+
+	return err
+}
+
+//Run labels node and refreshes labels periodically until stopCh is closed
+func (nl *nodeLabeler) Run(stopCh <-chan struct{}) error {
+	if err := nl.refresh(); err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Auto label is running")
+
+	go func() {
+		ticker := time.NewTicker(nl.refreshPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if err := nl.refresh(); err != nil {
+					klog.Warningf("Can't refresh labels of %s, %v", nl.hostName, err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (nl *nodeLabeler) refresh() error {
+	info, err := nl.gpuInfoFn()
+	if err != nil {
+		klog.Warningf("Can't get GPU information, computed labels are not refreshed, %v", err)
+		info = nil
+	}
+
+	labels := nl.desiredLabels(info)
+
+	return wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
 		node, err := nl.client.Nodes().Get(nl.hostName, metav1.GetOptions{})
 		if err != nil {
 			return false, err
 		}
 
-		for k, fn := range nl.labelMapper {
-			l := fn.GetLabel()
-			if len(l) == 0 {
-				klog.Warningf("Empty label for %s", k)
-				continue
-			}
-
-			klog.V(2).Infof("Label %s %s=%s", nl.hostName, k, l)
-			node.Labels[k] = l
+		if !nl.applyLabels(node, labels, info != nil) {
+			klog.V(4).Infof("Labels of %s are up to date", nl.hostName)
+			return true, nil
 		}
 
 		_, updateErr := nl.client.Nodes().Update(node)
@@ -145,12 +247,101 @@ func (nl *nodeLabeler) Run() error {
 
 		return true, nil
 	})
+}
 
-	if err != nil {
-		return err
+func (nl *nodeLabeler) desiredLabels(info *GPUInfo) map[string]string {
+	labels := make(map[string]string)
+
+	for k, v := range nl.staticLabels {
+		if len(v) == 0 {
+			klog.Warningf("Empty label for %s", k)
+			continue
+		}
+		labels[k] = v
 	}
 
-	klog.V(2).Infof("Auto label is running")
+	if info == nil {
+		return labels
+	}
 
-	return nil
+	for k, fn := range nl.computedLabels {
+		v := sanitizeLabelValue(fn(info))
+		if len(v) == 0 {
+			klog.V(4).Infof("Label %s doesn't apply to %s", k, nl.hostName)
+			continue
+		}
+		labels[k] = v
+	}
+
+	return labels
+}
+
+//applyLabels sets labels and annotations of node, removes obsolete labels,
+//returns whether node is changed
+func (nl *nodeLabeler) applyLabels(node *v1.Node, labels map[string]string, computed bool) bool {
+	changed := false
+
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+
+	managed := sets.StringKeySet(labels)
+	obsolete := sets.NewString()
+	if prev, ok := node.Annotations[managedLabelsAnnotation]; ok && len(prev) > 0 {
+		obsolete.Insert(strings.Split(prev, ",")...)
+	}
+
+	for k := range nl.computedLabels {
+		if !computed {
+			// Keep computed labels until GPU information is available
+			if obsolete.Has(k) {
+				managed.Insert(k)
+			}
+			continue
+		}
+		obsolete.Insert(k)
+	}
+
+	for _, k := range obsolete.Difference(managed).List() {
+		if _, ok := node.Labels[k]; ok {
+			klog.V(2).Infof("Remove obsolete label %s from %s", k, nl.hostName)
+			delete(node.Labels, k)
+			changed = true
+		}
+	}
+
+	for k, l := range labels {
+		if node.Labels[k] != l {
+			klog.V(2).Infof("Label %s %s=%s", nl.hostName, k, l)
+			node.Labels[k] = l
+			changed = true
+		}
+	}
+
+	annotations := map[string]string{
+		managedLabelsAnnotation: strings.Join(managed.List(), ","),
+	}
+
+	for k, fn := range nl.annotationMapper {
+		a := fn.GetLabel()
+		if len(a) == 0 {
+			klog.Warningf("Empty annotation for %s", k)
+			continue
+		}
+		annotations[k] = a
+	}
+
+	for k, a := range annotations {
+		if node.Annotations[k] != a {
+			klog.V(2).Infof("Annotate %s %s=%s", nl.hostName, k, a)
+			node.Annotations[k] = a
+			changed = true
+		}
+	}
+
+	return changed
 }
@@ -18,10 +18,16 @@
 package watchdog
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -52,8 +58,11 @@ func TestNodeLabeler(t *testing.T) {
 	k8sclient.CoreV1().Nodes().Create(node)
 
 	// create nodeLabeler and run
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
 	nodeLabeler := NewNodeLabeler(k8sclient.CoreV1(), nodeName, labels)
-	go nodeLabeler.Run()
+	go nodeLabeler.Run(stopCh)
 
 	// check if nodeLabeler work well
 	err := wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
@@ -70,3 +79,212 @@ func TestNodeLabeler(t *testing.T) {
 		t.Fatalf("test failed: %s", err.Error())
 	}
 }
+
+func TestPublishInventory(t *testing.T) {
+	flag.Parse()
+	nodeName := "testnode"
+
+	k8sclient := fake.NewSimpleClientset()
+	k8sclient.CoreV1().Nodes().Create(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+		},
+	})
+
+	obj := nvtree.NewNvidiaTree(nil)
+	tree, _ := obj.(*nvtree.NvidiaTree)
+	tree.Init("\tGPU0\tGPU1\nGPU0\tX\tPIX\nGPU1\tPIX\tX\n")
+	for _, n := range tree.Leaves() {
+		n.Meta.TotalMemory = 1024
+		n.AllocatableMeta.Cores = nvtree.HundredCore
+		n.AllocatableMeta.Memory = 1024
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	nodeLabeler := NewNodeLabeler(k8sclient.CoreV1(), nodeName, nil)
+	go nodeLabeler.PublishInventory(tree, stopCh)
+
+	expectAllocatable := func(cores, memory int64) error {
+		return wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+			node, err := k8sclient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			data, ok := node.Annotations[types.GPUInventoryAnnotation]
+			if !ok {
+				return false, nil
+			}
+
+			inv := &nvtree.Inventory{}
+			if err := json.Unmarshal([]byte(data), inv); err != nil {
+				return false, err
+			}
+
+			if len(inv.Devices) != 2 || inv.Devices[1].Allocatable.Memory != 1024 {
+				return false, fmt.Errorf("unexpected inventory %s", data)
+			}
+
+			return inv.Devices[0].Allocatable.Cores == cores && inv.Devices[0].Allocatable.Memory == memory, nil
+		})
+	}
+
+	if err := expectAllocatable(nvtree.HundredCore, 1024); err != nil {
+		t.Fatalf("inventory is not published: %v", err)
+	}
+
+	tree.MarkOccupied(tree.Leaves()[0], 50, 256)
+	if err := expectAllocatable(50, 768); err != nil {
+		t.Fatalf("inventory is not updated after allocation: %v", err)
+	}
+
+	tree.MarkFree(tree.Leaves()[0], 50, 256)
+	if err := expectAllocatable(nvtree.HundredCore, 1024); err != nil {
+		t.Fatalf("inventory is not updated after recycle: %v", err)
+	}
+}
+
+func TestComputedLabels(t *testing.T) {
+	flag.Parse()
+	nodeName := "testnode"
+
+	k8sclient := fake.NewSimpleClientset()
+	k8sclient.CoreV1().Nodes().Create(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+			Labels: map[string]string{
+				"obsolete": "true",
+				"user":     "true",
+			},
+			Annotations: map[string]string{
+				managedLabelsAnnotation: "obsolete",
+			},
+		},
+	})
+
+	var (
+		lock sync.Mutex
+		info = &GPUInfo{
+			DriverVersion: "418.67",
+			CUDAVersion:   "10.1",
+			Devices: []GPUDeviceInfo{
+				{Model: "Tesla V100-SXM2-16GB", TotalMemory: 16945512448, ComputeCapability: "7.0", NVLinks: 6, MIG: boolPtr(false)},
+				{Model: "Tesla V100-SXM2-16GB", TotalMemory: 16945512448, ComputeCapability: "7.0", NVLinks: 6, MIG: boolPtr(false)},
+			},
+		}
+	)
+
+	nodeLabeler := NewNodeLabeler(k8sclient.CoreV1(), nodeName, map[string]string{"static": "value"})
+	nodeLabeler.refreshPeriod = 100 * time.Millisecond
+	nodeLabeler.gpuInfoFn = func() (*GPUInfo, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return info, nil
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	if err := nodeLabeler.Run(stopCh); err != nil {
+		t.Fatalf("can't run labeler: %v", err)
+	}
+
+	expectLabels := func(expect map[string]string) error {
+		var node *v1.Node
+		err := wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+			var err error
+			node, err = k8sclient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			for k, v := range expect {
+				if l, ok := node.Labels[k]; (len(v) == 0 && ok) || (len(v) > 0 && l != v) {
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("%v, labels: %v", err, node.Labels)
+		}
+
+		return nil
+	}
+
+	err := expectLabels(map[string]string{
+		"static":                  "value",
+		"user":                    "true",
+		"obsolete":                "",
+		gpuModelFullLabel:         "Tesla-V100-SXM2-16GB",
+		gpuMemoryLabel:            "16Gi",
+		gpuCountLabel:             "2",
+		gpuDriverVersionLabel:     "418.67",
+		gpuCUDAVersionLabel:       "10.1",
+		gpuComputeCapabilityLabel: "7.0",
+		gpuNVLinkLabel:            "true",
+		gpuMIGLabel:               "false",
+		gpuHeterogeneousLabel:     "false",
+	})
+	if err != nil {
+		t.Fatalf("unexpected labels: %v", err)
+	}
+
+	lock.Lock()
+	info = &GPUInfo{
+		DriverVersion: "450.51.06",
+		CUDAVersion:   "11.0",
+		Devices: []GPUDeviceInfo{
+			{Model: "Tesla V100-SXM2-16GB", TotalMemory: 16945512448, ComputeCapability: "7.0", NVLinks: 6, MIG: boolPtr(false)},
+			{Model: "Tesla T4", TotalMemory: 15843721216, ComputeCapability: "7.5", MIG: boolPtr(false)},
+		},
+	}
+	lock.Unlock()
+
+	err = expectLabels(map[string]string{
+		"static":                  "value",
+		gpuModelFullLabel:         "",
+		gpuMemoryLabel:            "",
+		gpuCountLabel:             "2",
+		gpuCUDAVersionLabel:       "11.0",
+		gpuComputeCapabilityLabel: "",
+		gpuNVLinkLabel:            "",
+		gpuMIGLabel:               "false",
+		gpuHeterogeneousLabel:     "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected labels after refresh: %v", err)
+	}
+}
+
+func TestLabelValues(t *testing.T) {
+	testCases := []struct {
+		fn     LabelFunc
+		info   *GPUInfo
+		expect string
+	}{
+		{gpuCount, &GPUInfo{}, "0"},
+		{heterogeneous, &GPUInfo{}, ""},
+		{uniformLabel(memoryClass), &GPUInfo{Devices: []GPUDeviceInfo{{TotalMemory: 42505273344}}}, "40Gi"},
+		{uniformLabel(computeCapability), &GPUInfo{Devices: []GPUDeviceInfo{{ComputeCapability: "8.0"}}}, "8.0"},
+		{uniformLabel(computeCapability), &GPUInfo{Devices: []GPUDeviceInfo{{Model: "Unknown"}}}, ""},
+		{uniformLabel(migCapable), &GPUInfo{Devices: []GPUDeviceInfo{{MIG: boolPtr(true)}}}, "true"},
+		{uniformLabel(migCapable), &GPUInfo{Devices: []GPUDeviceInfo{{Model: "Unknown"}}}, ""},
+		{uniformLabel(nvlink), &GPUInfo{Devices: []GPUDeviceInfo{{Model: "A100-SXM4-40GB", NVLinks: 12}}}, "true"},
+		{uniformLabel(nvlink), &GPUInfo{Devices: []GPUDeviceInfo{{Model: "A100-SXM4-40GB", NVLinks: -1}}}, ""},
+		{uniformLabel(nvlink), &GPUInfo{Devices: []GPUDeviceInfo{{Model: "Tesla T4"}}}, "false"},
+	}
+
+	for i, tc := range testCases {
+		if got := tc.fn(tc.info); got != tc.expect {
+			t.Errorf("case %d: expect %q, got %q", i, tc.expect, got)
+		}
+	}
+
+	if got := sanitizeLabelValue("GeForce GTX 1080 Ti (rev 1)"); got != "GeForce-GTX-1080-Ti-rev-1" {
+		t.Errorf("unexpected sanitized value %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package watchdog
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stddef.h>
+
+#define NVML_SUCCESS 0
+#define NVML_ERROR_INVALID_ARGUMENT 2
+#define NVML_ERROR_NOT_SUPPORTED 3
+#define NVML_ERROR_FUNCTION_NOT_FOUND 13
+#define NVML_NVLINK_MAX_LINKS 18
+
+typedef void *nvml_device_t;
+
+static void *nvml_lib;
+
+static int nvml_ext_open(void) {
+  if (nvml_lib == NULL) {
+    nvml_lib = dlopen("libnvidia-ml.so.1", RTLD_LAZY | RTLD_GLOBAL);
+  }
+  return nvml_lib == NULL ? NVML_ERROR_FUNCTION_NOT_FOUND : NVML_SUCCESS;
+}
+
+static int nvml_ext_cuda_driver_version(int *version) {
+  int (*fn)(int *) = dlsym(nvml_lib, "nvmlSystemGetCudaDriverVersion_v2");
+  if (fn == NULL) {
+    fn = dlsym(nvml_lib, "nvmlSystemGetCudaDriverVersion");
+  }
+  if (fn == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return fn(version);
+}
+
+static int nvml_ext_device(unsigned int index, nvml_device_t *dev) {
+  int (*fn)(unsigned int, nvml_device_t *) = dlsym(nvml_lib, "nvmlDeviceGetHandleByIndex_v2");
+  if (fn == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return fn(index, dev);
+}
+
+static int nvml_ext_compute_capability(nvml_device_t dev, int *major, int *minor) {
+  int (*fn)(nvml_device_t, int *, int *) = dlsym(nvml_lib, "nvmlDeviceGetCudaComputeCapability");
+  if (fn == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return fn(dev, major, minor);
+}
+
+static int nvml_ext_nvlinks(nvml_device_t dev, int *active) {
+  int (*fn)(nvml_device_t, unsigned int, int *) = dlsym(nvml_lib, "nvmlDeviceGetNvLinkState");
+  unsigned int link;
+  int state, ret;
+
+  if (fn == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+
+  *active = 0;
+  for (link = 0; link < NVML_NVLINK_MAX_LINKS; link++) {
+    ret = fn(dev, link, &state);
+    if (ret == NVML_ERROR_NOT_SUPPORTED || ret == NVML_ERROR_INVALID_ARGUMENT) {
+      break;
+    }
+    if (ret != NVML_SUCCESS) {
+      return ret;
+    }
+    if (state) {
+      (*active)++;
+    }
+  }
+  return NVML_SUCCESS;
+}
+
+static int nvml_ext_mig_mode(nvml_device_t dev, unsigned int *current) {
+  int (*fn)(nvml_device_t, unsigned int *, unsigned int *) = dlsym(nvml_lib, "nvmlDeviceGetMigMode");
+  unsigned int pending;
+
+  if (fn == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return fn(dev, current, &pending);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+)
+
+//queryCUDAVersion returns the latest CUDA version supported by the driver,
+//NVML must have been initialized.
+func queryCUDAVersion() (string, error) {
+	if ret := C.nvml_ext_open(); ret != C.NVML_SUCCESS {
+		return "", fmt.Errorf("can't open NVML library")
+	}
+
+	var version C.int
+	if ret := C.nvml_ext_cuda_driver_version(&version); ret != C.NVML_SUCCESS {
+		return "", fmt.Errorf("can't get CUDA driver version, NVML error %d", int(ret))
+	}
+
+	// The version is 1000 * major + 10 * minor
+	return fmt.Sprintf("%d.%d", int(version)/1000, int(version)%1000/10), nil
+}
+
+//queryDeviceFeatures fills compute capability, NVLink and MIG support of
+//device index, which nvml library in use can't query. NVML must have been
+//initialized. Features NVML doesn't report are left unknown.
+func queryDeviceFeatures(index uint, info *GPUDeviceInfo) error {
+	info.NVLinks = -1
+
+	if ret := C.nvml_ext_open(); ret != C.NVML_SUCCESS {
+		return fmt.Errorf("can't open NVML library")
+	}
+
+	var dev C.nvml_device_t
+	if ret := C.nvml_ext_device(C.uint(index), &dev); ret != C.NVML_SUCCESS {
+		return fmt.Errorf("can't get device %d, NVML error %d", index, int(ret))
+	}
+
+	var major, minor C.int
+	if ret := C.nvml_ext_compute_capability(dev, &major, &minor); ret == C.NVML_SUCCESS {
+		info.ComputeCapability = fmt.Sprintf("%d.%d", int(major), int(minor))
+	}
+
+	var active C.int
+	if ret := C.nvml_ext_nvlinks(dev, &active); ret == C.NVML_SUCCESS {
+		info.NVLinks = int(active)
+	}
+
+	// Drivers without MIG API or cards reporting not supported can't use MIG
+	var current C.uint
+	switch ret := C.nvml_ext_mig_mode(dev, &current); ret {
+	case C.NVML_SUCCESS:
+		info.MIG = boolPtr(true)
+	case C.NVML_ERROR_NOT_SUPPORTED, C.NVML_ERROR_FUNCTION_NOT_FOUND:
+		info.MIG = boolPtr(false)
+	}
+
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -26,9 +26,11 @@ import (
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	informerCore "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
 )
 
@@ -36,18 +38,33 @@ const (
 	podHostField = "spec.nodeName"
 )
 
-//PodCache contains a podInformer of pod
+//PodLister provides pods on this node, it's shared by components which need
+//to know pods of this node
+type PodLister interface {
+	//GetActivePods returns pods which require GPU and are not terminated,
+	//keyed by pod UID
+	GetActivePods() map[string]*v1.Pod
+	//GetPod returns the pod if it requires GPU and is not terminated
+	GetPod(namespace, name string) (*v1.Pod, error)
+	//ListPods returns pods of phase, pods of all phases are returned if phase
+	//is empty. The returned pods are shared and must not be modified.
+	ListPods(phase v1.PodPhase) ([]*v1.Pod, error)
+	//AddEventHandler registers handler for pod events, existing pods are
+	//delivered to handler as add events
+	AddEventHandler(handler cache.ResourceEventHandler)
+}
+
+//PodCache is a PodLister backed by a podInformer
 type PodCache struct {
 	podInformer informerCore.PodInformer
 }
 
-var (
-	podCache *PodCache
-)
+var _ PodLister = &PodCache{}
 
-//NewPodCache creates a new podCache
-func NewPodCache(client kubernetes.Interface, hostName string) {
-	podCache = new(PodCache)
+//NewPodCache creates a new PodCache watching pods on hostName until stopCh
+//is closed, it returns after the cache is synced
+func NewPodCache(client kubernetes.Interface, hostName string, stopCh <-chan struct{}) (*PodCache, error) {
+	podCache := new(PodCache)
 
 	factory := informers.NewSharedInformerFactoryWithOptions(client, time.Minute,
 		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
@@ -55,59 +72,98 @@ func NewPodCache(client kubernetes.Interface, hostName string) {
 		}))
 	podCache.podInformer = factory.Core().V1().Pods()
 
-	ch := make(chan struct{})
-	go podCache.podInformer.Informer().Run(ch)
+	go podCache.podInformer.Informer().Run(stopCh)
 
-	for !podCache.podInformer.Informer().HasSynced() {
-		time.Sleep(time.Second)
+	if !cache.WaitForCacheSync(stopCh, podCache.podInformer.Informer().HasSynced) {
+		return nil, fmt.Errorf("pod cache is stopped before synced")
 	}
 	klog.V(2).Infof("Pod cache is running")
-}
 
-//NewPodCacheForTest creates a new podCache for testing
-func NewPodCacheForTest(client kubernetes.Interface) {
-	podCache = new(PodCache)
+	return podCache, nil
+}
 
-	informers := informers.NewSharedInformerFactory(client, 0)
-	podCache.podInformer = informers.Core().V1().Pods()
-	podCache.podInformer.Informer().AddEventHandler(podCache)
-	ch := make(chan struct{})
-	informers.Start(ch)
+//AddEventHandler registers handler to podInformer
+func (p *PodCache) AddEventHandler(handler cache.ResourceEventHandler) {
+	p.podInformer.Informer().AddEventHandler(handler)
+}
 
-	for !podCache.podInformer.Informer().HasSynced() {
-		time.Sleep(time.Second)
+//GetActivePods get all active pods from podCache and returns them.
+func (p *PodCache) GetActivePods() map[string]*v1.Pod {
+	pods, err := p.podInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil
 	}
-	klog.V(2).Infof("Pod cache is running")
+
+	return activePodsOf(pods)
 }
 
-//OnAdd is a callback function for podInformer, do nothing for now.
-func (p *PodCache) OnAdd(obj interface{}) {}
+//GetPod returns the active pod from podCache
+func (p *PodCache) GetPod(namespace, name string) (*v1.Pod, error) {
+	pod, err := p.podInformer.Lister().Pods(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
 
-//OnUpdate is a callback function for podInformer, do nothing for now.
-func (p *PodCache) OnUpdate(oldObj, newObj interface{}) {}
+	if err := checkActivePod(pod); err != nil {
+		return nil, err
+	}
 
-//OnDelete is a callback function for podInformer, do nothing for now.
-func (p *PodCache) OnDelete(obj interface{}) {}
+	return pod, nil
+}
 
-//GetActivePods get all active pods from podCache and returns them.
-func GetActivePods() map[string]*v1.Pod {
-	if podCache == nil {
-		return nil
+//ListPods returns pods of phase from podCache
+func (p *PodCache) ListPods(phase v1.PodPhase) ([]*v1.Pod, error) {
+	pods, err := p.podInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
 	}
 
-	activePods := make(map[string]*v1.Pod)
+	return podsOfPhase(pods, phase), nil
+}
 
-	for _, item := range podCache.podInformer.Informer().GetStore().List() {
-		pod, ok := item.(*v1.Pod)
-		if !ok {
-			continue
-		}
+//PodTerminationHandler returns an event handler which calls handler with GPU
+//pods which are terminated or removed from cache, including the one of a
+//tombstone. handler may be called more than once for a pod.
+func PodTerminationHandler(handler func(pod *v1.Pod)) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if pod, ok := newObj.(*v1.Pod); ok && podIsTerminated(pod) && utils.IsGPURequiredPod(pod) {
+				handler(pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*v1.Pod); ok && utils.IsGPURequiredPod(pod) {
+				handler(pod)
+			}
+		},
+	}
+}
 
-		if podIsTerminated(pod) {
-			continue
-		}
+//PodChangeHandler returns an event handler which calls handler with GPU
+//pods which are added or updated in cache
+func PodChangeHandler(handler func(pod *v1.Pod)) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*v1.Pod); ok && utils.IsGPURequiredPod(pod) {
+				handler(pod)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if pod, ok := newObj.(*v1.Pod); ok && utils.IsGPURequiredPod(pod) {
+				handler(pod)
+			}
+		},
+	}
+}
 
-		if !utils.IsGPURequiredPod(pod) {
+func activePodsOf(pods []*v1.Pod) map[string]*v1.Pod {
+	activePods := make(map[string]*v1.Pod)
+
+	for _, pod := range pods {
+		if checkActivePod(pod) != nil {
 			continue
 		}
 
@@ -117,21 +173,31 @@ func GetActivePods() map[string]*v1.Pod {
 	return activePods
 }
 
-func GetPod(namespace, name string) (*v1.Pod, error) {
-	pod, err := podCache.podInformer.Lister().Pods(namespace).Get(name)
-	if err != nil {
-		return nil, err
-	}
-
+func checkActivePod(pod *v1.Pod) error {
 	if podIsTerminated(pod) {
-		return nil, fmt.Errorf("terminated pod")
+		return fmt.Errorf("terminated pod")
 	}
 
 	if !utils.IsGPURequiredPod(pod) {
-		return nil, fmt.Errorf("no gpu pod")
+		return fmt.Errorf("no gpu pod")
 	}
 
-	return pod, nil
+	return nil
+}
+
+func podsOfPhase(pods []*v1.Pod, phase v1.PodPhase) []*v1.Pod {
+	if len(phase) == 0 {
+		return pods
+	}
+
+	filtered := make([]*v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.Phase == phase {
+			filtered = append(filtered, pod)
+		}
+	}
+
+	return filtered
 }
 
 func podIsTerminated(pod *v1.Pod) bool {
@@ -33,6 +33,8 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
+const testHostName = "test-node"
+
 func init() {
 	flag.Set("v", "4")
 	flag.Set("logtostderr", "true")
@@ -51,7 +53,7 @@ func TestWatchdog(t *testing.T) {
 			Name: podName,
 			UID:  k8stypes.UID(podUID),
 		},
-		Spec: v1.PodSpec{Containers: []v1.Container{
+		Spec: v1.PodSpec{NodeName: testHostName, Containers: []v1.Container{
 			{
 				Name: containerName,
 				Resources: v1.ResourceRequirements{
@@ -67,11 +69,16 @@ func TestWatchdog(t *testing.T) {
 	k8sclient.CoreV1().Pods(ns).Create(pod)
 
 	// create watchdog and run
-	NewPodCacheForTest(k8sclient)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	podCache, err := NewPodCache(k8sclient, testHostName, stopCh)
+	if err != nil {
+		t.Fatalf("failed to create pod cache: %v", err)
+	}
 
 	// check if watchdog work well
-	err := wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
-		activepods := GetActivePods()
+	err = wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+		activepods := podCache.GetActivePods()
 		if v, ok := activepods[podUID]; !ok || v.Name != podName {
 			t.Logf("can't find pod %s", podName)
 			return false, nil
@@ -82,3 +89,75 @@ func TestWatchdog(t *testing.T) {
 		t.Fatalf("test failed: %s", err.Error())
 	}
 }
+
+func TestPodCacheEventHandler(t *testing.T) {
+	flag.Parse()
+	k8sclient := fake.NewSimpleClientset()
+	newPod := func(name string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				UID:       k8stypes.UID(name + "-uid"),
+			},
+			Spec: v1.PodSpec{NodeName: testHostName, Containers: []v1.Container{{
+				Name: "test-container",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						types.VCoreAnnotation:   resource.MustParse("10"),
+						types.VMemoryAnnotation: resource.MustParse("1"),
+					},
+				},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodPending},
+		}
+	}
+	deletedPod, finishedPod, cpuPod := newPod("deleted"), newPod("finished"), newPod("cpu")
+	cpuPod.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+	for _, pod := range []*v1.Pod{deletedPod, finishedPod, cpuPod} {
+		k8sclient.CoreV1().Pods(pod.Namespace).Create(pod)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	podCache, err := NewPodCache(k8sclient, testHostName, stopCh)
+	if err != nil {
+		t.Fatalf("failed to create pod cache: %v", err)
+	}
+	terminated := make(chan string, 10)
+	podCache.AddEventHandler(PodTerminationHandler(func(pod *v1.Pod) {
+		terminated <- string(pod.UID)
+	}))
+
+	pods, err := podCache.ListPods(v1.PodPending)
+	if err != nil || len(pods) != 3 {
+		t.Fatalf("expect 3 pending pods, got %v, %v", pods, err)
+	}
+	if pods, _ := podCache.ListPods(v1.PodRunning); len(pods) != 0 {
+		t.Fatalf("expect no running pod, got %v", pods)
+	}
+	// pod without GPU is not active
+	if _, err := podCache.GetPod(cpuPod.Namespace, cpuPod.Name); err == nil {
+		t.Fatalf("expect error of pod without gpu")
+	}
+
+	expectTerminated := func(uid k8stypes.UID) {
+		select {
+		case got := <-terminated:
+			if got != string(uid) {
+				t.Fatalf("expect pod %s terminated, got %s", uid, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("termination handler is not called for %s", uid)
+		}
+	}
+
+	// termination of pod without GPU is ignored
+	k8sclient.CoreV1().Pods(cpuPod.Namespace).Delete(cpuPod.Name, &metav1.DeleteOptions{})
+	k8sclient.CoreV1().Pods(deletedPod.Namespace).Delete(deletedPod.Name, &metav1.DeleteOptions{})
+	expectTerminated(deletedPod.UID)
+
+	finishedPod.Status.Phase = v1.PodSucceeded
+	k8sclient.CoreV1().Pods(finishedPod.Namespace).UpdateStatus(finishedPod)
+	expectTerminated(finishedPod.UID)
+}
@@ -0,0 +1,390 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package simulator
+
+import (
+	"fmt"
+	"sort"
+
+	nveval "tkestack.io/gpu-manager/pkg/algorithm/nvidia"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+
+	"k8s.io/klog"
+)
+
+const (
+	//DefaultPolicy places pods with the evaluators of gpu-manager
+	DefaultPolicy = "gpu-manager"
+	//FirstFitPolicy places pods on cards with the lowest index, it's the
+	//baseline of comparison
+	FirstFitPolicy = "first-fit"
+)
+
+//Placer returns cards chosen for the request, nil if it can't be placed
+type Placer func(mode string, cores, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode
+
+//Policy creates a Placer for tree
+type Policy func(tree *nvtree.NvidiaTree, ratio float64) Placer
+
+//Policies are the placement policies can be simulated
+var Policies = map[string]Policy{
+	DefaultPolicy:  evaluatorPolicy,
+	FirstFitPolicy: firstFitPolicy,
+}
+
+type evaluator interface {
+	Evaluate(cores int64, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode
+}
+
+func evaluatorPolicy(tree *nvtree.NvidiaTree, ratio float64) Placer {
+	evaluators := map[string]evaluator{
+		nveval.LinkMode:       nveval.NewLinkMode(tree),
+		nveval.FragmentMode:   nveval.NewFragmentMode(tree),
+		nveval.ShareMode:      nveval.NewShareMode(tree),
+		nveval.BestEffortMode: nveval.NewBestEffortMode(tree, ratio),
+	}
+
+	return func(mode string, cores, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode {
+		return evaluators[mode].Evaluate(cores, memory, filter)
+	}
+}
+
+//firstFitPolicy ignores topology and fragmentation, best-effort work is
+//placed by the evaluator of gpu-manager
+func firstFitPolicy(tree *nvtree.NvidiaTree, ratio float64) Placer {
+	bestEffort := nveval.NewBestEffortMode(tree, ratio)
+
+	return func(mode string, cores, memory int64, filter nvtree.NodeFilter) []*nvtree.NvidiaNode {
+		switch mode {
+		case nveval.BestEffortMode:
+			return bestEffort.Evaluate(cores, memory, filter)
+		case nveval.ShareMode:
+			for _, n := range tree.Leaves() {
+				if filter != nil && !filter(n) {
+					continue
+				}
+
+				if n.AllocatableMeta.Cores >= cores && n.AllocatableMeta.Memory >= memory {
+					return []*nvtree.NvidiaNode{n}
+				}
+			}
+
+			return nil
+		}
+
+		num := int(cores / nvtree.HundredCore)
+		free := tree.Root().GetAvailableLeavesWith(filter)
+		if len(free) < num {
+			return nil
+		}
+
+		return free[:num]
+	}
+}
+
+//Options are the settings of gpu-manager in simulation
+type Options struct {
+	EnableShare           bool
+	OversubscriptionRatio float64
+	//MemoryBlockSize is in bytes, the default size is used if it's 0
+	MemoryBlockSize int64
+}
+
+//Report is the result of replaying a trace with a policy. Utilization and
+//fragmentation are averaged over time of the trace.
+type Report struct {
+	Policy   string `json:"policy"`
+	Pods     int    `json:"pods"`
+	Placed   int    `json:"placed"`
+	Rejected int    `json:"rejected"`
+	//Invalid pods are rejected by validation regardless of policy
+	Invalid       int     `json:"invalid"`
+	RejectionRate float64 `json:"rejectionRate"`
+
+	CoreUtilization   float64 `json:"coreUtilization"`
+	MemoryUtilization float64 `json:"memoryUtilization"`
+	Fragmentation     float64 `json:"fragmentation"`
+	PeakFragmentation float64 `json:"peakFragmentation"`
+
+	//Links counts multi-card placements by the link connecting the cards
+	Links map[string]int `json:"links"`
+	//LinkLevel is the average topology level of multi-card placements,
+	//lower is better
+	LinkLevel float64 `json:"linkLevel"`
+}
+
+type event struct {
+	time   float64
+	depart bool
+	index  int
+}
+
+type allocation struct {
+	nodes  []*nvtree.NvidiaNode
+	mode   string
+	qos    types.QoSClass
+	cores  int64
+	memory int64
+}
+
+//Simulate replays pods on a tree built from topo with policy. Departures
+//happen before arrivals at the same time.
+// #lizard forgives
+func Simulate(topo *nvtree.Topology, pods []Pod, policy string, opt Options) (*Report, error) {
+	newPlacer, ok := Policies[policy]
+	if !ok {
+		return nil, fmt.Errorf("unknown policy %s", policy)
+	}
+
+	tree, err := nvtree.NewNvidiaTreeFromTopology(topo, opt.MemoryBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	place := newPlacer(tree, opt.OversubscriptionRatio)
+	events := make([]event, 0, 2*len(pods))
+	for i, pod := range pods {
+		events = append(events, event{time: pod.Arrival, index: i})
+		if pod.Departure > pod.Arrival {
+			events = append(events, event{time: pod.Departure, depart: true, index: i})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].depart && !events[j].depart
+	})
+
+	report := &Report{
+		Policy: policy,
+		Pods:   len(pods),
+		Links:  make(map[string]int),
+	}
+
+	var (
+		allocated             = make(map[int]*allocation)
+		metrics               = newMetrics(tree)
+		linkLevels, multiCard int
+	)
+
+	for _, ev := range events {
+		metrics.advance(ev.time)
+		pod := &pods[ev.index]
+
+		if ev.depart {
+			if alloc, ok := allocated[ev.index]; ok {
+				for _, n := range alloc.nodes {
+					switch {
+					case alloc.mode == nveval.BestEffortMode:
+						tree.MarkFreeBestEffort(n, alloc.cores, alloc.memory)
+					case alloc.qos == types.QoSGuaranteed:
+						tree.MarkFreeGuaranteed(n, alloc.cores, alloc.memory)
+					default:
+						tree.MarkFree(n, alloc.cores, alloc.memory)
+					}
+				}
+				delete(allocated, ev.index)
+			}
+			metrics.update()
+			continue
+		}
+
+		alloc, err := request(tree, pod, opt)
+		if err != nil {
+			klog.V(2).Infof("Pod %s is invalid, %v", pod.Name, err)
+			report.Invalid++
+			continue
+		}
+
+		evalMemory := int64(0)
+		if alloc.mode == nveval.ShareMode || alloc.mode == nveval.BestEffortMode {
+			evalMemory = alloc.memory
+		}
+
+		alloc.nodes = place(alloc.mode, alloc.cores, evalMemory, nvtree.ModelFilter(pod.Models))
+		if len(alloc.nodes) == 0 {
+			klog.V(2).Infof("Pod %s is rejected by %s at %v", pod.Name, policy, ev.time)
+			report.Rejected++
+			continue
+		}
+
+		for _, n := range alloc.nodes {
+			switch {
+			case alloc.mode == nveval.BestEffortMode:
+				tree.MarkOccupiedBestEffort(n, alloc.cores, alloc.memory)
+			case alloc.qos == types.QoSGuaranteed:
+				tree.MarkOccupiedGuaranteed(n, alloc.cores, alloc.memory)
+			default:
+				tree.MarkOccupied(n, alloc.cores, alloc.memory)
+			}
+		}
+
+		if len(alloc.nodes) > 1 {
+			link := linkOf(alloc.nodes)
+			report.Links[link.String()]++
+			linkLevels += link.Type()
+			multiCard++
+		}
+
+		allocated[ev.index] = alloc
+		report.Placed++
+		metrics.update()
+	}
+
+	if report.Pods > 0 {
+		report.RejectionRate = float64(report.Rejected+report.Invalid) / float64(report.Pods)
+	}
+
+	if multiCard > 0 {
+		report.LinkLevel = float64(linkLevels) / float64(multiCard)
+	}
+
+	report.CoreUtilization, report.MemoryUtilization, report.Fragmentation = metrics.average()
+	report.PeakFragmentation = metrics.peakFragmentation
+
+	return report, nil
+}
+
+//request validates pod and returns the allocation to be placed, memory
+//is committed in whole blocks as allocator does
+func request(tree *nvtree.NvidiaTree, pod *Pod, opt Options) (*allocation, error) {
+	qos := types.QoSGuaranteed
+	if pod.QoS != "" {
+		qos = types.QoSClass(pod.QoS)
+		switch qos {
+		case types.QoSGuaranteed, types.QoSBurstable, types.QoSBestEffort:
+		default:
+			return nil, fmt.Errorf("invalid qos class %s", pod.QoS)
+		}
+	}
+
+	blockSize := tree.MemoryBlockSize()
+	memory := (pod.Memory.Value() + blockSize - 1) / blockSize * blockSize
+
+	mode, err := nveval.SelectModeWithQoS(pod.Cores, memory, opt.EnableShare, qos, opt.OversubscriptionRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	return &allocation{
+		mode:   mode,
+		qos:    qos,
+		cores:  pod.Cores,
+		memory: memory,
+	}, nil
+}
+
+//linkOf returns the lowest common ancestor of nodes
+func linkOf(nodes []*nvtree.NvidiaNode) *nvtree.NvidiaNode {
+	for p := nodes[0].Parent; p != nil; p = p.Parent {
+		covered := true
+		for _, n := range nodes[1:] {
+			if !isAncestor(p, n) {
+				covered = false
+				break
+			}
+		}
+
+		if covered {
+			return p
+		}
+	}
+
+	return nodes[0]
+}
+
+func isAncestor(p, n *nvtree.NvidiaNode) bool {
+	for q := n.Parent; q != nil; q = q.Parent {
+		if q == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+//metrics integrates utilization and fragmentation of tree over time
+type metrics struct {
+	tree *nvtree.NvidiaTree
+
+	start, last             float64
+	started                 bool
+	totalCores, totalMemory int64
+	coreUtil, memoryUtil    float64
+	fragmentation           float64
+	sumCore, sumMemory      float64
+	sumFragmentation        float64
+	peakFragmentation       float64
+}
+
+func newMetrics(tree *nvtree.NvidiaTree) *metrics {
+	m := &metrics{
+		tree:       tree,
+		totalCores: int64(len(tree.Leaves())) * nvtree.HundredCore,
+	}
+
+	for _, n := range tree.Leaves() {
+		m.totalMemory += n.LogicalMemory()
+	}
+
+	return m
+}
+
+//advance accumulates the current state until now
+func (m *metrics) advance(now float64) {
+	if !m.started {
+		m.start, m.last, m.started = now, now, true
+		return
+	}
+
+	elapsed := now - m.last
+	m.sumCore += m.coreUtil * elapsed
+	m.sumMemory += m.memoryUtil * elapsed
+	m.sumFragmentation += m.fragmentation * elapsed
+	m.last = now
+}
+
+//update samples the state after the tree is changed
+func (m *metrics) update() {
+	frag := m.tree.Fragmentation()
+	if m.totalCores > 0 {
+		m.coreUtil = 1 - float64(frag.FreeCores)/float64(m.totalCores)
+	}
+	if m.totalMemory > 0 {
+		m.memoryUtil = 1 - float64(frag.FreeMemory)/float64(m.totalMemory)
+	}
+
+	m.fragmentation = frag.Ratio
+	if frag.Ratio > m.peakFragmentation {
+		m.peakFragmentation = frag.Ratio
+	}
+}
+
+//average returns the averages over time, the last state is returned if
+//all events happen at the same time
+func (m *metrics) average() (core, memory, fragmentation float64) {
+	span := m.last - m.start
+	if span <= 0 {
+		return m.coreUtil, m.memoryUtil, m.fragmentation
+	}
+
+	return m.sumCore / span, m.sumMemory / span, m.sumFragmentation / span
+}
@@ -0,0 +1,161 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package simulator
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func init() {
+	flag.Set("v", "4")
+	flag.Set("logtostderr", "true")
+}
+
+const testTopology = `    GPU0    GPU1    GPU2    GPU3
+GPU0      X      PIX     PHB     PHB
+GPU1     PIX      X      PHB     PHB
+GPU2     PHB     PHB      X      PIX
+GPU3     PHB     PHB     PIX      X
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("can't write %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestLoadTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpu-sim")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	csvPods, err := LoadTrace(writeFile(t, dir, "trace.csv", `name, arrival, departure, cores, memory, qos, models
+a, 0, 10, 50, 1Gi, best-effort, v100;t4
+b, 1, , 100, , ,
+`))
+	if err != nil {
+		t.Fatalf("can't load csv trace: %v", err)
+	}
+
+	jsonPods, err := LoadTrace(writeFile(t, dir, "trace.json", `[
+{"name": "a", "arrival": 0, "departure": 10, "cores": 50, "memory": "1Gi", "qos": "best-effort", "models": ["v100", "t4"]},
+{"name": "b", "arrival": 1, "cores": 100}
+]`))
+	if err != nil {
+		t.Fatalf("can't load json trace: %v", err)
+	}
+
+	for _, pods := range [][]Pod{csvPods, jsonPods} {
+		if len(pods) != 2 {
+			t.Fatalf("expect 2 pods, got %+v", pods)
+		}
+
+		a, b := pods[0], pods[1]
+		if a.Name != "a" || a.Departure != 10 || a.Cores != 50 || a.Memory.Value() != 1<<30 ||
+			a.QoS != "best-effort" || strings.Join(a.Models, ",") != "v100,t4" {
+			t.Errorf("unexpected pod %+v", a)
+		}
+
+		if b.Arrival != 1 || b.Departure != 0 || b.Cores != 100 || !b.Memory.IsZero() {
+			t.Errorf("unexpected pod %+v", b)
+		}
+	}
+
+	if _, err := LoadTrace(writeFile(t, dir, "invalid.csv", "name,arrival\na,0\n")); err == nil {
+		t.Fatalf("trace without required columns should be rejected")
+	}
+
+	topo, err := LoadTopology(writeFile(t, dir, "topo", testTopology), 4*types.MemoryBlockSize, "Tesla T4")
+	if err != nil {
+		t.Fatalf("can't load topology: %v", err)
+	}
+
+	if len(topo.Devices) != 4 || topo.Devices[3].MinorID != 3 || topo.Devices[3].Model != "Tesla T4" {
+		t.Fatalf("unexpected topology %+v", topo)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpu-sim")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	topo, err := LoadTopology(writeFile(t, dir, "topo", testTopology), 4*types.MemoryBlockSize, "")
+	if err != nil {
+		t.Fatalf("can't load topology: %v", err)
+	}
+
+	pods, err := parseCSVTrace(strings.NewReader(`name,arrival,departure,cores,memory
+a,0,10,50,256Mi
+b,0,10,200,
+c,0,10,100,
+invalid,0,10,150,
+d,0,10,100,
+e,10,20,400,
+`))
+	if err != nil {
+		t.Fatalf("can't parse trace: %v", err)
+	}
+
+	// gpu-manager keeps b on the pair linked by PIX, first-fit splits it.
+	// d is rejected since all cards are used, e takes all cards after
+	// others depart.
+	testCases := map[string]map[string]int{
+		DefaultPolicy:  {"PIX": 1, "PHB": 1},
+		FirstFitPolicy: {"PHB": 2},
+	}
+
+	for policy, links := range testCases {
+		report, err := Simulate(topo, pods, policy, Options{EnableShare: true})
+		if err != nil {
+			t.Fatalf("can't simulate %s: %v", policy, err)
+		}
+
+		if report.Placed != 4 || report.Rejected != 1 || report.Invalid != 1 || report.RejectionRate != 2.0/6 {
+			t.Errorf("%s: unexpected placement %+v", policy, report)
+		}
+
+		if !reflect.DeepEqual(report.Links, links) {
+			t.Errorf("%s: expect links %v, got %v", policy, links, report.Links)
+		}
+
+		expect := (350.0/400 + 1) / 2
+		if diff := report.CoreUtilization - expect; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: expect core utilization %v, got %v", policy, expect, report.CoreUtilization)
+		}
+	}
+
+	if _, err := Simulate(topo, pods, "unknown", Options{}); err == nil {
+		t.Fatalf("unknown policy should be rejected")
+	}
+}
@@ -0,0 +1,194 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package simulator
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+)
+
+//Pod is a GPU container in the trace. It arrives at Arrival and departs
+//at Departure in seconds, it never departs if Departure is not greater
+//than Arrival.
+type Pod struct {
+	Name      string            `json:"name"`
+	Arrival   float64           `json:"arrival"`
+	Departure float64           `json:"departure"`
+	Cores     int64             `json:"cores"`
+	Memory    resource.Quantity `json:"memory"`
+	//QoS is guaranteed if it's empty
+	QoS    string   `json:"qos,omitempty"`
+	Models []string `json:"models,omitempty"`
+}
+
+//LoadTrace reads pods from a JSON array, or a CSV file if the name ends
+//with .csv. The CSV file has a header of columns name, arrival,
+//departure, cores, memory and optional qos and models, models are
+//separated by semicolons.
+func LoadTrace(path string) ([]Pod, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseCSVTrace(f)
+	}
+
+	var pods []Pod
+	if err := json.NewDecoder(f).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("invalid trace %s, %v", path, err)
+	}
+
+	return pods, nil
+}
+
+func parseCSVTrace(r io.Reader) ([]Pod, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can't read header of trace, %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"name", "arrival", "departure", "cores", "memory"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing in trace", name)
+		}
+	}
+
+	var pods []Pod
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return pods, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		pod := Pod{
+			Name: field("name"),
+			QoS:  field("qos"),
+		}
+
+		if pod.Arrival, err = strconv.ParseFloat(field("arrival"), 64); err != nil {
+			return nil, fmt.Errorf("invalid arrival at line %d, %v", line, err)
+		}
+
+		if departure := field("departure"); departure != "" {
+			if pod.Departure, err = strconv.ParseFloat(departure, 64); err != nil {
+				return nil, fmt.Errorf("invalid departure at line %d, %v", line, err)
+			}
+		}
+
+		if pod.Cores, err = strconv.ParseInt(field("cores"), 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid cores at line %d, %v", line, err)
+		}
+
+		if memory := field("memory"); memory != "" {
+			if pod.Memory, err = resource.ParseQuantity(memory); err != nil {
+				return nil, fmt.Errorf("invalid memory at line %d, %v", line, err)
+			}
+		}
+
+		if models := field("models"); models != "" {
+			pod.Models = strings.Split(models, ";")
+		}
+
+		pods = append(pods, pod)
+	}
+}
+
+var matrixSplitter = regexp.MustCompile("[ \t]+")
+
+//LoadTopology reads a Topology in JSON, which is the same as the
+//tencent.com/gpu-topology annotation of nodes, or a matrix in the format
+//of `nvidia-smi topo -m` without columns other than GPUs. Cards of a
+//matrix have memory of cardMemory and model of cardModel.
+func LoadTopology(path string, cardMemory uint64, cardModel string) (*nvtree.Topology, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	topo := &nvtree.Topology{}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		if err := json.Unmarshal(data, topo); err != nil {
+			return nil, fmt.Errorf("invalid topology %s, %v", path, err)
+		}
+
+		return topo, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	var lines []string
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			lines = append(lines, scanner.Text())
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("topology %s is empty", path)
+	}
+
+	topo.Matrix = strings.Join(lines, "\n") + "\n"
+	for i, name := range matrixSplitter.Split(strings.TrimSpace(lines[0]), -1) {
+		if !strings.HasPrefix(name, "GPU") {
+			return nil, fmt.Errorf("unexpected column %s in topology %s", name, path)
+		}
+
+		topo.Devices = append(topo.Devices, nvtree.TopologyDevice{
+			ID:          i,
+			MinorID:     i,
+			Model:       cardModel,
+			TotalMemory: cardMemory,
+		})
+	}
+
+	return topo, nil
+}
@@ -29,11 +29,18 @@ const (
 	PredicateTimeAnnotation = "tencent.com/predicate-time"
 	PredicateGPUIndexPrefix = "tencent.com/predicate-gpu-idx-"
 	GPUAssigned             = "tencent.com/gpu-assigned"
+	GPUTopologyAnnotation   = "tencent.com/gpu-topology"
+	GPUInventoryAnnotation  = "tencent.com/gpu-inventory"
+	GPUModelAnnotation      = "tencent.com/gpu-model"
+	VCudaQoSAnnotation      = "tencent.com/vcuda-qos"
+	VMemorySwapAnnotation   = "tencent.com/vcuda-memory-swap"
+	VMemoryBlockAnnotation  = "tencent.com/vcuda-memory-block-size"
+	VMemoryAllocatedPrefix  = "tencent.com/vcuda-memory-allocated-"
 	ClusterNameAnnotation   = "clusterName"
 
 	VCUDA_MOUNTPOINT = "/etc/vcuda"
 
-	/** 256MB */
+	/** 256MB, default size of vmemory block */
 	MemoryBlockSize = 268435456
 
 	KubeletSocket                 = "kubelet.sock"
@@ -42,6 +49,8 @@ const (
 	PreStartContainerCheckErrMsg  = "PreStartContainer check failed"
 	PreStartContainerCheckErrType = "PreStartContainerCheckErr"
 	UnexpectedAdmissionErrType    = "UnexpectedAdmissionError"
+	GPUQuotaExceededErrMsg        = "GPU quota exceeded"
+	GPUQuotaExceededErrType       = "GPUQuotaExceeded"
 )
 
 const (
@@ -55,9 +64,19 @@ const (
 	ManagerSocket = "/var/run/gpu-manager.sock"
 )
 
+//QoSClass is the time-slice QoS class of containers sharing a GPU
+type QoSClass string
+
+const (
+	QoSGuaranteed QoSClass = "guaranteed"
+	QoSBurstable  QoSClass = "burstable"
+	QoSBestEffort QoSClass = "best-effort"
+)
+
 const (
-	CGROUP_BASE  = "/sys/fs/cgroup/memory"
+	CGROUP_ROOT  = "/sys/fs/cgroup"
 	CGROUP_PROCS = "cgroup.procs"
+	PROC_ROOT    = "/proc"
 )
 
 type VCudaRequest struct {
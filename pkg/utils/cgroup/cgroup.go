@@ -6,8 +6,6 @@ import (
 	"strings"
 
 	cgroupsystemd "github.com/opencontainers/runc/libcontainer/cgroups/systemd"
-
-	"k8s.io/klog"
 )
 
 // CgroupName is the abstract name of a cgroup prior to any driver specific conversion.
@@ -69,14 +67,32 @@ func (cgroupName CgroupName) ToCgroupfs() string {
 	return "/" + path.Join(cgroupName...)
 }
 
-func SystemdPathPrefixOfRuntime(runtimeName string) string {
-	switch runtimeName {
-	case "cri-o":
-		return "crio"
-	case "containerd":
-		return "cri-containerd"
-	default:
-		klog.Infof("prefix of container runtime %s was not tested. Maybe not correct!", runtimeName)
-		return runtimeName
+// ConvertCgroupsPath converts cgroupsPath of OCI runtime spec to the path
+// relative to cgroup hierarchy. Systemd driver uses "slice:prefix:name",
+// which means name.scope of prefix under slice.
+func ConvertCgroupsPath(cgroupsPath string) (string, error) {
+	parts := strings.Split(cgroupsPath, ":")
+	if len(parts) == 1 {
+		return path.Clean("/" + cgroupsPath), nil
+	}
+
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid cgroups path %s", cgroupsPath)
 	}
+
+	slice := "/"
+	if parts[0] != "" {
+		expanded, err := cgroupsystemd.ExpandSlice(parts[0])
+		if err != nil {
+			return "", err
+		}
+		slice = expanded
+	}
+
+	scope := parts[2] + ".scope"
+	if parts[1] != "" {
+		scope = parts[1] + "-" + scope
+	}
+
+	return path.Join(slice, scope), nil
 }
@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package cgroup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//Mode is the cgroup hierarchy layout of host
+type Mode int
+
+const (
+	//Legacy mounts every v1 controller under its own directory
+	Legacy Mode = iota
+	//Hybrid mounts v1 controllers and an extra v2 hierarchy at unified
+	Hybrid
+	//Unified mounts only the v2 hierarchy at root
+	Unified
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Legacy:
+		return "legacy"
+	case Hybrid:
+		return "hybrid"
+	case Unified:
+		return "unified"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+//DetectMode returns the cgroup mode of hierarchy mounted at root. Only v2
+//hierarchy has cgroup.controllers at its top.
+func DetectMode(root string) (Mode, error) {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return Unified, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "memory")); err != nil {
+		return Legacy, fmt.Errorf("neither cgroup v2 nor memory controller is found in %s", root)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "unified", "cgroup.controllers")); err == nil {
+		return Hybrid, nil
+	}
+
+	return Legacy, nil
+}
+
+//ProcsBaseDir returns the directory where container cgroups are created.
+//Kubelet doesn't use v2 hierarchy of hybrid mode, so memory controller is
+//used for both legacy and hybrid mode.
+func ProcsBaseDir(root string, mode Mode) string {
+	if mode == Unified {
+		return root
+	}
+
+	return filepath.Join(root, "memory")
+}
@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectMode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   []string
+		dirs    []string
+		mode    Mode
+		baseDir string
+	}{
+		{"legacy", nil, []string{"memory", "cpu"}, Legacy, "memory"},
+		{"hybrid", []string{"unified/cgroup.controllers"}, []string{"memory", "unified"}, Hybrid, "memory"},
+		{"unified", []string{"cgroup.controllers"}, []string{"kubepods"}, Unified, ""},
+	}
+
+	for _, tc := range testCases {
+		root, err := ioutil.TempDir("", "cgroup")
+		if err != nil {
+			t.Fatalf("can't create dir, %v", err)
+		}
+		defer os.RemoveAll(root)
+
+		for _, dir := range tc.dirs {
+			os.MkdirAll(filepath.Join(root, dir), 0755)
+		}
+		for _, file := range tc.files {
+			ioutil.WriteFile(filepath.Join(root, file), []byte("cpu memory pids\n"), 0644)
+		}
+
+		mode, err := DetectMode(root)
+		if err != nil || mode != tc.mode {
+			t.Fatalf("%s: expect %s, got %s, %v", tc.name, tc.mode, mode, err)
+		}
+
+		if baseDir := ProcsBaseDir(root, mode); baseDir != filepath.Join(root, tc.baseDir) {
+			t.Fatalf("%s: unexpected base dir %s", tc.name, baseDir)
+		}
+	}
+
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+	if _, err := DetectMode(root); err == nil {
+		t.Fatalf("expect error for empty root")
+	}
+}
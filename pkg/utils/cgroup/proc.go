@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// podUIDPattern matches pod UID in both cgroupfs and systemd cgroup path,
+	// systemd driver replaces "-" with "_"
+	podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+	// containerIDPattern matches the last component of container cgroup,
+	// e.g. docker-<id>.scope, cri-containerd-<id>.scope or <id>
+	containerIDPattern = regexp.MustCompile(`^(?:[a-z-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+)
+
+// GetPodContainerOfProcess returns pod UID and container ID of process by
+// reading /proc/<pid>/cgroup
+func GetPodContainerOfProcess(pid int) (podUID, containerID string, err error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", "", err
+	}
+
+	return ParsePodContainer(data)
+}
+
+// ParsePodContainer returns pod UID and container ID from the content of
+// /proc/<pid>/cgroup, every line is in the format of id:controllers:path
+func ParsePodContainer(data []byte) (podUID, containerID string, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		cgroupPath := fields[2]
+		podMatch := podUIDPattern.FindStringSubmatch(cgroupPath)
+		containerMatch := containerIDPattern.FindStringSubmatch(path.Base(cgroupPath))
+		if podMatch == nil || containerMatch == nil {
+			continue
+		}
+
+		return strings.Replace(podMatch[1], "_", "-", -1), containerMatch[1], nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	return "", "", fmt.Errorf("no pod container found in cgroup")
+}
+
+// ParseProcessCgroup returns the cgroup path of process from the content of
+// /proc/<pid>/cgroup, the path of memory controller is used for v1 hierarchy
+func ParseProcessCgroup(data []byte, mode Mode) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		if mode == Unified {
+			if fields[0] == "0" && fields[1] == "" {
+				return fields[2], nil
+			}
+			continue
+		}
+
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "memory" {
+				return fields[2], nil
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no %s cgroup found", mode)
+}
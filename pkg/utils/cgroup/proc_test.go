@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package cgroup
+
+import (
+	"testing"
+)
+
+func TestParsePodContainer(t *testing.T) {
+	const (
+		podUID      = "0d1e5a3f-2c4b-4e8a-9f10-1234567890ab"
+		containerID = "4c1f7a6b2e9d8c0a1b3e5f7a9c2d4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b5c7d"
+	)
+
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"cgroupfs", "12:memory:/kubepods/burstable/pod" + podUID + "/" + containerID + "\n" +
+			"11:cpu,cpuacct:/kubepods/burstable/pod" + podUID + "/" + containerID + "\n"},
+		{"systemd", "12:memory:/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" +
+			"0d1e5a3f_2c4b_4e8a_9f10_1234567890ab.slice/docker-" + containerID + ".scope\n"},
+		{"containerd", "1:name=systemd:/kubepods.slice/kubepods-pod0d1e5a3f_2c4b_4e8a_9f10_1234567890ab.slice/" +
+			"cri-containerd-" + containerID + ".scope\n"},
+		{"unified", "0::/kubepods/pod" + podUID + "/" + containerID + "\n"},
+	}
+
+	for _, tc := range testCases {
+		pod, container, err := ParsePodContainer([]byte(tc.data))
+		if err != nil || pod != podUID || container != containerID {
+			t.Fatalf("%s: expect %s/%s, got %s/%s, %v", tc.name, podUID, containerID, pod, container, err)
+		}
+	}
+
+	// processes of host or pod sandbox cgroup don't belong to any container
+	for _, data := range []string{
+		"12:memory:/user.slice\n",
+		"12:memory:/kubepods/burstable/pod" + podUID + "\n",
+	} {
+		if _, _, err := ParsePodContainer([]byte(data)); err == nil {
+			t.Fatalf("expect error for %q", data)
+		}
+	}
+}
+
+func TestParseProcessCgroup(t *testing.T) {
+	data := []byte("12:memory:/kubepods/besteffort/podabc/123\n" +
+		"11:cpu,cpuacct:/kubepods/besteffort/podabc/123\n" +
+		"0::/system.slice/containerd.service\n")
+
+	testCases := []struct {
+		mode     Mode
+		expected string
+	}{
+		{Legacy, "/kubepods/besteffort/podabc/123"},
+		{Hybrid, "/kubepods/besteffort/podabc/123"},
+		{Unified, "/system.slice/containerd.service"},
+	}
+
+	for _, tc := range testCases {
+		if p, err := ParseProcessCgroup(data, tc.mode); err != nil || p != tc.expected {
+			t.Fatalf("%s: expect %s, got %s, %v", tc.mode, tc.expected, p, err)
+		}
+	}
+
+	if _, err := ParseProcessCgroup([]byte("11:cpu,cpuacct:/\n"), Legacy); err == nil {
+		t.Fatalf("expect error without memory controller")
+	}
+}
+
+func TestConvertCgroupsPath(t *testing.T) {
+	testCases := []struct {
+		cgroupsPath string
+		expected    string
+	}{
+		{"/kubepods/burstable/podabc/123", "/kubepods/burstable/podabc/123"},
+		{"kubepods-burstable-podabc.slice:cri-containerd:123",
+			"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-podabc.slice/cri-containerd-123.scope"},
+		{"system.slice:crio:123", "/system.slice/crio-123.scope"},
+		{":docker:123", "/docker-123.scope"},
+	}
+
+	for _, tc := range testCases {
+		if p, err := ConvertCgroupsPath(tc.cgroupsPath); err != nil || p != tc.expected {
+			t.Fatalf("%s: expect %s, got %s, %v", tc.cgroupsPath, tc.expected, p, err)
+		}
+	}
+
+	if _, err := ConvertCgroupsPath("a:b"); err == nil {
+		t.Fatalf("expect error for invalid path")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+//CheckpointIndex indexes entries of kubelet device plugin checkpoint by
+//device and container. The checkpoint file is parsed again only if it's
+//changed, so lookups don't scan all entries for every request.
+type CheckpointIndex struct {
+	sync.Mutex
+
+	devicePluginPath string
+	modTime          time.Time
+	size             int64
+
+	//byDevice maps a device ID to entries having it, a device is allocated
+	//to one container, but stale entries may still exist in checkpoint
+	byDevice    map[string][]*types.PodDevicesEntry
+	byContainer map[string]*types.PodDevicesEntry
+}
+
+//NewCheckpointIndex returns a new CheckpointIndex of the checkpoint under
+//devicePluginPath
+func NewCheckpointIndex(devicePluginPath string) *CheckpointIndex {
+	return &CheckpointIndex{
+		devicePluginPath: devicePluginPath,
+	}
+}
+
+//FindByDevices returns the entry of resource which has exactly the same
+//devices, nil is returned if not found
+func (idx *CheckpointIndex) FindByDevices(resourceName string, devices []string) (*types.PodDevicesEntry, error) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	if err := idx.refresh(); err != nil {
+		return nil, err
+	}
+
+	if len(devices) == 0 {
+		return nil, nil
+	}
+
+	for _, entry := range idx.byDevice[devices[0]] {
+		if entry.ResourceName == resourceName && sameDevices(entry.DeviceIDs, devices) {
+			return entry, nil
+		}
+	}
+
+	return nil, nil
+}
+
+//FindByContainer returns the entry of resource allocated to container,
+//nil is returned if not found
+func (idx *CheckpointIndex) FindByContainer(podUID, containerName, resourceName string) (*types.PodDevicesEntry, error) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	if err := idx.refresh(); err != nil {
+		return nil, err
+	}
+
+	return idx.byContainer[containerKey(podUID, containerName, resourceName)], nil
+}
+
+func (idx *CheckpointIndex) refresh() error {
+	info, err := os.Stat(filepath.Join(idx.devicePluginPath, types.CheckPointFileName))
+	if err != nil {
+		return err
+	}
+
+	if idx.byDevice != nil && info.ModTime().Equal(idx.modTime) && info.Size() == idx.size {
+		return nil
+	}
+
+	cp, err := GetCheckpointData(idx.devicePluginPath)
+	if err != nil {
+		return err
+	}
+
+	byDevice := make(map[string][]*types.PodDevicesEntry)
+	byContainer := make(map[string]*types.PodDevicesEntry, len(cp.PodDeviceEntries))
+	for i := range cp.PodDeviceEntries {
+		entry := &cp.PodDeviceEntries[i]
+		for _, id := range entry.DeviceIDs {
+			byDevice[id] = append(byDevice[id], entry)
+		}
+		byContainer[containerKey(entry.PodUID, entry.ContainerName, entry.ResourceName)] = entry
+	}
+
+	klog.V(4).Infof("Index %d entries of device plugin checkpoint", len(cp.PodDeviceEntries))
+	idx.byDevice, idx.byContainer = byDevice, byContainer
+	idx.modTime, idx.size = info.ModTime(), info.Size()
+
+	return nil
+}
+
+func containerKey(podUID, containerName, resourceName string) string {
+	return podUID + "/" + containerName + "/" + resourceName
+}
+
+//sameDevices returns true if a and b have the same devices, unlike
+//IsStringSliceEqual, it doesn't reorder the slices
+func sameDevices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[string]int, len(a))
+	for _, v := range a {
+		set[v]++
+	}
+
+	for _, v := range b {
+		if set[v] == 0 {
+			return false
+		}
+		set[v]--
+	}
+
+	return true
+}
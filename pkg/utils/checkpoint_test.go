@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func writeCheckpoint(t *testing.T, dir string, entries []types.PodDevicesEntry, modTime time.Time) {
+	data, err := json.Marshal(&types.CheckpointData{
+		Data: &types.Checkpoint{PodDeviceEntries: entries},
+	})
+	if err != nil {
+		t.Fatalf("can't marshal checkpoint: %v", err)
+	}
+
+	file := filepath.Join(dir, types.CheckPointFileName)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatalf("can't write checkpoint: %v", err)
+	}
+
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("can't change time of checkpoint: %v", err)
+	}
+}
+
+func TestCheckpointIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	writeCheckpoint(t, dir, []types.PodDevicesEntry{
+		{PodUID: "stale", ContainerName: "c", ResourceName: types.VCoreAnnotation, DeviceIDs: []string{"core-1"}},
+		{PodUID: "pod1", ContainerName: "c", ResourceName: types.VCoreAnnotation, DeviceIDs: []string{"core-1", "core-2"}},
+		{PodUID: "pod1", ContainerName: "c", ResourceName: types.VMemoryAnnotation, DeviceIDs: []string{"memory-1"}},
+	}, now)
+
+	idx := NewCheckpointIndex(dir)
+	req := []string{"core-2", "core-1"}
+	entry, err := idx.FindByDevices(types.VCoreAnnotation, req)
+	if err != nil || entry == nil || entry.PodUID != "pod1" {
+		t.Fatalf("expect entry of pod1, got %+v, %v", entry, err)
+	}
+
+	if req[0] != "core-2" {
+		t.Fatalf("request devices should not be reordered")
+	}
+
+	entry, err = idx.FindByContainer("pod1", "c", types.VMemoryAnnotation)
+	if err != nil || entry == nil || entry.DeviceIDs[0] != "memory-1" {
+		t.Fatalf("expect memory entry of pod1, got %+v, %v", entry, err)
+	}
+
+	if entry, _ := idx.FindByDevices(types.VCoreAnnotation, []string{"core-3"}); entry != nil {
+		t.Fatalf("expect no entry, got %+v", entry)
+	}
+
+	// the index is rebuilt after checkpoint is changed
+	writeCheckpoint(t, dir, []types.PodDevicesEntry{
+		{PodUID: "pod2", ContainerName: "c", ResourceName: types.VCoreAnnotation, DeviceIDs: []string{"core-3"}},
+	}, now.Add(time.Second))
+
+	entry, err = idx.FindByDevices(types.VCoreAnnotation, []string{"core-3"})
+	if err != nil || entry == nil || entry.PodUID != "pod2" {
+		t.Fatalf("expect entry of pod2, got %+v, %v", entry, err)
+	}
+
+	if entry, _ := idx.FindByContainer("pod1", "c", types.VMemoryAnnotation); entry != nil {
+		t.Fatalf("entry of pod1 should be removed, got %+v", entry)
+	}
+}
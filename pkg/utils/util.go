@@ -320,6 +320,64 @@ func GetPredicateTimeOfPod(pod *v1.Pod) (predicateTime uint64) {
 	return predicateTime
 }
 
+//GetGPUModelSelectorsOfPod returns GPU models selected by pod annotation,
+//empty result means any model
+func GetGPUModelSelectorsOfPod(pod *v1.Pod) []string {
+	var selectors []string
+
+	for _, s := range strings.Split(pod.ObjectMeta.Annotations[types.GPUModelAnnotation], ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			selectors = append(selectors, s)
+		}
+	}
+
+	return selectors
+}
+
+//GetQoSClassOfPod returns the time-slice QoS class of pod, annotation
+//takes precedence over Kubernetes QoS class. Kubernetes QoS class is only
+//honoured if the pod requests cpu or memory, a pod only requesting GPU is
+//BestEffort to Kubernetes but not meant to be best-effort on GPU, so it's
+//burstable without annotation.
+func GetQoSClassOfPod(pod *v1.Pod) (types.QoSClass, error) {
+	if qos, ok := pod.ObjectMeta.Annotations[types.VCudaQoSAnnotation]; ok {
+		switch types.QoSClass(qos) {
+		case types.QoSGuaranteed, types.QoSBurstable, types.QoSBestEffort:
+			return types.QoSClass(qos), nil
+		}
+
+		return "", fmt.Errorf("invalid qos class %s of pod %s", qos, pod.UID)
+	}
+
+	if !requestsComputeResources(pod) {
+		return types.QoSBurstable, nil
+	}
+
+	switch pod.Status.QOSClass {
+	case v1.PodQOSGuaranteed:
+		return types.QoSGuaranteed, nil
+	case v1.PodQOSBestEffort:
+		return types.QoSBestEffort, nil
+	}
+
+	return types.QoSBurstable, nil
+}
+
+func requestsComputeResources(pod *v1.Pod) bool {
+	containers := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, c := range containers {
+		for _, list := range []v1.ResourceList{c.Resources.Requests, c.Resources.Limits} {
+			for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				if q, ok := list[name]; ok && !q.IsZero() {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func GetGPUResourceOfContainer(container *v1.Container, resourceName v1.ResourceName) uint {
 	var count uint
 	if val, ok := container.Resources.Limits[resourceName]; ok {
@@ -328,6 +386,21 @@ func GetGPUResourceOfContainer(container *v1.Container, resourceName v1.Resource
 	return count
 }
 
+//GetGPUMemoryOfContainer returns GPU memory of the i-th container of pod
+//in bytes. Memory recorded by allocator is used if the container is
+//allocated, so it doesn't change with vmemory block size of the node,
+//otherwise vmemory count is converted by blockSize.
+func GetGPUMemoryOfContainer(pod *v1.Pod, i int, blockSize int64) int64 {
+	if data, ok := pod.ObjectMeta.Annotations[types.VMemoryAllocatedPrefix+strconv.Itoa(i)]; ok {
+		if memory, err := strconv.ParseInt(data, 10, 64); err == nil && memory >= 0 {
+			return memory
+		}
+		klog.Warningf("Invalid allocated memory %q of container %d of pod %s", data, i, pod.UID)
+	}
+
+	return int64(GetGPUResourceOfContainer(&pod.Spec.Containers[i], types.VMemoryAnnotation)) * blockSize
+}
+
 func GetContainerIndexByName(pod *v1.Pod, containerName string) (int, error) {
 	containerIndex := -1
 	for i, c := range pod.Spec.Containers {
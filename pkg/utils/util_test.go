@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func TestGetQoSClassOfPod(t *testing.T) {
+	newPod := func(annotations map[string]string, qos v1.PodQOSClass, resources v1.ResourceList) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Name:      "c",
+					Resources: v1.ResourceRequirements{Limits: resources},
+				}},
+			},
+			Status: v1.PodStatus{QOSClass: qos},
+		}
+	}
+
+	gpuOnly := v1.ResourceList{
+		types.VCoreAnnotation:   resource.MustParse("50"),
+		types.VMemoryAnnotation: resource.MustParse("4"),
+	}
+	withCPU := v1.ResourceList{
+		v1.ResourceCPU:        resource.MustParse("1"),
+		v1.ResourceMemory:     resource.MustParse("1Gi"),
+		types.VCoreAnnotation: resource.MustParse("50"),
+	}
+
+	testCases := []struct {
+		pod    *v1.Pod
+		expect types.QoSClass
+		err    bool
+	}{
+		{newPod(nil, v1.PodQOSBestEffort, gpuOnly), types.QoSBurstable, false},
+		{newPod(nil, v1.PodQOSGuaranteed, withCPU), types.QoSGuaranteed, false},
+		{newPod(nil, v1.PodQOSBestEffort, v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")}), types.QoSBurstable, false},
+		{newPod(map[string]string{types.VCudaQoSAnnotation: "best-effort"}, v1.PodQOSBestEffort, gpuOnly), types.QoSBestEffort, false},
+		{newPod(map[string]string{types.VCudaQoSAnnotation: "invalid"}, v1.PodQOSGuaranteed, withCPU), "", true},
+	}
+
+	for i, tc := range testCases {
+		qos, err := GetQoSClassOfPod(tc.pod)
+		if (err != nil) != tc.err || qos != tc.expect {
+			t.Errorf("case %d: expect %q (error %v), got %q (%v)", i, tc.expect, tc.err, qos, err)
+		}
+	}
+}
+
+func TestGetGPUMemoryOfContainer(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{types.VMemoryAllocatedPrefix + "1": "536870912"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Limits: v1.ResourceList{types.VMemoryAnnotation: resource.MustParse("2")}}},
+				{Resources: v1.ResourceRequirements{Limits: v1.ResourceList{types.VMemoryAnnotation: resource.MustParse("2")}}},
+			},
+		},
+	}
+
+	if memory := GetGPUMemoryOfContainer(pod, 0, 1<<30); memory != 2<<30 {
+		t.Errorf("memory of unallocated container should be converted by block size, got %d", memory)
+	}
+
+	if memory := GetGPUMemoryOfContainer(pod, 1, 1<<30); memory != 512<<20 {
+		t.Errorf("memory of allocated container should be recorded one, got %d", memory)
+	}
+}
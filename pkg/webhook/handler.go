@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	//ValidatePath is the url path of validating webhook
+	ValidatePath = "/validate"
+	//MutatePath is the url path of mutating webhook
+	MutatePath = "/mutate"
+)
+
+var podResource = metav1.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+//Handler returns a http handler serves the admission reviews. The review
+//of admission.k8s.io/v1 has the same layout as v1beta1, the response is
+//sent with the version of request.
+func (w *Webhook) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(ValidatePath, func(rw http.ResponseWriter, r *http.Request) {
+		serve(rw, r, func(pod *v1.Pod, resp *admissionv1beta1.AdmissionResponse) {
+			if err := w.Validate(pod); err != nil {
+				klog.V(2).Infof("Reject pod %s/%s, %v", pod.Namespace, pod.Name, err)
+				resp.Allowed = false
+				resp.Result = &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+					Reason:  metav1.StatusReasonInvalid,
+					Code:    http.StatusUnprocessableEntity,
+				}
+			}
+		})
+	})
+
+	mux.HandleFunc(MutatePath, func(rw http.ResponseWriter, r *http.Request) {
+		serve(rw, r, func(pod *v1.Pod, resp *admissionv1beta1.AdmissionResponse) {
+			patch := w.Mutate(pod)
+			if len(patch) == 0 {
+				return
+			}
+
+			data, err := json.Marshal(patch)
+			if err != nil {
+				klog.Errorf("Failed to marshal patch of pod %s/%s, %v", pod.Namespace, pod.Name, err)
+				return
+			}
+
+			klog.V(2).Infof("Patch vcuda request of pod %s/%s", pod.Namespace, pod.Name)
+			patchType := admissionv1beta1.PatchTypeJSONPatch
+			resp.Patch = data
+			resp.PatchType = &patchType
+		})
+	})
+
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Write([]byte("ok"))
+	})
+
+	return mux
+}
+
+func serve(rw http.ResponseWriter, r *http.Request, admit func(*v1.Pod, *admissionv1beta1.AdmissionResponse)) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	review := &admissionv1beta1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if review.Request == nil {
+		http.Error(rw, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	req := review.Request
+	resp := &admissionv1beta1.AdmissionResponse{
+		UID:     req.UID,
+		Allowed: true,
+	}
+
+	// Container resources are immutable, so only created pods are admitted,
+	// other operations are allowed unchanged
+	if req.Resource == podResource && req.Operation == admissionv1beta1.Create {
+		pod := &v1.Pod{}
+		if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+			resp.Allowed = false
+			resp.Result = &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: fmt.Sprintf("can't decode pod, %v", err),
+				Reason:  metav1.StatusReasonBadRequest,
+				Code:    http.StatusBadRequest,
+			}
+		} else {
+			if pod.Namespace == "" {
+				pod.Namespace = req.Namespace
+			}
+			admit(pod, resp)
+		}
+	}
+
+	review.Request = nil
+	review.Response = resp
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		klog.Errorf("Failed to encode response, %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	nveval "tkestack.io/gpu-manager/pkg/algorithm/nvidia"
+	"tkestack.io/gpu-manager/pkg/config"
+	nvtree "tkestack.io/gpu-manager/pkg/device/nvidia"
+	"tkestack.io/gpu-manager/pkg/types"
+	"tkestack.io/gpu-manager/pkg/utils"
+)
+
+//Webhook validates vcuda resources of pods with the rules of allocator,
+//and sets the default vmemory of pods which only request vcore
+type Webhook struct {
+	enableShare bool
+	blockSize   int64
+	//defaultMemory is the number of vmemory blocks, zero disables defaulting
+	defaultMemory int64
+}
+
+//NewWebhook returns a Webhook, defaultMemory is in bytes and rounded up
+//to whole vmemory blocks
+func NewWebhook(enableShare bool, blockSize int64, defaultMemory int64) (*Webhook, error) {
+	if err := config.ValidateMemoryBlockSize(blockSize); err != nil {
+		return nil, err
+	}
+
+	if defaultMemory < 0 {
+		return nil, fmt.Errorf("default memory %d is negative", defaultMemory)
+	}
+
+	return &Webhook{
+		enableShare:   enableShare,
+		blockSize:     blockSize,
+		defaultMemory: (defaultMemory + blockSize - 1) / blockSize,
+	}, nil
+}
+
+//Validate checks the vcuda request of every container in pod, the error
+//is the same as the one of allocator
+func (w *Webhook) Validate(pod *v1.Pod) error {
+	if keys := systemAnnotations(pod); len(keys) > 0 {
+		return fmt.Errorf("annotations %s are set by gpu-manager", strings.Join(keys, ", "))
+	}
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		cores, memory := w.containerRequest(c)
+		if cores == 0 && memory == 0 {
+			continue
+		}
+
+		if _, err := nveval.SelectMode(cores, memory, w.enableShare); err != nil {
+			return fmt.Errorf("container %s: %v", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+//PatchOperation is an operation of JSON patch
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+//Mutate returns the patch which sets the default vmemory for containers
+//requesting vcore less than a card without vmemory, and removes the
+//annotations only gpu-manager may set
+func (w *Webhook) Mutate(pod *v1.Pod) []PatchOperation {
+	var patch []PatchOperation
+	for _, key := range systemAnnotations(pod) {
+		patch = append(patch, PatchOperation{
+			Op:   "remove",
+			Path: "/metadata/annotations/" + escapeJSONPointer(key),
+		})
+	}
+
+	quantity := *resource.NewQuantity(w.defaultMemory, resource.DecimalSI)
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		cores, memory := w.containerRequest(c)
+		if w.defaultMemory == 0 || cores == 0 || cores >= nvtree.HundredCore || memory > 0 {
+			continue
+		}
+
+		base := fmt.Sprintf("/spec/containers/%d/resources", i)
+		patch = append(patch, PatchOperation{
+			Op:    "add",
+			Path:  base + "/limits/" + escapeJSONPointer(types.VMemoryAnnotation),
+			Value: quantity,
+		})
+
+		// extended resources in requests have been defaulted from limits
+		// before mutating admission, so vmemory is added to both
+		if c.Resources.Requests == nil {
+			patch = append(patch, PatchOperation{
+				Op:    "add",
+				Path:  base + "/requests",
+				Value: v1.ResourceList{types.VMemoryAnnotation: quantity},
+			})
+		} else {
+			patch = append(patch, PatchOperation{
+				Op:    "add",
+				Path:  base + "/requests/" + escapeJSONPointer(types.VMemoryAnnotation),
+				Value: quantity,
+			})
+		}
+	}
+
+	return patch
+}
+
+func (w *Webhook) containerRequest(c *v1.Container) (cores int64, memory int64) {
+	cores = int64(utils.GetGPUResourceOfContainer(c, types.VCoreAnnotation))
+	memory = int64(utils.GetGPUResourceOfContainer(c, types.VMemoryAnnotation)) * w.blockSize
+	return
+}
+
+//systemAnnotations returns the annotations of pod recording allocation of
+//gpu-manager, which are trusted by scheduler extender and display
+func systemAnnotations(pod *v1.Pod) []string {
+	var keys []string
+	for key := range pod.Annotations {
+		if strings.HasPrefix(key, types.VMemoryAllocatedPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
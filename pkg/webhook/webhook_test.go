@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making TKEStack available.
+ *
+ * Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+ * this file except in compliance with the License. You may obtain a copy of the
+ * License at
+ *
+ * https://opensource.org/licenses/Apache-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"tkestack.io/gpu-manager/pkg/types"
+)
+
+func newTestPod(cores, memory int64) *v1.Pod {
+	resources := v1.ResourceList{}
+	if cores > 0 {
+		resources[types.VCoreAnnotation] = resource.MustParse(fmt.Sprintf("%d", cores))
+	}
+	if memory > 0 {
+		resources[types.VMemoryAnnotation] = resource.MustParse(fmt.Sprintf("%d", memory))
+	}
+
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "sidecar"},
+				{
+					Name: "gpu",
+					Resources: v1.ResourceRequirements{
+						Limits:   resources,
+						Requests: resources.DeepCopy(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func review(t *testing.T, handler http.Handler, path string, pod *v1.Pod) *admissionv1beta1.AdmissionResponse {
+	return reviewOperation(t, handler, path, pod, admissionv1beta1.Create)
+}
+
+func reviewOperation(t *testing.T, handler http.Handler, path string, pod *v1.Pod,
+	operation admissionv1beta1.Operation) *admissionv1beta1.AdmissionResponse {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("can't marshal pod: %v", err)
+	}
+
+	body, _ := json.Marshal(&admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "uid",
+			Resource:  podResource,
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	result := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+		t.Fatalf("can't decode review: %v", err)
+	}
+	if result.APIVersion != "admission.k8s.io/v1" || result.Response == nil || result.Response.UID != "uid" {
+		t.Fatalf("unexpected review %+v", result)
+	}
+
+	return result.Response
+}
+
+func TestValidate(t *testing.T) {
+	wh, err := NewWebhook(true, types.MemoryBlockSize, 0)
+	if err != nil {
+		t.Fatalf("can't create webhook: %v", err)
+	}
+	handler := wh.Handler()
+
+	testCases := []struct {
+		cores, memory int64
+		reason        string
+	}{
+		{cores: 50, memory: 4},
+		{cores: 100},
+		{cores: 200, memory: 4},
+		{cores: 150, reason: "multiple of 100"},
+		{cores: 50, reason: "memory must be requested"},
+		{memory: 4, reason: "without cores"},
+	}
+
+	for _, tc := range testCases {
+		resp := review(t, handler, ValidatePath, newTestPod(tc.cores, tc.memory))
+		if tc.reason == "" {
+			if !resp.Allowed {
+				t.Errorf("vcore %d vmemory %d should be allowed, got %+v", tc.cores, tc.memory, resp.Result)
+			}
+			continue
+		}
+
+		if resp.Allowed || resp.Result == nil || !strings.Contains(resp.Result.Message, tc.reason) {
+			t.Errorf("vcore %d vmemory %d should be rejected with %q, got %+v", tc.cores, tc.memory, tc.reason, resp.Result)
+		}
+	}
+
+	// allocation recorded by gpu-manager can't be set by users
+	pod := newTestPod(50, 0)
+	pod.Annotations = map[string]string{types.VMemoryAllocatedPrefix + "1": "1073741824"}
+	if resp := review(t, handler, ValidatePath, pod); resp.Allowed || !strings.Contains(resp.Result.Message, "set by gpu-manager") {
+		t.Errorf("pod with allocated memory annotation should be rejected, got %+v", resp.Result)
+	}
+}
+
+func TestMutate(t *testing.T) {
+	wh, err := NewWebhook(true, types.MemoryBlockSize, 900<<20)
+	if err != nil {
+		t.Fatalf("can't create webhook: %v", err)
+	}
+	handler := wh.Handler()
+
+	for _, pod := range []*v1.Pod{newTestPod(50, 2), newTestPod(100, 0), newTestPod(0, 0)} {
+		if resp := review(t, handler, MutatePath, pod); !resp.Allowed || resp.Patch != nil {
+			t.Errorf("pod should not be patched, got %s", resp.Patch)
+		}
+	}
+
+	resp := review(t, handler, MutatePath, newTestPod(50, 0))
+	if !resp.Allowed || resp.PatchType == nil || *resp.PatchType != admissionv1beta1.PatchTypeJSONPatch {
+		t.Fatalf("pod should be patched, got %+v", resp)
+	}
+
+	var patch []PatchOperation
+	if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+		t.Fatalf("can't decode patch: %v", err)
+	}
+
+	expect := map[string]interface{}{
+		"/spec/containers/1/resources/limits/tencent.com~1vcuda-memory":   "4",
+		"/spec/containers/1/resources/requests/tencent.com~1vcuda-memory": "4",
+	}
+	if len(patch) != len(expect) {
+		t.Fatalf("unexpected patch %+v", patch)
+	}
+	for _, op := range patch {
+		if op.Op != "add" || expect[op.Path] != op.Value {
+			t.Errorf("unexpected patch operation %+v", op)
+		}
+	}
+
+	pod := newTestPod(50, 2)
+	pod.Annotations = map[string]string{types.VMemoryAllocatedPrefix + "1": "1073741824", "other": "value"}
+	resp = review(t, handler, MutatePath, pod)
+	if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+		t.Fatalf("can't decode patch: %v", err)
+	}
+
+	if len(patch) != 1 || patch[0].Op != "remove" || patch[0].Path != "/metadata/annotations/tencent.com~1vcuda-memory-allocated-1" {
+		t.Fatalf("allocated memory annotation should be removed, got %+v", patch)
+	}
+}
+
+func TestUpdateNotAdmitted(t *testing.T) {
+	wh, err := NewWebhook(true, types.MemoryBlockSize, 1<<30)
+	if err != nil {
+		t.Fatalf("can't create webhook: %v", err)
+	}
+	handler := wh.Handler()
+
+	pod := newTestPod(50, 0)
+	if resp := reviewOperation(t, handler, ValidatePath, pod, admissionv1beta1.Update); !resp.Allowed {
+		t.Errorf("update should be allowed, got %+v", resp.Result)
+	}
+
+	if resp := reviewOperation(t, handler, MutatePath, pod, admissionv1beta1.Update); !resp.Allowed || resp.Patch != nil {
+		t.Errorf("update should not be patched, got %s", resp.Patch)
+	}
+}